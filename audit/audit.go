@@ -0,0 +1,224 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/webpa-common/device"
+	"github.com/xmidt-org/webpa-common/logging"
+)
+
+// Supported sink types.
+const (
+	FileSinkType = "file"
+)
+
+var errUnknownSinkType = errors.New("unknown audit sink type")
+
+// Record describes a mutating operation along with its outcome.
+type Record struct {
+	Time       time.Time   `json:"time"`
+	TID        string      `json:"tid,omitempty"`
+	Principal  string      `json:"satClientID"`
+	PartnerIDs []string    `json:"partnerIDs,omitempty"`
+	DeviceID   string      `json:"deviceID,omitempty"`
+	Operation  string      `json:"operation"`
+	Parameters []Parameter `json:"parameters,omitempty"`
+
+	// Target is what the operation applies to when it is not a device (i.e. a webhook URL).
+	Target string `json:"target,omitempty"`
+
+	// Code is the HTTP status code the caller got.
+	Code int `json:"code"`
+}
+
+// Parameter is a parameter (or table) name along with its value. Values of sensitive
+// parameters are expected to be masked already.
+type Parameter struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Sink stores audit records.
+type Sink interface {
+	Write(ctx context.Context, r Record) error
+}
+
+// Reader is implemented by the sinks that can be queried.
+type Reader interface {
+	Query(ctx context.Context, f Filter) ([]Record, error)
+}
+
+// Filter selects records. Zero fields select all records.
+type Filter struct {
+	DeviceID  string
+	Principal string
+
+	// From (inclusive) and To (exclusive) bound the time of the records.
+	From time.Time
+	To   time.Time
+
+	// Limit keeps the most recent records only.
+	Limit int
+
+	// Scope, when set, restricts records to the ones visible to a caller.
+	Scope *Scope
+}
+
+// Scope describes what a caller may see of the audit trail: the records of its own
+// principal and the ones sharing one of its partner IDs.
+type Scope struct {
+	Principal  string
+	PartnerIDs []string
+}
+
+func (s *Scope) allows(r Record) bool {
+	if s.Principal != "" && s.Principal == r.Principal {
+		return true
+	}
+
+	for _, p := range s.PartnerIDs {
+		for _, rp := range r.PartnerIDs {
+			if p == rp {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f Filter) matches(r Record) bool {
+	switch {
+	case f.Scope != nil && !f.Scope.allows(r):
+		return false
+	case f.DeviceID != "" && f.DeviceID != r.DeviceID:
+		return false
+	case f.Principal != "" && f.Principal != r.Principal:
+		return false
+	case !f.From.IsZero() && r.Time.Before(f.From):
+		return false
+	case !f.To.IsZero() && !r.Time.Before(f.To):
+		return false
+	}
+	return true
+}
+
+// Config selects and configures the audit sink.
+type Config struct {
+	// Type is the type of sink. Only "file" is supported.
+	// (Optional) Defaults to "file".
+	Type string
+
+	File FileConfig
+
+	// ReadCapability is the token capability required to query records through GET /audit.
+	// (Optional) If not set, the query route is disabled.
+	ReadCapability string
+
+	// AdminCapability allows querying the records of all principals and partners.
+	// (Optional) By default, callers only get their own records and the ones sharing
+	// one of their token partner IDs.
+	AdminCapability string
+}
+
+// NewSink creates the sink described by the config.
+func NewSink(c Config) (Sink, error) {
+	switch c.Type {
+	case "", FileSinkType:
+		return NewFileSink(c.File)
+	}
+	return nil, fmt.Errorf("%w: %s", errUnknownSinkType, c.Type)
+}
+
+// DescribeFunc returns the record of the operation a request carries out given its body.
+// Requests it returns false for are not audited.
+type DescribeFunc func(r *http.Request, body []byte) (Record, bool)
+
+// Middleware is an Alice-style constructor that writes an audit record for every request
+// describe returns one for. Time, TID, principal, device ID and outcome code are filled in
+// when describe leaves them empty. A nil sink disables auditing.
+func Middleware(sink Sink, logger kitlog.Logger, describe DescribeFunc) func(http.Handler) http.Handler {
+	return func(delegate http.Handler) http.Handler {
+		if sink == nil {
+			return delegate
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			r.Body.Close()
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			if err != nil {
+				delegate.ServeHTTP(w, r)
+				return
+			}
+
+			record, ok := describe(r, body)
+			if !ok {
+				delegate.ServeHTTP(w, r)
+				return
+			}
+
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+			delegate.ServeHTTP(sw, r)
+
+			fill(&record, r, sw)
+			if err := sink.Write(r.Context(), record); err != nil {
+				logging.Error(logger).Log(logging.MessageKey(), "Failed to write audit record", "tid", record.TID,
+					"operation", record.Operation, logging.ErrorKey(), err)
+			}
+		})
+	}
+}
+
+func fill(record *Record, r *http.Request, sw *statusWriter) {
+	record.Code = sw.code
+
+	if record.Time.IsZero() {
+		record.Time = time.Now().UTC()
+	}
+
+	if record.TID == "" {
+		// handlers generate a TID when the caller did not provide one
+		if record.TID = r.Header.Get(common.HeaderWPATID); record.TID == "" {
+			record.TID = sw.Header().Get(common.HeaderWPATID)
+		}
+	}
+
+	if record.Principal == "" {
+		if auth, ok := bascule.FromContext(r.Context()); ok {
+			record.Principal = auth.Token.Principal()
+		}
+	}
+
+	if id, err := device.ParseID(record.DeviceID); err == nil {
+		record.DeviceID = string(id)
+	}
+}
+
+// statusWriter remembers the status code written to the response.
+type statusWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.code, w.wroteHeader = code, true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
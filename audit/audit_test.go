@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/common"
+)
+
+type sinkFunc func(context.Context, Record) error
+
+func (f sinkFunc) Write(ctx context.Context, r Record) error {
+	return f(ctx, r)
+}
+
+func TestFilterMatches(t *testing.T) {
+	now := time.Now()
+	r := Record{Time: now, DeviceID: "mac:112233445566", Principal: "client0", PartnerIDs: []string{"partnerA"}}
+
+	testCases := []struct {
+		Name    string
+		Filter  Filter
+		Matches bool
+	}{
+		{Name: "Empty", Matches: true},
+		{Name: "Device", Filter: Filter{DeviceID: "mac:112233445566"}, Matches: true},
+		{Name: "Other device", Filter: Filter{DeviceID: "mac:aabbccddeeff"}},
+		{Name: "Principal", Filter: Filter{Principal: "client0"}, Matches: true},
+		{Name: "Other principal", Filter: Filter{Principal: "client1"}},
+		{Name: "Within range", Filter: Filter{From: now, To: now.Add(time.Second)}, Matches: true},
+		{Name: "Before range", Filter: Filter{From: now.Add(time.Nanosecond)}},
+		{Name: "After range", Filter: Filter{To: now}},
+		{Name: "Own record", Filter: Filter{Scope: &Scope{Principal: "client0"}}, Matches: true},
+		{Name: "Partner record", Filter: Filter{Scope: &Scope{Principal: "client1", PartnerIDs: []string{"partnerB", "partnerA"}}}, Matches: true},
+		{Name: "Foreign record", Filter: Filter{Scope: &Scope{Principal: "client1", PartnerIDs: []string{"partnerB"}}}},
+		{Name: "Empty scope", Filter: Filter{Scope: &Scope{}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Matches, tc.Filter.matches(r))
+		})
+	}
+}
+
+func TestNewSink(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := NewSink(Config{Type: "kafka"})
+	assert.True(errors.Is(err, errUnknownSinkType))
+
+	_, err = NewSink(Config{})
+	assert.Equal(errMissingPath, err)
+}
+
+func TestMiddleware(t *testing.T) {
+	assert := assert.New(t)
+
+	var records []Record
+	sink := sinkFunc(func(_ context.Context, r Record) error {
+		records = append(records, r)
+		return nil
+	})
+
+	describe := func(r *http.Request, body []byte) (Record, bool) {
+		if r.Method == http.MethodGet {
+			return Record{}, false
+		}
+		return Record{Operation: "SET", DeviceID: "mac:11-22-33-44-55-66", Parameters: []Parameter{{Name: string(body)}}}, true
+	}
+
+	handler := Middleware(sink, log.NewNopLogger(), describe)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			body, _ := ioutil.ReadAll(r.Body)
+			assert.Equal("Device.Time.Enable", string(body))
+		}
+		w.Header().Set(common.HeaderWPATID, "generated-tid")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	r := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader("Device.Time.Enable"))
+	r = r.WithContext(bascule.WithAuthentication(r.Context(), bascule.Authentication{
+		Token: bascule.NewToken("jwt", "client0", bascule.NewAttributes(nil)),
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if assert.Len(records, 1) {
+		record := records[0]
+		assert.Equal("SET", record.Operation)
+		assert.Equal("mac:112233445566", record.DeviceID)
+		assert.Equal("client0", record.Principal)
+		assert.Equal("generated-tid", record.TID)
+		assert.Equal(http.StatusAccepted, record.Code)
+		assert.Equal([]Parameter{{Name: "Device.Time.Enable"}}, record.Parameters)
+		assert.False(record.Time.IsZero())
+	}
+}
+
+func TestMiddlewareNoSink(t *testing.T) {
+	delegate := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	handler := Middleware(nil, log.NewNopLogger(), nil)(delegate)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPatch, "/", nil))
+}
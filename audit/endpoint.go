@@ -0,0 +1,13 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+func makeQueryEndpoint(r Reader) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return r.Query(ctx, *request.(*Filter))
+	}
+}
@@ -0,0 +1,259 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Defaults of the file sink.
+const (
+	DefaultMaxSize    = 10 << 20
+	DefaultMaxBackups = 5
+)
+
+// MaxQueryLimit caps the records a query returns. Queries without a limit, or with a
+// bigger one, get the most recent MaxQueryLimit records.
+const MaxQueryLimit = 1000
+
+var errMissingPath = errors.New("audit file path is required")
+
+// FileConfig configures the JSON-lines file sink.
+type FileConfig struct {
+	// Path is the file records are appended to.
+	Path string
+
+	// MaxSize is the size in bytes after which the file is rotated.
+	// (Optional) Defaults to 10MiB.
+	MaxSize int64
+
+	// MaxBackups is the number of rotated files kept as Path.1 (the most recent) to Path.MaxBackups.
+	// (Optional) Defaults to 5.
+	MaxBackups int
+}
+
+// FileSink appends records as JSON lines to a file which is rotated once it gets too big.
+// It can be queried, in which case the rotated files are searched too.
+type FileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	lock sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewFileSink opens (or creates) the file records are appended to.
+func NewFileSink(c FileConfig) (*FileSink, error) {
+	if c.Path == "" {
+		return nil, errMissingPath
+	}
+
+	if c.MaxSize <= 0 {
+		c.MaxSize = DefaultMaxSize
+	}
+
+	if c.MaxBackups <= 0 {
+		c.MaxBackups = DefaultMaxBackups
+	}
+
+	f := &FileSink{
+		path:       c.Path,
+		maxSize:    c.MaxSize,
+		maxBackups: c.MaxBackups,
+	}
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *FileSink) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// Write appends the record to the file, rotating it first if the record does not fit.
+func (f *FileSink) Write(_ context.Context, r Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+
+	var rotateErr error
+	if f.size > 0 && f.size+int64(len(line)) > f.maxSize {
+		// records are still written to the current file when it cannot be rotated
+		if rotateErr = f.rotate(); f.file == nil {
+			return rotateErr
+		}
+	}
+
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+	if err == nil && rotateErr != nil {
+		err = fmt.Errorf("record written but rotation failed: %w", rotateErr)
+	}
+	return err
+}
+
+// rotate shifts the backups, dropping the oldest one, and starts a new file.
+// Whatever fails, the file at the path is open afterwards unless it cannot be opened.
+func (f *FileSink) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err == nil {
+		err = f.shift()
+	}
+
+	if openErr := f.open(); err == nil {
+		err = openErr
+	}
+	return err
+}
+
+func (f *FileSink) shift() error {
+	for n := f.maxBackups - 1; n > 0; n-- {
+		if err := os.Rename(f.backup(n), f.backup(n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(f.path, f.backup(1))
+}
+
+func (f *FileSink) backup(n int) string {
+	return fmt.Sprintf("%s.%d", f.path, n)
+}
+
+// Query returns the most recent matching records, oldest first, up to the filter limit or
+// MaxQueryLimit. Lines which cannot be decoded are skipped.
+func (f *FileSink) Query(ctx context.Context, filter Filter) ([]Record, error) {
+	if filter.Limit <= 0 || filter.Limit > MaxQueryLimit {
+		filter.Limit = MaxQueryLimit
+	}
+
+	snapshot, err := f.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, r := range snapshot {
+			r.Close()
+		}
+	}()
+
+	records := []Record{}
+	for _, r := range snapshot {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if records, err = readRecords(r, filter, records); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(records) > filter.Limit {
+		records = records[len(records)-filter.Limit:]
+	}
+	return records, nil
+}
+
+// snapshotReader reads a file as it was when the snapshot was taken.
+type snapshotReader struct {
+	io.Reader
+	io.Closer
+}
+
+// snapshot opens the files, oldest first, and bounds the current one to its size at
+// that time. Reading the snapshot needs no lock as rotations only rename files.
+func (f *FileSink) snapshot() ([]snapshotReader, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var snapshot []snapshotReader
+	for n := f.maxBackups; n >= 0; n-- {
+		path := f.path
+		if n > 0 {
+			path = f.backup(n)
+		}
+
+		file, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			for _, r := range snapshot {
+				r.Close()
+			}
+			return nil, err
+		}
+
+		var r io.Reader = file
+		if n == 0 {
+			r = io.LimitReader(file, f.size)
+		}
+		snapshot = append(snapshot, snapshotReader{Reader: r, Closer: file})
+	}
+	return snapshot, nil
+}
+
+func readRecords(r io.Reader, filter Filter, records []Record) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4<<20)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+
+		if !filter.matches(r) {
+			continue
+		}
+
+		// only the most recent records are kept, so memory is bounded by the limit
+		if len(records) >= 2*filter.Limit {
+			records = append(records[:0], records[len(records)-filter.Limit:]...)
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
+
+// Close closes the file. Later writes fail.
+func (f *FileSink) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
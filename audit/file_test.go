@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSink(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.json")
+	f, err := NewFileSink(FileConfig{Path: path, MaxSize: 300, MaxBackups: 2})
+	require.NoError(err)
+
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		principal := "client0"
+		if i%2 == 1 {
+			principal = "client1"
+		}
+
+		require.NoError(f.Write(context.Background(), Record{
+			Time:      start.Add(time.Duration(i) * time.Minute),
+			Principal: principal,
+			DeviceID:  "mac:112233445566",
+			Operation: "SET",
+			Code:      200,
+		}))
+	}
+
+	// rotation keeps the file under the max size and drops the oldest backups
+	info, err := os.Stat(path)
+	require.NoError(err)
+	assert.True(info.Size() <= 300)
+	_, err = os.Stat(path + ".2")
+	assert.Nil(err)
+	_, err = os.Stat(path + ".3")
+	assert.True(os.IsNotExist(err))
+
+	all, err := f.Query(context.Background(), Filter{})
+	require.NoError(err)
+	require.NotEmpty(all)
+	assert.True(len(all) < 10)
+	for i := 1; i < len(all); i++ {
+		assert.True(all[i-1].Time.Before(all[i].Time))
+	}
+	assert.Equal(start.Add(9*time.Minute), all[len(all)-1].Time)
+
+	filtered, err := f.Query(context.Background(), Filter{Principal: "client1", Limit: 1})
+	require.NoError(err)
+	if assert.Len(filtered, 1) {
+		assert.Equal(start.Add(9*time.Minute), filtered[0].Time)
+	}
+
+	ranged, err := f.Query(context.Background(), Filter{From: start.Add(8 * time.Minute), To: start.Add(9 * time.Minute)})
+	require.NoError(err)
+	if assert.Len(ranged, 1) {
+		assert.Equal("client0", ranged[0].Principal)
+	}
+
+	// records survive reopening the sink
+	require.NoError(f.Close())
+	assert.Equal(os.ErrClosed, f.Write(context.Background(), Record{}))
+
+	f, err = NewFileSink(FileConfig{Path: path, MaxSize: 300, MaxBackups: 2})
+	require.NoError(err)
+	defer f.Close()
+
+	reopened, err := f.Query(context.Background(), Filter{})
+	require.NoError(err)
+	assert.Equal(all, reopened)
+}
+
+func TestFileSinkRotationFailure(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.json")
+	f, err := NewFileSink(FileConfig{Path: path, MaxSize: 100, MaxBackups: 1})
+	require.NoError(err)
+	defer f.Close()
+
+	// a non-empty directory in the way of the backup makes rotations fail
+	require.NoError(os.MkdirAll(filepath.Join(path+".1", "blocker"), 0700))
+
+	record := Record{Principal: "client0", Operation: "SET", Code: 200}
+	require.NoError(f.Write(context.Background(), record))
+	assert.Error(f.Write(context.Background(), record))
+
+	// records keep being written to the current file
+	require.NoError(os.RemoveAll(path + ".1"))
+	assert.NoError(f.Write(context.Background(), record))
+
+	all, err := f.Query(context.Background(), Filter{})
+	require.NoError(err)
+	assert.Len(all, 3)
+}
+
+func TestFileSinkQueryLimit(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "audit")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	f, err := NewFileSink(FileConfig{Path: filepath.Join(dir, "audit.json")})
+	require.NoError(err)
+	defer f.Close()
+
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < MaxQueryLimit+10; i++ {
+		require.NoError(f.Write(context.Background(), Record{Time: start.Add(time.Duration(i) * time.Second), Operation: "SET"}))
+	}
+
+	for _, limit := range []int{0, MaxQueryLimit + 1} {
+		records, err := f.Query(context.Background(), Filter{Limit: limit})
+		require.NoError(err)
+		if assert.Len(records, MaxQueryLimit) {
+			assert.Equal(start.Add(10*time.Second), records[0].Time)
+			assert.Equal(start.Add(time.Duration(MaxQueryLimit+9)*time.Second), records[len(records)-1].Time)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/justinas/alice"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/webpa-common/device"
+)
+
+// Query parameters supported by the audit query route.
+const (
+	deviceIDQueryKey  = "deviceID"
+	principalQueryKey = "principal"
+	fromQueryKey      = "from"
+	toQueryKey        = "to"
+	limitQueryKey     = "limit"
+)
+
+// Errors of the audit query route.
+var (
+	ErrInvalidTimeRange = common.NewBadRequestError(errors.New("from and to must be RFC3339 timestamps with from before to"))
+	ErrInvalidLimit     = common.NewBadRequestError(errors.New("limit must be a positive integer"))
+	ErrForbiddenQuery   = common.NewProblemError(errors.New("token lacks the capability to read audit records"), http.StatusForbidden, "forbidden-audit-query", "Forbidden audit query")
+)
+
+// Options wraps the properties needed to set up the audit query server
+type Options struct {
+	R Reader
+
+	//APIRouter is assumed to be a subrouter with the API prefix path (i.e. 'api/v2')
+	APIRouter                   *mux.Router
+	Authenticate                *alice.Chain
+	Log                         kitlog.Logger
+	ReducedLoggingResponseCodes []int
+
+	//ReadCapability is the token capability required to query records.
+	ReadCapability string
+
+	//AdminCapability allows querying the records of all principals and partners.
+	//(Optional) By default, callers only get their own records and the ones sharing
+	//one of their token partner IDs.
+	AdminCapability string
+
+	//Runtime, when set, overrides ReducedLoggingResponseCodes with the ones of its current snapshot.
+	//(Optional)
+	Runtime *common.Runtime
+}
+
+// ConfigHandler sets up the route to query audit records by device, principal and time range.
+// Only callers with the read capability may query records.
+func ConfigHandler(c *Options) {
+	transactionLogging := common.TransactionLogging(c.ReducedLoggingResponseCodes, c.Log)
+	if c.Runtime != nil {
+		transactionLogging = common.RuntimeTransactionLogging(c.Runtime, c.Log)
+	}
+
+	opts := []kithttp.ServerOption{
+		kithttp.ServerBefore(common.Capture(c.Log)),
+		kithttp.ServerErrorEncoder(common.ErrorLogEncoder(c.Log, encodeError)),
+		kithttp.ServerFinalizer(transactionLogging),
+	}
+
+	queryHandler := kithttp.NewServer(
+		makeQueryEndpoint(c.R),
+		decodeQueryRequest(c.ReadCapability, c.AdminCapability),
+		encodeQueryResponse,
+		opts...,
+	)
+
+	c.APIRouter.Handle("/audit", c.Authenticate.Then(common.Welcome(queryHandler))).Methods(http.MethodGet)
+}
+
+func decodeQueryRequest(readCapability, adminCapability string) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		if !common.HasCapability(ctx, readCapability) {
+			return nil, ErrForbiddenQuery
+		}
+
+		f, err := decodeFilter(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if !common.HasCapability(ctx, adminCapability) {
+			f.Scope = new(Scope)
+			if auth, ok := bascule.FromContext(ctx); ok {
+				f.Scope.Principal = auth.Token.Principal()
+			}
+			// partner ID headers can be set by anyone, so only token partner IDs are used
+			f.Scope.PartnerIDs, _ = common.TokenPartnerIDs(ctx)
+		}
+		return f, nil
+	}
+}
+
+func decodeFilter(r *http.Request) (*Filter, error) {
+	query := r.URL.Query()
+	f := Filter{
+		Principal: query.Get(principalQueryKey),
+	}
+
+	if deviceID := query.Get(deviceIDQueryKey); deviceID != "" {
+		id, err := device.ParseID(deviceID)
+		if err != nil {
+			return nil, common.NewBadRequestError(err)
+		}
+		f.DeviceID = string(id)
+	}
+
+	var err error
+	if f.From, err = parseTime(query.Get(fromQueryKey)); err != nil {
+		return nil, ErrInvalidTimeRange
+	}
+
+	if f.To, err = parseTime(query.Get(toQueryKey)); err != nil {
+		return nil, ErrInvalidTimeRange
+	}
+
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	if limit := query.Get(limitQueryKey); limit != "" {
+		if f.Limit, err = strconv.Atoi(limit); err != nil || f.Limit <= 0 {
+			return nil, ErrInvalidLimit
+		}
+	}
+
+	return &f, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func encodeQueryResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set(common.HeaderWPATID, ctx.Value(common.ContextKeyRequestTID).(string))
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	common.WriteError(ctx, err, w)
+}
@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/webpa-common/basculechecks"
+)
+
+func TestDecodeQueryRequest(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Query       string
+		Expected    *Filter
+		ExpectedErr error
+	}{
+		{Name: "Empty", Expected: &Filter{}},
+		{
+			Name:  "Full",
+			Query: "deviceID=mac:11-22-33-44-55-66&principal=client0&from=2021-03-01T00:00:00Z&to=2021-03-02T00:00:00Z&limit=10",
+			Expected: &Filter{
+				DeviceID:  "mac:112233445566",
+				Principal: "client0",
+				From:      time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC),
+				To:        time.Date(2021, 3, 2, 0, 0, 0, 0, time.UTC),
+				Limit:     10,
+			},
+		},
+		{Name: "Bad from", Query: "from=yesterday", ExpectedErr: ErrInvalidTimeRange},
+		{Name: "Bad to", Query: "to=tomorrow", ExpectedErr: ErrInvalidTimeRange},
+		{Name: "Inverted range", Query: "from=2021-03-02T00:00:00Z&to=2021-03-01T00:00:00Z", ExpectedErr: ErrInvalidTimeRange},
+		{Name: "Bad limit", Query: "limit=-1", ExpectedErr: ErrInvalidLimit},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			r := httptest.NewRequest(http.MethodGet, "/audit?"+tc.Query, nil)
+			f, err := decodeFilter(r)
+			assert.Equal(tc.ExpectedErr, err)
+			if tc.ExpectedErr == nil {
+				assert.Equal(tc.Expected, f)
+			}
+		})
+	}
+
+	_, err := decodeFilter(httptest.NewRequest(http.MethodGet, "/audit?deviceID=nope", nil))
+	assert.NotNil(t, err)
+}
+
+func capabilityContext(principal string, capabilities []string, partners ...string) context.Context {
+	return bascule.WithAuthentication(context.Background(), bascule.Authentication{
+		Token: bascule.NewToken("jwt", principal, bascule.NewAttributes(map[string]interface{}{
+			basculechecks.CapabilityKey: capabilities,
+			"allowedResources":          map[string]interface{}{"allowedPartners": partners},
+		})),
+	})
+}
+
+func TestDecodeQueryRequestAccess(t *testing.T) {
+	assert := assert.New(t)
+	decode := decodeQueryRequest("audit:read", "audit:admin")
+	r := httptest.NewRequest(http.MethodGet, "/audit?principal=client1", nil)
+	r.Header.Set("X-Xmidt-Partner-Id", "partnerB")
+
+	_, err := decode(capabilityContext("client0", nil, "partnerA"), r)
+	assert.Equal(ErrForbiddenQuery, err)
+
+	// partner ID headers don't widen the scope of callers
+	f, err := decode(capabilityContext("client0", []string{"audit:read"}, "partnerA"), r)
+	assert.Nil(err)
+	assert.Equal(&Filter{Principal: "client1", Scope: &Scope{Principal: "client0", PartnerIDs: []string{"partnerA"}}}, f)
+
+	f, err = decode(capabilityContext("client0", []string{"audit:read", "audit:admin"}, "partnerA"), r)
+	assert.Nil(err)
+	assert.Equal(&Filter{Principal: "client1"}, f)
+
+	_, err = decodeQueryRequest("", "")(capabilityContext("client0", []string{""}), r)
+	assert.Equal(ErrForbiddenQuery, err)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/xmidt-org/tr1d1um/common"
+)
+
+// Error codes of the requests rejected by the auth middleware.
+const (
+	errorCodeUnauthorized = "unauthorized"
+	errorCodeForbidden    = "forbidden"
+)
+
+var (
+	errUnauthorized = common.NewProblemError(errors.New("missing or invalid credentials"), http.StatusUnauthorized, errorCodeUnauthorized, "Unauthorized")
+	errForbidden    = common.NewProblemError(errors.New("credentials not allowed to make the request"), http.StatusForbidden, errorCodeForbidden, "Forbidden")
+)
+
+// authError returns the error of the requests the auth middleware rejects with statusCode.
+func authError(statusCode int) error {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return errUnauthorized
+	case http.StatusForbidden:
+		return errForbidden
+	default:
+		return common.NewCodedError(errors.New(http.StatusText(statusCode)), statusCode)
+	}
+}
+
+// authErrorWriter rewrites the error responses of the bascule constructor and enforcer, which
+// are bare status codes or plain text, as the error bodies of the API.
+type authErrorWriter struct {
+	http.ResponseWriter
+	ctx         context.Context
+	wroteHeader bool
+}
+
+func (w *authErrorWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	common.WriteError(w.ctx, authError(statusCode), w.ResponseWriter)
+}
+
+// Write drops the plain text bodies bascule writes after the status code.
+func (w *authErrorWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	return len(b), nil
+}
+
+// authErrors wraps the response writer of the bascule constructor and enforcer with an
+// authErrorWriter. authPassed must follow them in the chain to hand the original writer
+// to the requests they accept.
+func authErrors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), common.ContextKeyRequestTID, r.Header.Get(common.HeaderWPATID))
+		ctx = context.WithValue(ctx, common.ContextKeyRequestAccept, r.Header.Get("Accept"))
+		next.ServeHTTP(&authErrorWriter{ResponseWriter: w, ctx: ctx}, r)
+	})
+}
+
+// authPassed unwraps the response writer wrapped by authErrors.
+func authPassed(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if aw, ok := w.(*authErrorWriter); ok {
+			w = aw.ResponseWriter
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/bascule/basculehttp"
+	"github.com/xmidt-org/tr1d1um/common"
+)
+
+func TestAuthErrors(t *testing.T) {
+	var (
+		chain = alice.New(
+			authErrors,
+			basculehttp.NewConstructor(
+				basculehttp.WithTokenFactory("Basic", basculehttp.BasicTokenFactory{"user": "pass", "guest": "pass"}),
+			),
+			basculehttp.NewEnforcer(
+				basculehttp.WithRules("Basic", bascule.Validators{
+					bascule.ValidatorFunc(func(_ context.Context, token bascule.Token) error {
+						if token.Principal() == "user" {
+							return nil
+						}
+						return errors.New("principal not allowed")
+					}),
+				}),
+			),
+			authPassed,
+		)
+
+		handler = chain.ThenFunc(func(w http.ResponseWriter, _ *http.Request) {
+			// errors of the handlers are written as is
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("handler"))
+		})
+	)
+
+	testCases := []struct {
+		Name         string
+		Username     string
+		Password     string
+		ExpectedCode string
+		Expected     int
+	}{
+		{Name: "Missing Credentials", ExpectedCode: errorCodeUnauthorized, Expected: http.StatusUnauthorized},
+		{Name: "Invalid Credentials", Username: "user", Password: "wrong", ExpectedCode: errorCodeUnauthorized, Expected: http.StatusUnauthorized},
+		{Name: "Failed Checks", Username: "guest", Password: "pass", ExpectedCode: errorCodeForbidden, Expected: http.StatusForbidden},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			r := httptest.NewRequest(http.MethodGet, "/api/v2/device", nil)
+			r.Header.Set("Accept", common.ProblemContentType)
+			r.Header.Set(common.HeaderWPATID, "tid")
+			if testCase.Username != "" {
+				r.SetBasicAuth(testCase.Username, testCase.Password)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, r)
+
+			assert.Equal(testCase.Expected, recorder.Code)
+			assert.Equal(common.ProblemContentType, recorder.Header().Get("Content-Type"))
+
+			var problem common.Problem
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+			assert.Equal(testCase.Expected, problem.Status)
+			assert.Equal(testCase.ExpectedCode, problem.Code)
+			assert.Equal("tid", problem.TID)
+		})
+	}
+
+	t.Run("Legacy Body", func(t *testing.T) {
+		assert := assert.New(t)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/v2/device", nil))
+
+		assert.Equal(http.StatusUnauthorized, recorder.Code)
+		assert.Equal("Bearer", recorder.Header().Get(basculehttp.AuthTypeHeaderKey))
+		assert.JSONEq(`{"message": "missing or invalid credentials"}`, recorder.Body.String())
+	})
+
+	t.Run("Accepted", func(t *testing.T) {
+		assert := assert.New(t)
+		r := httptest.NewRequest(http.MethodGet, "/api/v2/device", nil)
+		r.SetBasicAuth("user", "pass")
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, r)
+
+		assert.Equal(http.StatusForbidden, recorder.Code)
+		assert.Equal("handler", recorder.Body.String())
+	})
+}
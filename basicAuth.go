@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-kit/kit/log"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/bascule/basculehttp"
+	"github.com/xmidt-org/webpa-common/logging"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	errUnsupportedHash = errors.New("unsupported password hash. Only bcrypt and argon2 hashes are supported")
+	errInvalidArgon2   = errors.New("malformed argon2 hash")
+	errMalformedLine   = errors.New("credentials line must have the form 'username:hash'")
+)
+
+// BasicAuthConfig provides hashed credentials for Basic authentication. Hashes are
+// either bcrypt ($2a$, $2b$ or $2y$) or argon2 ($argon2id$ or $argon2i$ in the PHC string format).
+type BasicAuthConfig struct {
+	// Credentials are the username and password hash pairs allowed.
+	Credentials []BasicCredential
+
+	// File is the path of an htpasswd-style file with one 'username:hash' pair per line.
+	// The file is reloaded whenever it changes. Its credentials take precedence over
+	// the ones in the config.
+	File string
+}
+
+// BasicCredential is a username along with the hash of its password.
+type BasicCredential struct {
+	Username string
+	Hash     string
+}
+
+// hashedBasicTokenFactory is a Basic token factory which verifies passwords against their hash.
+// Plaintext credentials are still supported for backwards compatibility with authHeader.
+type hashedBasicTokenFactory struct {
+	plaintext map[string]string
+	hashed    map[string]string
+
+	// fromFile holds the credentials of the file as a map[string]string
+	fromFile atomic.Value
+
+	// verified remembers the credentials which matched their hash so the (purposely)
+	// expensive hash functions only run once per credentials and hash.
+	verified sync.Map
+}
+
+func newHashedBasicTokenFactory(plaintext map[string]string, c BasicAuthConfig) *hashedBasicTokenFactory {
+	f := &hashedBasicTokenFactory{
+		plaintext: plaintext,
+		hashed:    make(map[string]string, len(c.Credentials)),
+	}
+
+	for _, credential := range c.Credentials {
+		f.hashed[credential.Username] = credential.Hash
+	}
+
+	f.fromFile.Store(map[string]string{})
+	return f
+}
+
+// ParseAndValidate expects the given value to be a base64 encoded string with
+// the username followed by a colon and then the password.
+func (f *hashedBasicTokenFactory) ParseAndValidate(_ context.Context, _ *http.Request, _ bascule.Authorization, value string) (bascule.Token, error) {
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode string: %v", err)
+	}
+
+	i := bytes.IndexByte(decoded, ':')
+	if i <= 0 {
+		return nil, basculehttp.ErrorMalformedValue
+	}
+
+	principal, password := string(decoded[:i]), decoded[i+1:]
+
+	hash, ok := f.fromFile.Load().(map[string]string)[principal]
+	if !ok {
+		hash, ok = f.hashed[principal]
+	}
+
+	switch {
+	case ok:
+		if err = f.verify(decoded, password, hash); err != nil {
+			return nil, basculehttp.ErrorInvalidPassword
+		}
+	case f.plaintext != nil:
+		expected, found := f.plaintext[principal]
+		if !found {
+			return nil, basculehttp.ErrorPrincipalNotFound
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), password) != 1 {
+			return nil, basculehttp.ErrorInvalidPassword
+		}
+	default:
+		return nil, basculehttp.ErrorPrincipalNotFound
+	}
+
+	return bascule.NewToken("basic", principal, bascule.NewAttributes(map[string]interface{}{})), nil
+}
+
+func (f *hashedBasicTokenFactory) verify(credentials, password []byte, hash string) error {
+	key := sha256.Sum256(credentials)
+	if h, ok := f.verified.Load(key); ok && h.(string) == hash {
+		return nil
+	}
+
+	if err := compareHash(hash, password); err != nil {
+		return err
+	}
+
+	f.verified.Store(key, hash)
+	return nil
+}
+
+// compareHash checks that password matches the bcrypt or argon2 hash.
+func compareHash(hash string, password []byte) error {
+	switch {
+	case strings.HasPrefix(hash, "$2"):
+		return bcrypt.CompareHashAndPassword([]byte(hash), password)
+	case strings.HasPrefix(hash, "$argon2"):
+		return compareArgon2(hash, password)
+	}
+	return errUnsupportedHash
+}
+
+// compareArgon2 checks password against a hash in the PHC string format, i.e.
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash> where salt and hash are base64 encoded.
+func compareArgon2(hash string, password []byte) error {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return errInvalidArgon2
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return errInvalidArgon2
+	}
+
+	var (
+		memory  uint32
+		time    uint32
+		threads uint8
+	)
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return errInvalidArgon2
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return errInvalidArgon2
+	}
+
+	expected, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(expected) == 0 {
+		return errInvalidArgon2
+	}
+
+	var actual []byte
+	switch parts[1] {
+	case "argon2id":
+		actual = argon2.IDKey(password, salt, time, memory, threads, uint32(len(expected)))
+	case "argon2i":
+		actual = argon2.Key(password, salt, time, memory, threads, uint32(len(expected)))
+	default:
+		return errUnsupportedHash
+	}
+
+	if subtle.ConstantTimeCompare(expected, actual) != 1 {
+		return bcrypt.ErrMismatchedHashAndPassword
+	}
+	return nil
+}
+
+// loadFile replaces the file credentials with the ones in the given file content.
+func (f *hashedBasicTokenFactory) loadFile(file string, content []byte) error {
+	credentials, err := parseCredentials(bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	f.fromFile.Store(credentials)
+	return nil
+}
+
+// parseCredentials reads htpasswd-style 'username:hash' lines. Empty lines
+// and lines starting with '#' are ignored.
+func parseCredentials(r io.Reader) (map[string]string, error) {
+	credentials := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		i := strings.IndexByte(line, ':')
+		if i <= 0 || i == len(line)-1 {
+			return nil, fmt.Errorf("line %d: %w", n, errMalformedLine)
+		}
+		credentials[line[:i]] = line[i+1:]
+	}
+	return credentials, scanner.Err()
+}
+
+// watchFile loads the credentials file and reloads it whenever it changes until done is closed.
+// Reload failures are logged and the previous credentials are kept.
+func (f *hashedBasicTokenFactory) watchFile(file string, logger log.Logger, done <-chan struct{}) error {
+	loaded, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	if err = f.loadFile(file, loaded); err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	// watch the directory as editors and secret mounts usually replace the file. Kubernetes
+	// mounts even swap a symlink to the directory holding the file, so no event names it.
+	if err = watcher.Add(filepath.Dir(file)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-done:
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				// any event in the directory may have changed the file, so it is read again
+				// and only reloaded when its content changed
+				content, err := ioutil.ReadFile(file)
+				if err != nil {
+					if !os.IsNotExist(err) {
+						logging.Error(logger).Log(logging.MessageKey(), "Failed to read basic auth credentials", "event", event.String(), logging.ErrorKey(), err)
+					}
+					continue
+				}
+
+				if bytes.Equal(content, loaded) {
+					continue
+				}
+				loaded = content
+
+				if err := f.loadFile(file, content); err != nil {
+					logging.Error(logger).Log(logging.MessageKey(), "Failed to reload basic auth credentials", logging.ErrorKey(), err)
+					continue
+				}
+				logging.Info(logger).Log(logging.MessageKey(), "Reloaded basic auth credentials", "file", file)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logging.Error(logger).Log(logging.MessageKey(), "Basic auth credentials file watch error", logging.ErrorKey(), err)
+			}
+		}
+	}()
+	return nil
+}
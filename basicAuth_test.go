@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/bascule/basculehttp"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func basicValue(username, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+}
+
+func argon2Hash(password string) string {
+	salt := []byte("somesaltysalt")
+	key := argon2.IDKey([]byte(password), salt, 1, 64, 1, 32)
+	return fmt.Sprintf("$argon2id$v=%d$m=64,t=1,p=1$%s$%s", argon2.Version,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func TestHashedBasicTokenFactory(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("bcryptPass"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	f := newHashedBasicTokenFactory(map[string]string{"legacy": "plainPass"}, BasicAuthConfig{
+		Credentials: []BasicCredential{
+			{Username: "bcryptUser", Hash: string(bcryptHash)},
+			{Username: "argonUser", Hash: argon2Hash("argonPass")},
+			{Username: "oddUser", Hash: "{SHA}abc"},
+		},
+	})
+
+	testCases := []struct {
+		Description string
+		Value       string
+		ExpectedErr error
+	}{
+		{Description: "Bcrypt", Value: basicValue("bcryptUser", "bcryptPass")},
+		{Description: "Bcrypt again", Value: basicValue("bcryptUser", "bcryptPass")},
+		{Description: "Bcrypt wrong password", Value: basicValue("bcryptUser", "nope"), ExpectedErr: basculehttp.ErrorInvalidPassword},
+		{Description: "Argon2", Value: basicValue("argonUser", "argonPass")},
+		{Description: "Argon2 wrong password", Value: basicValue("argonUser", "nope"), ExpectedErr: basculehttp.ErrorInvalidPassword},
+		{Description: "Unsupported hash", Value: basicValue("oddUser", "abc"), ExpectedErr: basculehttp.ErrorInvalidPassword},
+		{Description: "Plaintext", Value: basicValue("legacy", "plainPass")},
+		{Description: "Plaintext wrong password", Value: basicValue("legacy", "nope"), ExpectedErr: basculehttp.ErrorInvalidPassword},
+		{Description: "Unknown user", Value: basicValue("stranger", "pass"), ExpectedErr: basculehttp.ErrorPrincipalNotFound},
+		{Description: "Malformed", Value: base64.StdEncoding.EncodeToString([]byte("nocolon")), ExpectedErr: basculehttp.ErrorMalformedValue},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			assert := assert.New(t)
+			token, err := f.ParseAndValidate(context.Background(), nil, "Basic", tc.Value)
+			assert.Equal(tc.ExpectedErr, err)
+			if tc.ExpectedErr == nil {
+				assert.Equal("basic", token.Type())
+			}
+		})
+	}
+}
+
+func TestParseCredentials(t *testing.T) {
+	assert := assert.New(t)
+
+	credentials, err := parseCredentials(strings.NewReader("# comment\n\nuser0:$2y$10$abc\n user1:$argon2id$v=19$m=64,t=1,p=1$c2FsdA$aGFzaA \n"))
+	assert.Nil(err)
+	assert.Equal(map[string]string{"user0": "$2y$10$abc", "user1": "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$aGFzaA"}, credentials)
+
+	_, err = parseCredentials(strings.NewReader("user0:$2y$10$abc\nuser1\n"))
+	assert.True(strings.HasPrefix(err.Error(), "line 2"))
+}
+
+func TestWatchFile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "basicAuth")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "htpasswd")
+	require.NoError(ioutil.WriteFile(file, []byte("user0:"+argon2Hash("first")+"\n"), 0600))
+
+	done := make(chan struct{})
+	defer close(done)
+
+	f := newHashedBasicTokenFactory(nil, BasicAuthConfig{})
+	require.NoError(f.watchFile(file, log.NewNopLogger(), done))
+
+	_, err = f.ParseAndValidate(context.Background(), nil, "Basic", basicValue("user0", "first"))
+	assert.Nil(err)
+
+	// rotate the password
+	require.NoError(ioutil.WriteFile(file, []byte("user0:"+argon2Hash("second")+"\n"), 0600))
+	assert.Eventually(func() bool {
+		_, err := f.ParseAndValidate(context.Background(), nil, "Basic", basicValue("user0", "second"))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err = f.ParseAndValidate(context.Background(), nil, "Basic", basicValue("user0", "first"))
+	assert.Equal(basculehttp.ErrorInvalidPassword, err)
+
+	assert.NotNil(newHashedBasicTokenFactory(nil, BasicAuthConfig{}).watchFile(filepath.Join(dir, "missing"), log.NewNopLogger(), done))
+}
+
+func TestWatchFileSymlinkSwap(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "basicAuth")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	// lay the file out the way Kubernetes mounts secrets and configmaps
+	writeVersion := func(version, password string) {
+		require.NoError(os.Mkdir(filepath.Join(dir, version), 0700))
+		require.NoError(ioutil.WriteFile(filepath.Join(dir, version, "htpasswd"), []byte("user0:"+argon2Hash(password)+"\n"), 0600))
+		require.NoError(os.Symlink(version, filepath.Join(dir, "..data_tmp")))
+		require.NoError(os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+	}
+
+	writeVersion("..v1", "first")
+	file := filepath.Join(dir, "htpasswd")
+	require.NoError(os.Symlink(filepath.Join("..data", "htpasswd"), file))
+
+	done := make(chan struct{})
+	defer close(done)
+
+	f := newHashedBasicTokenFactory(nil, BasicAuthConfig{})
+	require.NoError(f.watchFile(file, log.NewNopLogger(), done))
+
+	// swapping the data symlink emits no event for the file itself
+	writeVersion("..v2", "second")
+	assert.Eventually(func() bool {
+		_, err := f.ParseAndValidate(context.Background(), nil, "Basic", basicValue("user0", "second"))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
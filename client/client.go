@@ -0,0 +1,204 @@
+// Package client is a client of the tr1d1um API. It sends the same request bodies the
+// translation, stat and webhook routes expect, so callers don't have to assemble them.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/xmidt-org/tr1d1um/translation"
+	"github.com/xmidt-org/wrp-go/v3/wrphttp"
+)
+
+const apiBase = "/api/v2"
+
+// DefaultService is the device service the translation requests are sent to when none is given.
+const DefaultService = "config"
+
+// Errors returned before any request is sent.
+var (
+	ErrMissingNames      = errors.New("at least one parameter name is required")
+	ErrMissingParameters = errors.New("at least one parameter is required")
+	ErrMissingTable      = errors.New("table is required")
+	ErrMissingRow        = errors.New("row is required")
+)
+
+// Client sends requests to tr1d1um.
+type Client struct {
+	// URL is the base URL of tr1d1um, i.e. "http://localhost:6100".
+	URL string
+
+	// Authorization is the value of the Authorization header of every request.
+	// (Optional)
+	Authorization string
+
+	// PartnerIDs are sent in the X-Xmidt-Partner-Id header.
+	// (Optional)
+	PartnerIDs []string
+
+	// HTTPClient sends the requests.
+	// (Optional) Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Response is the response of tr1d1um.
+type Response struct {
+	Code   int
+	Header http.Header
+	Body   []byte
+}
+
+// Parameter is a device parameter of a set request. Parameters with attributes set
+// their attributes instead of their value.
+type Parameter struct {
+	Name       string                 `json:"name"`
+	DataType   *int8                  `json:"dataType,omitempty"`
+	Value      interface{}            `json:"value,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// CID are the headers turning a set request into a TEST_AND_SET one.
+type CID struct {
+	New     string
+	Old     string
+	SyncCMC string
+}
+
+type setRequest struct {
+	Parameters []Parameter `json:"parameters"`
+}
+
+// Get gets the values or, when attributes is not empty, the attributes of the parameters of a device.
+func (c *Client) Get(ctx context.Context, deviceID, service string, names []string, attributes string) (*Response, error) {
+	if len(names) == 0 {
+		return nil, ErrMissingNames
+	}
+
+	query := url.Values{"names": {strings.Join(names, ",")}}
+	if attributes != "" {
+		query.Set("attributes", attributes)
+	}
+	return c.do(ctx, http.MethodGet, devicePath(deviceID, service)+"?"+query.Encode(), nil, nil)
+}
+
+// Set sets the parameters of a device.
+func (c *Client) Set(ctx context.Context, deviceID, service string, parameters []Parameter, cid CID) (*Response, error) {
+	if len(parameters) == 0 {
+		return nil, ErrMissingParameters
+	}
+
+	header := make(http.Header)
+	for name, value := range map[string]string{
+		translation.HeaderWPASyncNewCID: cid.New,
+		translation.HeaderWPASyncOldCID: cid.Old,
+		translation.HeaderWPASyncCMC:    cid.SyncCMC,
+	} {
+		if value != "" {
+			header.Set(name, value)
+		}
+	}
+	return c.do(ctx, http.MethodPatch, devicePath(deviceID, service), &setRequest{Parameters: parameters}, header)
+}
+
+// AddRow adds a row to a table of a device.
+func (c *Client) AddRow(ctx context.Context, deviceID, service, table string, row map[string]string) (*Response, error) {
+	if table == "" {
+		return nil, ErrMissingTable
+	}
+	if len(row) == 0 {
+		return nil, ErrMissingRow
+	}
+	return c.do(ctx, http.MethodPost, devicePath(deviceID, service, table), row, nil)
+}
+
+// ReplaceRows replaces the rows of a table of a device. The rows are keyed by their index.
+func (c *Client) ReplaceRows(ctx context.Context, deviceID, service, table string, rows map[string]map[string]string) (*Response, error) {
+	if table == "" {
+		return nil, ErrMissingTable
+	}
+	return c.do(ctx, http.MethodPut, devicePath(deviceID, service, table), rows, nil)
+}
+
+// DeleteRow deletes a table row of a device.
+func (c *Client) DeleteRow(ctx context.Context, deviceID, service, row string) (*Response, error) {
+	if row == "" {
+		return nil, ErrMissingRow
+	}
+	return c.do(ctx, http.MethodDelete, devicePath(deviceID, service, row), nil, nil)
+}
+
+// Stat gets the statistics of a device.
+func (c *Client) Stat(ctx context.Context, deviceID string) (*Response, error) {
+	return c.do(ctx, http.MethodGet, devicePath(deviceID, "stat"), nil, nil)
+}
+
+// Hooks lists the registered webhooks.
+func (c *Client) Hooks(ctx context.Context) (*Response, error) {
+	return c.do(ctx, http.MethodGet, apiBase+"/hooks", nil, nil)
+}
+
+// AddHook registers a webhook. The registration is sent as is.
+func (c *Client) AddHook(ctx context.Context, registration json.RawMessage) (*Response, error) {
+	return c.do(ctx, http.MethodPost, apiBase+"/hook", registration, nil)
+}
+
+func devicePath(deviceID string, segments ...string) string {
+	path := apiBase + "/device/" + url.PathEscape(deviceID)
+	for _, s := range segments {
+		path += "/" + url.PathEscape(s)
+	}
+	return path
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, header http.Header) (*Response, error) {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.URL, "/")+path, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range header {
+		r.Header[name] = values
+	}
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if c.Authorization != "" {
+		r.Header.Set("Authorization", c.Authorization)
+	}
+	if len(c.PartnerIDs) > 0 {
+		r.Header.Set(wrphttp.PartnerIdHeader, strings.Join(c.PartnerIDs, ","))
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Code: resp.StatusCode, Header: resp.Header, Body: data}, nil
+}
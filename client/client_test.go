@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedRequest struct {
+	Method string
+	URI    string
+	Header http.Header
+	Body   string
+}
+
+func TestClient(t *testing.T) {
+	dataType := int8(3)
+
+	testCases := []struct {
+		Name            string
+		Call            func(*Client) (*Response, error)
+		ExpectedErr     error
+		ExpectedMethod  string
+		ExpectedURI     string
+		ExpectedBody    string
+		ExpectedHeaders map[string]string
+	}{
+		{
+			Name: "Get",
+			Call: func(c *Client) (*Response, error) {
+				return c.Get(context.Background(), "mac:112233445566", DefaultService, []string{"p1", "p2"}, "")
+			},
+			ExpectedMethod: http.MethodGet,
+			ExpectedURI:    "/api/v2/device/mac:112233445566/config?names=p1%2Cp2",
+		},
+		{
+			Name: "Get attributes",
+			Call: func(c *Client) (*Response, error) {
+				return c.Get(context.Background(), "mac:112233445566", DefaultService, []string{"p1"}, "notify")
+			},
+			ExpectedMethod: http.MethodGet,
+			ExpectedURI:    "/api/v2/device/mac:112233445566/config?attributes=notify&names=p1",
+		},
+		{
+			Name: "Get without names",
+			Call: func(c *Client) (*Response, error) {
+				return c.Get(context.Background(), "mac:112233445566", DefaultService, nil, "")
+			},
+			ExpectedErr: ErrMissingNames,
+		},
+		{
+			Name: "Set",
+			Call: func(c *Client) (*Response, error) {
+				return c.Set(context.Background(), "mac:112233445566", DefaultService,
+					[]Parameter{{Name: "p1", DataType: &dataType, Value: "true"}}, CID{New: "new", Old: "old"})
+			},
+			ExpectedMethod:  http.MethodPatch,
+			ExpectedURI:     "/api/v2/device/mac:112233445566/config",
+			ExpectedBody:    `{"parameters":[{"name":"p1","dataType":3,"value":"true"}]}`,
+			ExpectedHeaders: map[string]string{"X-Webpa-Sync-New-Cid": "new", "X-Webpa-Sync-Old-Cid": "old", "X-Webpa-Sync-Cmc": ""},
+		},
+		{
+			Name: "Set without parameters",
+			Call: func(c *Client) (*Response, error) {
+				return c.Set(context.Background(), "mac:112233445566", DefaultService, nil, CID{})
+			},
+			ExpectedErr: ErrMissingParameters,
+		},
+		{
+			Name: "Add row",
+			Call: func(c *Client) (*Response, error) {
+				return c.AddRow(context.Background(), "mac:112233445566", DefaultService, "Device.NAT.PortMapping.", map[string]string{"Enable": "true"})
+			},
+			ExpectedMethod: http.MethodPost,
+			ExpectedURI:    "/api/v2/device/mac:112233445566/config/Device.NAT.PortMapping.",
+			ExpectedBody:   `{"Enable":"true"}`,
+		},
+		{
+			Name: "Add row without table",
+			Call: func(c *Client) (*Response, error) {
+				return c.AddRow(context.Background(), "mac:112233445566", DefaultService, "", map[string]string{"Enable": "true"})
+			},
+			ExpectedErr: ErrMissingTable,
+		},
+		{
+			Name: "Replace rows",
+			Call: func(c *Client) (*Response, error) {
+				return c.ReplaceRows(context.Background(), "mac:112233445566", DefaultService, "Device.NAT.PortMapping.",
+					map[string]map[string]string{"1": {"Enable": "true"}})
+			},
+			ExpectedMethod: http.MethodPut,
+			ExpectedURI:    "/api/v2/device/mac:112233445566/config/Device.NAT.PortMapping.",
+			ExpectedBody:   `{"1":{"Enable":"true"}}`,
+		},
+		{
+			Name: "Delete row",
+			Call: func(c *Client) (*Response, error) {
+				return c.DeleteRow(context.Background(), "mac:112233445566", DefaultService, "Device.NAT.PortMapping.1.")
+			},
+			ExpectedMethod: http.MethodDelete,
+			ExpectedURI:    "/api/v2/device/mac:112233445566/config/Device.NAT.PortMapping.1.",
+		},
+		{
+			Name: "Stat",
+			Call: func(c *Client) (*Response, error) {
+				return c.Stat(context.Background(), "mac:112233445566")
+			},
+			ExpectedMethod: http.MethodGet,
+			ExpectedURI:    "/api/v2/device/mac:112233445566/stat",
+		},
+		{
+			Name: "Hooks",
+			Call: func(c *Client) (*Response, error) {
+				return c.Hooks(context.Background())
+			},
+			ExpectedMethod: http.MethodGet,
+			ExpectedURI:    "/api/v2/hooks",
+		},
+		{
+			Name: "Add hook",
+			Call: func(c *Client) (*Response, error) {
+				return c.AddHook(context.Background(), json.RawMessage(`{"config":{"url":"http://example.com"}}`))
+			},
+			ExpectedMethod: http.MethodPost,
+			ExpectedURI:    "/api/v2/hook",
+			ExpectedBody:   `{"config":{"url":"http://example.com"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var recorded *recordedRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				recorded = &recordedRequest{Method: r.Method, URI: r.RequestURI, Header: r.Header, Body: string(body)}
+				w.Header().Set("X-WebPA-Transaction-Id", "tid")
+				w.WriteHeader(http.StatusAccepted)
+				w.Write([]byte(`{"statusCode":202}`))
+			}))
+			defer server.Close()
+
+			c := &Client{URL: server.URL + "/", Authorization: "Basic dXNlcjpwYXNz", PartnerIDs: []string{"comcast", "sky"}}
+			resp, err := tc.Call(c)
+			if tc.ExpectedErr != nil {
+				assert.Equal(tc.ExpectedErr, err)
+				assert.Nil(recorded)
+				return
+			}
+
+			require.NoError(err)
+			require.NotNil(recorded)
+			assert.Equal(http.StatusAccepted, resp.Code)
+			assert.Equal("tid", resp.Header.Get("X-WebPA-Transaction-Id"))
+			assert.Equal(`{"statusCode":202}`, string(resp.Body))
+
+			assert.Equal(tc.ExpectedMethod, recorded.Method)
+			assert.Equal(tc.ExpectedURI, recorded.URI)
+			assert.Equal("Basic dXNlcjpwYXNz", recorded.Header.Get("Authorization"))
+			assert.Equal("comcast,sky", recorded.Header.Get("X-Xmidt-Partner-Id"))
+			if tc.ExpectedBody != "" {
+				assert.JSONEq(tc.ExpectedBody, recorded.Body)
+			} else {
+				assert.Empty(recorded.Body)
+			}
+			for name, value := range tc.ExpectedHeaders {
+				assert.Equal(value, recorded.Header.Get(name), name)
+			}
+		})
+	}
+}
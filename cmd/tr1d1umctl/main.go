@@ -0,0 +1,426 @@
+// tr1d1umctl is a command-line client of the tr1d1um API.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/xmidt-org/tr1d1um/client"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	applicationName = "tr1d1umctl"
+
+	urlEnv  = "TR1D1UM_URL"
+	authEnv = "TR1D1UM_AUTH"
+
+	// exit codes
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `usage: tr1d1umctl [flags] <command> [arguments]
+
+commands:
+  get <device> <name>[,<name>...]              gets parameter values
+  set <device> <name>[:<dataType>]=<value>...  sets parameter values, or the ones of --file
+  add-row <device> <table> --file <row>        adds a table row
+  replace-rows <device> <table> --file <rows>  replaces the rows of a table
+  delete-row <device> <row>                    deletes a table row
+  stat <device>                                gets device statistics
+  hooks list                                   lists the registered webhooks
+  hooks add --file <registration>              registers a webhook
+
+Files are JSON or, if their extension is .yaml or .yml, YAML. Use - for stdin.
+
+flags:
+`
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// command runs a command with the arguments following its name.
+type command func(ctx context.Context, c *client.Client, o *options, arguments []string) (*client.Response, error)
+
+var commands = map[string]command{
+	"get":          get,
+	"set":          set,
+	"add-row":      addRow,
+	"replace-rows": replaceRows,
+	"delete-row":   deleteRow,
+	"stat":         stat,
+	"hooks":        hooks,
+}
+
+type options struct {
+	service string
+	raw     bool
+	stdin   io.Reader
+}
+
+func run(arguments []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var (
+		f          = pflag.NewFlagSet(applicationName, pflag.ContinueOnError)
+		url        = f.String("url", envOr(urlEnv, "http://localhost:6100"), "base URL of tr1d1um, defaults to $"+urlEnv)
+		auth       = f.String("auth", os.Getenv(authEnv), "Authorization header value, defaults to $"+authEnv)
+		user       = f.String("user", "", "Basic auth user")
+		password   = f.String("password", "", "Basic auth password")
+		token      = f.String("token", "", "Bearer token")
+		partnerIDs = f.StringSlice("partner-id", nil, "partner IDs sent in the X-Xmidt-Partner-Id header")
+		timeout    = f.Duration("timeout", 30*time.Second, "request timeout")
+		o          = options{stdin: stdin}
+	)
+
+	f.StringVar(&o.service, "service", client.DefaultService, "device service of get, set and table commands")
+	f.BoolVar(&o.raw, "raw", false, "prints responses as they are instead of pretty-printing them")
+	f.SetInterspersed(false)
+	f.SetOutput(stderr)
+	f.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		f.PrintDefaults()
+	}
+
+	if err := f.Parse(arguments); err != nil {
+		if err == pflag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if f.NArg() == 0 {
+		f.Usage()
+		return exitUsage
+	}
+
+	cmd, ok := commands[f.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", f.Arg(0))
+		f.Usage()
+		return exitUsage
+	}
+
+	c := &client.Client{
+		URL:           *url,
+		Authorization: authorization(*auth, *user, *password, *token),
+		PartnerIDs:    *partnerIDs,
+		HTTPClient:    &http.Client{Timeout: *timeout},
+	}
+
+	resp, err := cmd(context.Background(), c, &o, f.Args()[1:])
+	switch {
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(stderr, err)
+		f.Usage()
+		return exitUsage
+	case err != nil:
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitError
+	}
+
+	fmt.Fprintf(stderr, "%d %s\n", resp.Code, http.StatusText(resp.Code))
+	if err := printBody(stdout, resp.Body, o.raw); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitError
+	}
+
+	if resp.Code >= http.StatusBadRequest {
+		return exitError
+	}
+	return exitOK
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// authorization builds the Authorization header value. An explicit value wins over
+// a token, which wins over Basic credentials.
+func authorization(auth, user, password, token string) string {
+	switch {
+	case auth != "":
+		return auth
+	case token != "":
+		return "Bearer " + token
+	case user != "":
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+password))
+	}
+	return ""
+}
+
+func usageError(format string, a ...interface{}) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, a...))
+}
+
+// commandFlags parses the flags of a command and checks its argument count.
+func commandFlags(name string, arguments []string, args int, flags func(*pflag.FlagSet)) (*pflag.FlagSet, error) {
+	f := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	if flags != nil {
+		flags(f)
+	}
+
+	if err := f.Parse(arguments); err != nil {
+		return nil, usageError("%s: %v", name, err)
+	}
+
+	if f.NArg() != args {
+		return nil, usageError("%s takes %d argument(s), got %d", name, args, f.NArg())
+	}
+	return f, nil
+}
+
+func get(ctx context.Context, c *client.Client, o *options, arguments []string) (*client.Response, error) {
+	var attributes string
+	f, err := commandFlags("get", arguments, 2, func(f *pflag.FlagSet) {
+		f.StringVar(&attributes, "attributes", "", "gets these attributes instead of the values, i.e. notify")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Get(ctx, f.Arg(0), o.service, strings.Split(f.Arg(1), ","), attributes)
+}
+
+func set(ctx context.Context, c *client.Client, o *options, arguments []string) (*client.Response, error) {
+	var (
+		file string
+		cid  client.CID
+	)
+
+	f := pflag.NewFlagSet("set", pflag.ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	f.StringVar(&file, "file", "", "file with the parameters to set")
+	f.StringVar(&cid.New, "new-cid", "", "new CID of a TEST_AND_SET")
+	f.StringVar(&cid.Old, "old-cid", "", "old CID of a TEST_AND_SET")
+	f.StringVar(&cid.SyncCMC, "sync-cmc", "", "CMC of a TEST_AND_SET")
+	if err := f.Parse(arguments); err != nil {
+		return nil, usageError("set: %v", err)
+	}
+
+	if f.NArg() < 1 {
+		return nil, usageError("set takes a device and the parameters to set")
+	}
+
+	var parameters []client.Parameter
+	if file != "" {
+		if err := readFile(file, o.stdin, &parameters); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, arg := range f.Args()[1:] {
+		p, err := parseParameter(arg)
+		if err != nil {
+			return nil, err
+		}
+		parameters = append(parameters, p)
+	}
+
+	return c.Set(ctx, f.Arg(0), o.service, parameters, cid)
+}
+
+// parseParameter parses name[:dataType]=value. The data type defaults to 0, a string.
+// Parameter names contain neither ':' nor '=' so the value may contain both.
+func parseParameter(arg string) (client.Parameter, error) {
+	i := strings.Index(arg, "=")
+	if i <= 0 {
+		return client.Parameter{}, usageError("parameter %q is not of the form name[:dataType]=value", arg)
+	}
+
+	var (
+		name, value = arg[:i], arg[i+1:]
+		dataType    int8
+	)
+
+	if j := strings.Index(name, ":"); j >= 0 {
+		t, err := strconv.ParseInt(name[j+1:], 10, 8)
+		if err != nil || t < 0 {
+			return client.Parameter{}, usageError("parameter %q has an invalid data type", arg)
+		}
+		name, dataType = name[:j], int8(t)
+	}
+
+	return client.Parameter{Name: name, DataType: &dataType, Value: value}, nil
+}
+
+func addRow(ctx context.Context, c *client.Client, o *options, arguments []string) (*client.Response, error) {
+	var file string
+	f, err := commandFlags("add-row", arguments, 2, fileFlag(&file))
+	if err != nil {
+		return nil, err
+	}
+
+	var row map[string]string
+	if err := readRequiredFile(file, o.stdin, &row); err != nil {
+		return nil, err
+	}
+	return c.AddRow(ctx, f.Arg(0), o.service, f.Arg(1), row)
+}
+
+func replaceRows(ctx context.Context, c *client.Client, o *options, arguments []string) (*client.Response, error) {
+	var file string
+	f, err := commandFlags("replace-rows", arguments, 2, fileFlag(&file))
+	if err != nil {
+		return nil, err
+	}
+
+	var rows map[string]map[string]string
+	if err := readRequiredFile(file, o.stdin, &rows); err != nil {
+		return nil, err
+	}
+	return c.ReplaceRows(ctx, f.Arg(0), o.service, f.Arg(1), rows)
+}
+
+func deleteRow(ctx context.Context, c *client.Client, o *options, arguments []string) (*client.Response, error) {
+	f, err := commandFlags("delete-row", arguments, 2, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.DeleteRow(ctx, f.Arg(0), o.service, f.Arg(1))
+}
+
+func stat(ctx context.Context, c *client.Client, _ *options, arguments []string) (*client.Response, error) {
+	f, err := commandFlags("stat", arguments, 1, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Stat(ctx, f.Arg(0))
+}
+
+func hooks(ctx context.Context, c *client.Client, o *options, arguments []string) (*client.Response, error) {
+	if len(arguments) == 0 {
+		return nil, usageError("hooks takes list or add")
+	}
+
+	switch arguments[0] {
+	case "list":
+		if _, err := commandFlags("hooks list", arguments[1:], 0, nil); err != nil {
+			return nil, err
+		}
+		return c.Hooks(ctx)
+	case "add":
+		var file string
+		if _, err := commandFlags("hooks add", arguments[1:], 0, fileFlag(&file)); err != nil {
+			return nil, err
+		}
+
+		var registration json.RawMessage
+		if err := readRequiredFile(file, o.stdin, &registration); err != nil {
+			return nil, err
+		}
+		return c.AddHook(ctx, registration)
+	}
+	return nil, usageError("unknown hooks command %q", arguments[0])
+}
+
+func fileFlag(file *string) func(*pflag.FlagSet) {
+	return func(f *pflag.FlagSet) {
+		f.StringVar(file, "file", "", "JSON or YAML file, - for stdin")
+	}
+}
+
+func readRequiredFile(file string, stdin io.Reader, v interface{}) error {
+	if file == "" {
+		return usageError("--file is required")
+	}
+	return readFile(file, stdin, v)
+}
+
+// readFile decodes a JSON or YAML file into v. YAML is converted to JSON first so
+// the json tags of v apply to both.
+func readFile(file string, stdin io.Reader, v interface{}) error {
+	var (
+		data []byte
+		err  error
+	)
+
+	if file == "-" {
+		data, err = ioutil.ReadAll(stdin)
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return err
+	}
+
+	if ext := strings.ToLower(filepath.Ext(file)); ext == ".yaml" || ext == ".yml" {
+		if data, err = yamlToJSON(data); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+func yamlToJSON(data []byte) ([]byte, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonValue(v))
+}
+
+// jsonValue turns the map[interface{}]interface{} values yaml decodes into ones json can encode.
+func jsonValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, value := range t {
+			m[fmt.Sprint(k)] = jsonValue(value)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, value := range t {
+			s[i] = jsonValue(value)
+		}
+		return s
+	}
+	return v
+}
+
+// printBody pretty-prints JSON bodies. Other bodies are printed as they are.
+func printBody(w io.Writer, body []byte, raw bool) error {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var indented bytes.Buffer
+	if !raw && json.Indent(&indented, body, "", "  ") == nil {
+		body = indented.Bytes()
+	}
+
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+
+	if body[len(body)-1] != '\n' {
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/tr1d1um/client"
+)
+
+func TestParseParameter(t *testing.T) {
+	boolean, str := int8(3), int8(0)
+
+	testCases := []struct {
+		Arg         string
+		Expected    client.Parameter
+		ExpectedErr bool
+	}{
+		{Arg: "Device.DeviceInfo.X=value", Expected: client.Parameter{Name: "Device.DeviceInfo.X", DataType: &str, Value: "value"}},
+		{Arg: "Device.WiFi.Enable:3=true", Expected: client.Parameter{Name: "Device.WiFi.Enable", DataType: &boolean, Value: "true"}},
+		{Arg: "Device.X:0=a:b=c", Expected: client.Parameter{Name: "Device.X", DataType: &str, Value: "a:b=c"}},
+		{Arg: "Device.X", ExpectedErr: true},
+		{Arg: "=value", ExpectedErr: true},
+		{Arg: "Device.X:bool=true", ExpectedErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Arg, func(t *testing.T) {
+			assert := assert.New(t)
+			p, err := parseParameter(tc.Arg)
+			if tc.ExpectedErr {
+				assert.NotNil(err)
+				return
+			}
+			assert.Nil(err)
+			assert.Equal(tc.Expected, p)
+		})
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "tr1d1umctl")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	yamlFile := filepath.Join(dir, "rows.yaml")
+	require.NoError(ioutil.WriteFile(yamlFile, []byte("\"1\":\n  Enable: \"true\"\n2:\n  Enable: \"false\"\n"), 0600))
+
+	var rows map[string]map[string]string
+	require.NoError(readFile(yamlFile, nil, &rows))
+	assert.Equal(map[string]map[string]string{"1": {"Enable": "true"}, "2": {"Enable": "false"}}, rows)
+
+	var parameters []client.Parameter
+	require.NoError(readFile("-", strings.NewReader(`[{"name":"p1","dataType":1,"value":"5"}]`), &parameters))
+	require.Len(parameters, 1)
+	assert.Equal("p1", parameters[0].Name)
+
+	assert.NotNil(readFile(filepath.Join(dir, "missing.json"), nil, &rows))
+	assert.NotNil(readFile("-", strings.NewReader("{"), &rows))
+}
+
+func TestRun(t *testing.T) {
+	var (
+		lastURI  string
+		lastAuth string
+		lastBody string
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		lastURI, lastAuth, lastBody = r.RequestURI, r.Header.Get("Authorization"), string(body)
+		if strings.HasSuffix(r.URL.Path, "/stat") {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("device not found"))
+			return
+		}
+		w.Write([]byte(`{"statusCode":200,"parameters":[]}`))
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		Name             string
+		Arguments        []string
+		Stdin            string
+		ExpectedCode     int
+		ExpectedURI      string
+		ExpectedAuth     string
+		ExpectedBody     string
+		ExpectedOutput   string
+		ExpectedInStderr string
+	}{
+		{
+			Name:           "Get",
+			Arguments:      []string{"--user", "user", "--password", "pass", "get", "mac:112233445566", "p1,p2"},
+			ExpectedCode:   exitOK,
+			ExpectedURI:    "/api/v2/device/mac:112233445566/config?names=p1%2Cp2",
+			ExpectedAuth:   "Basic dXNlcjpwYXNz",
+			ExpectedOutput: "{\n  \"statusCode\": 200,\n  \"parameters\": []\n}\n",
+		},
+		{
+			Name:           "Raw",
+			Arguments:      []string{"--raw", "--token", "t", "get", "mac:112233445566", "p1"},
+			ExpectedCode:   exitOK,
+			ExpectedURI:    "/api/v2/device/mac:112233445566/config?names=p1",
+			ExpectedAuth:   "Bearer t",
+			ExpectedOutput: "{\"statusCode\":200,\"parameters\":[]}\n",
+		},
+		{
+			Name:         "Set from stdin and arguments",
+			Arguments:    []string{"--service", "iot", "set", "mac:112233445566", "--file", "-", "p2:3=true"},
+			Stdin:        `[{"name":"p1","dataType":0,"value":"a"}]`,
+			ExpectedCode: exitOK,
+			ExpectedURI:  "/api/v2/device/mac:112233445566/iot",
+			ExpectedBody: `{"parameters":[{"name":"p1","dataType":0,"value":"a"},{"name":"p2","dataType":3,"value":"true"}]}`,
+		},
+		{
+			Name:         "Add row",
+			Arguments:    []string{"add-row", "mac:112233445566", "Device.NAT.PortMapping.", "--file", "-"},
+			Stdin:        `{"Enable":"true"}`,
+			ExpectedCode: exitOK,
+			ExpectedURI:  "/api/v2/device/mac:112233445566/config/Device.NAT.PortMapping.",
+			ExpectedBody: `{"Enable":"true"}`,
+		},
+		{
+			Name:         "Hooks add",
+			Arguments:    []string{"--auth", "Basic x", "hooks", "add", "--file", "-"},
+			Stdin:        `{"config":{"url":"http://example.com"}}`,
+			ExpectedCode: exitOK,
+			ExpectedURI:  "/api/v2/hook",
+			ExpectedAuth: "Basic x",
+			ExpectedBody: `{"config":{"url":"http://example.com"}}`,
+		},
+		{
+			Name:             "Error response",
+			Arguments:        []string{"stat", "mac:112233445566"},
+			ExpectedCode:     exitError,
+			ExpectedURI:      "/api/v2/device/mac:112233445566/stat",
+			ExpectedOutput:   "device not found\n",
+			ExpectedInStderr: "404 Not Found",
+		},
+		{
+			Name:             "Unknown command",
+			Arguments:        []string{"reboot", "mac:112233445566"},
+			ExpectedCode:     exitUsage,
+			ExpectedInStderr: `unknown command "reboot"`,
+		},
+		{
+			Name:             "Missing file",
+			Arguments:        []string{"replace-rows", "mac:112233445566", "Device.NAT.PortMapping."},
+			ExpectedCode:     exitUsage,
+			ExpectedInStderr: "--file is required",
+		},
+		{
+			Name:             "Wrong argument count",
+			Arguments:        []string{"delete-row", "mac:112233445566"},
+			ExpectedCode:     exitUsage,
+			ExpectedInStderr: "delete-row takes 2 argument(s), got 1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			lastURI, lastAuth, lastBody = "", "", ""
+
+			var stdout, stderr bytes.Buffer
+			code := run(append([]string{"--url", server.URL}, tc.Arguments...), strings.NewReader(tc.Stdin), &stdout, &stderr)
+			assert.Equal(tc.ExpectedCode, code, stderr.String())
+			assert.Equal(tc.ExpectedURI, lastURI)
+			assert.Equal(tc.ExpectedAuth, lastAuth)
+			if tc.ExpectedBody != "" {
+				assert.JSONEq(tc.ExpectedBody, lastBody)
+			}
+			if tc.ExpectedOutput != "" {
+				assert.Equal(tc.ExpectedOutput, stdout.String())
+			}
+			assert.Contains(stderr.String(), tc.ExpectedInStderr)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/webpa-common/basculechecks"
+	"github.com/xmidt-org/wrp-go/v3/wrphttp"
+)
+
+// HeaderPartnerIDs returns the array that represents the partner-ids that were
+// passed in as headers.  This function handles multiple duplicate headers.
+func HeaderPartnerIDs(h http.Header) []string {
+	headers, ok := h[wrphttp.PartnerIdHeader]
+	if !ok {
+		return nil
+	}
+
+	var partners []string
+
+	for _, value := range headers {
+		fields := strings.Split(value, ",")
+		for i := 0; i < len(fields); i++ {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		partners = append(partners, fields...)
+	}
+	return partners
+}
+
+// TokenPartnerIDs returns the partner IDs found in the claims of the JWT token in ctx, if any.
+func TokenPartnerIDs(ctx context.Context) ([]string, bool) {
+	auth, ok := bascule.FromContext(ctx)
+	//if no token
+	if !ok {
+		return nil, false
+	}
+	//if not jwt type
+	if auth.Token.Type() != "jwt" {
+		return nil, false
+	}
+	partnerVal, ok := bascule.GetNestedAttribute(auth.Token.Attributes(), basculechecks.PartnerKeys()...)
+	//if no partner ids
+	if !ok {
+		return nil, false
+	}
+	partnerIDs, err := cast.ToStringSliceE(partnerVal)
+	if err != nil {
+		return nil, false
+	}
+	return partnerIDs, true
+}
+
+// PartnerIDs returns the partner IDs of a request. The ones in the claims of its JWT token
+// are preferred; otherwise the ones passed in as headers are used.
+func PartnerIDs(ctx context.Context, h http.Header) []string {
+	if partnerIDs, ok := TokenPartnerIDs(ctx); ok {
+		return partnerIDs
+	}
+	return HeaderPartnerIDs(h)
+}
+
+// PartnerPolicy configures how the partner IDs of a request are derived from its
+// auth token and its partner ID headers.
+type PartnerPolicy struct {
+	// RequireTokenPartners lists the auth types (i.e. 'jwt', 'basic') whose requests are
+	// rejected when their token carries no partner IDs. Partner IDs are only read from
+	// JWT claims so listing other auth types rejects them altogether.
+	// (Optional) By default, partner ID headers are used when tokens carry no partner IDs.
+	RequireTokenPartners []string
+
+	// EnforceHeaderSubset rejects requests whose partner ID headers list partners
+	// missing from the partner IDs of their token.
+	// (Optional) Defaults to false in which case partner ID headers are ignored when
+	// the token carries partner IDs.
+	EnforceHeaderSubset bool
+
+	// Wildcard is the token partner ID which grants access to every partner. Requests
+	// whose token carries it act on behalf of the partners listed in their headers, if any.
+	// (Optional) By default, no partner ID is treated as a wildcard.
+	Wildcard string
+}
+
+var errPartnersForbidden = errors.New("partner IDs forbidden")
+
+// ErrorCodeForbiddenPartners is the error code of requests violating the partner policy.
+const ErrorCodeForbiddenPartners = "forbidden-partners"
+
+func newPartnersForbiddenError(err error) ProblemError {
+	return NewProblemError(err, http.StatusForbidden, ErrorCodeForbiddenPartners, "Forbidden partner IDs", wrphttp.PartnerIdHeader)
+}
+
+// PartnerIDs returns the partner IDs of a request according to the policy. A nil policy
+// behaves like the package level PartnerIDs function. Requests violating the policy
+// get a 403 coded error with the reason.
+func (p *PartnerPolicy) PartnerIDs(ctx context.Context, h http.Header) ([]string, error) {
+	if p == nil {
+		return PartnerIDs(ctx, h), nil
+	}
+
+	tokenPartners, ok := TokenPartnerIDs(ctx)
+	if !ok || len(tokenPartners) == 0 {
+		if authType, required := p.requiresTokenPartners(ctx); required {
+			return nil, newPartnersForbiddenError(fmt.Errorf("%w: token partner IDs are required for %s authentication", errPartnersForbidden, authType))
+		}
+		return HeaderPartnerIDs(h), nil
+	}
+
+	headerPartners := HeaderPartnerIDs(h)
+	if p.Wildcard != "" && contains(tokenPartners, p.Wildcard) {
+		if len(headerPartners) > 0 {
+			return headerPartners, nil
+		}
+		return tokenPartners, nil
+	}
+
+	if p.EnforceHeaderSubset {
+		var forbidden []string
+		for _, partner := range headerPartners {
+			if !contains(tokenPartners, partner) {
+				forbidden = append(forbidden, partner)
+			}
+		}
+
+		if len(forbidden) > 0 {
+			return nil, newPartnersForbiddenError(fmt.Errorf("%w: %s not allowed by the token", errPartnersForbidden, strings.Join(forbidden, ", ")))
+		}
+	}
+	return tokenPartners, nil
+}
+
+func (p *PartnerPolicy) requiresTokenPartners(ctx context.Context) (string, bool) {
+	auth, ok := bascule.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	authType := auth.Token.Type()
+	return authType, contains(p.RequireTokenPartners, authType)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Capabilities returns the capabilities found in the claims of the auth token in ctx.
+func Capabilities(ctx context.Context) []string {
+	auth, ok := bascule.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	val, ok := auth.Token.Attributes().Get(basculechecks.CapabilityKey)
+	if !ok {
+		return nil
+	}
+	capabilities, _ := cast.ToStringSliceE(val)
+	return capabilities
+}
+
+// HasCapability reports whether the auth token in ctx carries the given capability.
+// An empty capability is never granted.
+func HasCapability(ctx context.Context, capability string) bool {
+	if capability == "" {
+		return false
+	}
+	return contains(Capabilities(ctx), capability)
+}
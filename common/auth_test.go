@@ -0,0 +1,113 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/bascule"
+)
+
+func tokenContext(tokenType string, attrs map[string]interface{}) context.Context {
+	return bascule.WithAuthentication(context.Background(), bascule.Authentication{
+		Token: bascule.NewToken(tokenType, "client0", bascule.NewAttributes(attrs)),
+	})
+}
+
+func TestPartnerIDs(t *testing.T) {
+	partnerClaims := map[string]interface{}{
+		"allowedResources": map[string]interface{}{
+			"allowedPartners": []interface{}{"partnerA"},
+		},
+	}
+
+	header := http.Header{"X-Xmidt-Partner-Id": []string{"partnerB, partnerC", "partnerD"}}
+
+	testCases := []struct {
+		Name     string
+		Ctx      context.Context
+		Header   http.Header
+		Expected []string
+	}{
+		{Name: "JWT claims", Ctx: tokenContext("jwt", partnerClaims), Header: header, Expected: []string{"partnerA"}},
+		{Name: "JWT without claims", Ctx: tokenContext("jwt", nil), Header: header, Expected: []string{"partnerB", "partnerC", "partnerD"}},
+		{Name: "Basic token", Ctx: tokenContext("basic", partnerClaims), Header: header, Expected: []string{"partnerB", "partnerC", "partnerD"}},
+		{Name: "No token nor header", Ctx: context.Background(), Header: http.Header{}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert.Equal(t, testCase.Expected, PartnerIDs(testCase.Ctx, testCase.Header))
+		})
+	}
+}
+
+func TestHasCapability(t *testing.T) {
+	assert := assert.New(t)
+	ctx := tokenContext("jwt", map[string]interface{}{
+		"capabilities": []interface{}{"x1:webpa:api:.*:all", "x1:tr1d1um:hooks:admin"},
+	})
+
+	assert.Equal([]string{"x1:webpa:api:.*:all", "x1:tr1d1um:hooks:admin"}, Capabilities(ctx))
+	assert.True(HasCapability(ctx, "x1:tr1d1um:hooks:admin"))
+	assert.False(HasCapability(ctx, "x1:tr1d1um:other"))
+	assert.False(HasCapability(ctx, ""))
+	assert.False(HasCapability(context.Background(), "x1:tr1d1um:hooks:admin"))
+}
+
+func TestPartnerPolicy(t *testing.T) {
+	jwtContext := func(partners ...interface{}) context.Context {
+		return tokenContext("jwt", map[string]interface{}{
+			"allowedResources": map[string]interface{}{
+				"allowedPartners": partners,
+			},
+		})
+	}
+
+	policy := &PartnerPolicy{
+		RequireTokenPartners: []string{"basic"},
+		EnforceHeaderSubset:  true,
+		Wildcard:             "*",
+	}
+
+	testCases := []struct {
+		Name            string
+		Policy          *PartnerPolicy
+		Ctx             context.Context
+		HeaderPartners  []string
+		Expected        []string
+		ExpectedMessage string
+	}{
+		{Name: "Nil policy", Ctx: tokenContext("basic", nil), HeaderPartners: []string{"partnerA"}, Expected: []string{"partnerA"}},
+		{Name: "Token partners", Policy: policy, Ctx: jwtContext("partnerA", "partnerB"), Expected: []string{"partnerA", "partnerB"}},
+		{Name: "Header subset", Policy: policy, Ctx: jwtContext("partnerA", "partnerB"), HeaderPartners: []string{"partnerB"}, Expected: []string{"partnerA", "partnerB"}},
+		{Name: "Header not a subset", Policy: policy, Ctx: jwtContext("partnerA"), HeaderPartners: []string{"partnerA", "partnerB", "partnerC"}, ExpectedMessage: "partner IDs forbidden: partnerB, partnerC not allowed by the token"},
+		{Name: "Header not a subset without enforcement", Policy: &PartnerPolicy{}, Ctx: jwtContext("partnerA"), HeaderPartners: []string{"partnerB"}, Expected: []string{"partnerA"}},
+		{Name: "Wildcard with header", Policy: policy, Ctx: jwtContext("*"), HeaderPartners: []string{"partnerB"}, Expected: []string{"partnerB"}},
+		{Name: "Wildcard without header", Policy: policy, Ctx: jwtContext("*"), Expected: []string{"*"}},
+		{Name: "Required token partners", Policy: policy, Ctx: tokenContext("basic", nil), HeaderPartners: []string{"partnerA"}, ExpectedMessage: "partner IDs forbidden: token partner IDs are required for basic authentication"},
+		{Name: "Not required token partners", Policy: policy, Ctx: jwtContext(), HeaderPartners: []string{"partnerA"}, Expected: []string{"partnerA"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			h := http.Header{}
+			for _, p := range testCase.HeaderPartners {
+				h.Add("X-Xmidt-Partner-Id", p)
+			}
+
+			partnerIDs, err := testCase.Policy.PartnerIDs(testCase.Ctx, h)
+			if testCase.ExpectedMessage == "" {
+				assert.Nil(err)
+				assert.Equal(testCase.Expected, partnerIDs)
+				return
+			}
+
+			assert.Nil(partnerIDs)
+			assert.Equal(testCase.ExpectedMessage, err.Error())
+			assert.Equal(http.StatusForbidden, err.(CodedError).StatusCode())
+		})
+	}
+}
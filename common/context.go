@@ -7,4 +7,6 @@ const (
 	ContextKeyRequestArrivalTime contextKey = iota
 	ContextKeyRequestTID
 	ContextKeyTransactionInfoLogger
+	ContextKeyRequestAccept
+	ContextKeyRequestHeader
 )
@@ -0,0 +1,84 @@
+package common
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/xmidt-org/webpa-common/device"
+)
+
+// maxCachedDeviceEntries bounds the compiled allowed devices claim entries kept around. The
+// cache is emptied when it's full, which only happens if tokens carry many distinct entries.
+const maxCachedDeviceEntries = 1024
+
+// deviceEntry is a compiled entry of an allowed devices claim. Entries may be a device ID,
+// a device ID regular expression or both. Entries being neither are invalid.
+type deviceEntry struct {
+	id      device.ID
+	isID    bool
+	pattern *regexp.Regexp
+}
+
+var deviceEntries = struct {
+	lock    sync.Mutex
+	entries map[string]deviceEntry
+}{entries: make(map[string]deviceEntry)}
+
+// compileDeviceEntry returns the compiled entry a, compiling it unless it's cached.
+func compileDeviceEntry(a string) deviceEntry {
+	deviceEntries.lock.Lock()
+	defer deviceEntries.lock.Unlock()
+
+	if e, ok := deviceEntries.entries[a]; ok {
+		return e
+	}
+
+	var e deviceEntry
+	if id, err := device.ParseID(a); err == nil {
+		e.id, e.isID = id, true
+	}
+	if r, err := regexp.Compile("^(?:" + a + ")$"); err == nil {
+		e.pattern = r
+	}
+
+	if len(deviceEntries.entries) >= maxCachedDeviceEntries {
+		deviceEntries.entries = make(map[string]deviceEntry)
+	}
+	deviceEntries.entries[a] = e
+	return e
+}
+
+// AllowedDevices is a compiled allowed devices claim, which lists device IDs or device ID
+// regular expressions. Compile claims once per token or per run rather than per device.
+type AllowedDevices struct {
+	entries []deviceEntry
+
+	// Invalid lists the entries that are neither a device ID nor a valid regular expression.
+	// They never allow any device.
+	Invalid []string
+}
+
+// NewAllowedDevices compiles an allowed devices claim. Compiled entries are cached as the
+// claims of tokens are the same from one request to the next.
+func NewAllowedDevices(claim []string) AllowedDevices {
+	a := AllowedDevices{entries: make([]deviceEntry, 0, len(claim))}
+	for _, entry := range claim {
+		e := compileDeviceEntry(entry)
+		if !e.isID && e.pattern == nil {
+			a.Invalid = append(a.Invalid, entry)
+			continue
+		}
+		a.entries = append(a.entries, e)
+	}
+	return a
+}
+
+// Allows reports whether one of the entries of the claim allows id.
+func (a AllowedDevices) Allows(id device.ID) bool {
+	for _, e := range a.entries {
+		if (e.isID && e.id == id) || (e.pattern != nil && e.pattern.MatchString(string(id))) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowedDevices(t *testing.T) {
+	assert := assert.New(t)
+	allowed := NewAllowedDevices([]string{"mac:11-22-33-44-55-66", "serial:abc.*", "(bad"})
+
+	assert.True(allowed.Allows("mac:112233445566"))
+	assert.True(allowed.Allows("serial:abc123"))
+	assert.False(allowed.Allows("mac:aabbccddeeff"))
+	assert.Equal([]string{"(bad"}, allowed.Invalid)
+
+	assert.False(NewAllowedDevices(nil).Allows("mac:112233445566"))
+}
+
+func TestCompileDeviceEntryCache(t *testing.T) {
+	assert := assert.New(t)
+
+	first := compileDeviceEntry("serial:cache.*")
+	assert.Same(first.pattern, compileDeviceEntry("serial:cache.*").pattern)
+
+	for i := 0; i <= maxCachedDeviceEntries; i++ {
+		compileDeviceEntry(string(rune('a'+i%26)) + string(rune(i)))
+	}
+
+	deviceEntries.lock.Lock()
+	size := len(deviceEntries.entries)
+	deviceEntries.lock.Unlock()
+	assert.LessOrEqual(size, maxCachedDeviceEntries)
+}
@@ -0,0 +1,155 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/provider"
+)
+
+// Metric names of the shutdown drain phase.
+const (
+	DrainRejectedRequestsCounter  = "drain_rejected_requests"
+	DrainAbandonedRequestsCounter = "drain_abandoned_requests"
+)
+
+// ErrorCodeShuttingDown is the error code of requests rejected while draining.
+const ErrorCodeShuttingDown = "shutting-down"
+
+// ErrShuttingDown is returned to the requests arriving once tr1d1um started draining.
+var ErrShuttingDown = NewProblemError(errors.New("tr1d1um is shutting down. Retry on a different instance"), http.StatusServiceUnavailable, ErrorCodeShuttingDown, "Shutting down")
+
+// Drainer keeps track of in-flight requests so they can finish before tr1d1um stops.
+// Once draining starts, Ready returns false and new requests are rejected.
+type Drainer struct {
+	lock     sync.Mutex
+	inFlight int
+	draining bool
+	idle     chan struct{}
+
+	rejected  metrics.Counter
+	abandoned metrics.Counter
+}
+
+// NewDrainer returns a Drainer using the metrics of the provider. A nil provider discards them.
+func NewDrainer(p provider.Provider) *Drainer {
+	if p == nil {
+		p = provider.NewDiscardProvider()
+	}
+
+	return &Drainer{
+		idle:      make(chan struct{}),
+		rejected:  p.NewCounter(DrainRejectedRequestsCounter),
+		abandoned: p.NewCounter(DrainAbandonedRequestsCounter),
+	}
+}
+
+// Track is an Alice-style constructor counting the requests of next as in flight until they
+// complete. Requests arriving while draining are rejected with ErrShuttingDown. A nil Drainer
+// tracks nothing.
+func (d *Drainer) Track(next http.Handler) http.Handler {
+	if d == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !d.start() {
+			d.rejected.Add(1)
+			ctx := context.WithValue(r.Context(), ContextKeyRequestTID, r.Header.Get(HeaderWPATID))
+			ctx = context.WithValue(ctx, ContextKeyRequestAccept, r.Header.Get("Accept"))
+			w.Header().Set("Connection", "close")
+			WriteError(ctx, ErrShuttingDown, w)
+			return
+		}
+
+		defer d.done()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Run runs f as a request in flight unless draining, in which case f is rejected with
+// ErrShuttingDown. It's meant for requests which are not HTTP requests, such as the commands
+// of long-lived sessions. A nil Drainer runs f right away.
+func (d *Drainer) Run(f func()) error {
+	if d == nil {
+		f()
+		return nil
+	}
+
+	if !d.start() {
+		d.rejected.Add(1)
+		return ErrShuttingDown
+	}
+
+	defer d.done()
+	f()
+	return nil
+}
+
+func (d *Drainer) start() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.draining {
+		return false
+	}
+	d.inFlight++
+	return true
+}
+
+func (d *Drainer) done() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.inFlight--
+	if d.draining && d.inFlight == 0 {
+		close(d.idle)
+	}
+}
+
+// Ready returns false once draining started. A nil Drainer is always ready.
+func (d *Drainer) Ready() bool {
+	if d == nil {
+		return true
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return !d.draining
+}
+
+// InFlight returns the number of requests in flight.
+func (d *Drainer) InFlight() int {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.inFlight
+}
+
+// Drain stops accepting requests and waits up to gracePeriod for the ones in flight to
+// complete. It returns the number of requests still in flight at the deadline, which are
+// abandoned. Calling Drain more than once is a programming error.
+func (d *Drainer) Drain(gracePeriod time.Duration) int {
+	d.lock.Lock()
+	d.draining = true
+	if d.inFlight == 0 {
+		close(d.idle)
+	}
+	d.lock.Unlock()
+
+	timer := time.NewTimer(gracePeriod)
+	defer timer.Stop()
+
+	select {
+	case <-d.idle:
+		return 0
+	case <-timer.C:
+	}
+
+	abandoned := d.InFlight()
+	d.abandoned.Add(float64(abandoned))
+	return abandoned
+}
@@ -0,0 +1,112 @@
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/webpa-common/xmetrics/xmetricstest"
+)
+
+// blockingHandler serves requests once they are released, signaling when they started.
+func blockingHandler() (http.Handler, <-chan struct{}, chan<- struct{}) {
+	started, release := make(chan struct{}, 10), make(chan struct{})
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}), started, release
+}
+
+func TestDrainerTrack(t *testing.T) {
+	t.Run("NilDrainer", func(t *testing.T) {
+		assert := assert.New(t)
+		next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {})
+
+		var d *Drainer
+		assert.NotNil(d.Track(next))
+	})
+
+	t.Run("Draining", func(t *testing.T) {
+		assert := assert.New(t)
+		p := xmetricstest.NewProvider(nil, Metrics)
+		d := NewDrainer(p)
+		handler := d.Track(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(http.StatusOK, w.Code)
+		assert.Zero(d.InFlight())
+
+		assert.True(d.Ready())
+		assert.Zero(d.Drain(time.Second))
+		assert.False(d.Ready())
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", ProblemContentType)
+		r.Header.Set(HeaderWPATID, "tid")
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		var problem Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(http.StatusServiceUnavailable, w.Code)
+		assert.Equal("close", w.Header().Get("Connection"))
+		assert.Equal(ErrorCodeShuttingDown, problem.Code)
+		assert.Equal("tid", problem.TID)
+		p.Assert(t, DrainRejectedRequestsCounter)(xmetricstest.Value(1))
+	})
+}
+
+func TestDrainerDrain(t *testing.T) {
+	t.Run("Completed", func(t *testing.T) {
+		assert := assert.New(t)
+		d := NewDrainer(nil)
+		next, started, release := blockingHandler()
+		handler := d.Track(next)
+
+		go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		<-started
+		assert.Equal(1, d.InFlight())
+
+		time.AfterFunc(10*time.Millisecond, func() { close(release) })
+		assert.Zero(d.Drain(time.Minute))
+		assert.Zero(d.InFlight())
+	})
+
+	t.Run("Abandoned", func(t *testing.T) {
+		assert := assert.New(t)
+		p := xmetricstest.NewProvider(nil, Metrics)
+		d := NewDrainer(p)
+		next, started, release := blockingHandler()
+		defer close(release)
+		handler := d.Track(next)
+
+		for i := 0; i < 2; i++ {
+			go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+			<-started
+		}
+
+		assert.Equal(2, d.Drain(10*time.Millisecond))
+		p.Assert(t, DrainAbandonedRequestsCounter)(xmetricstest.Value(2))
+	})
+}
+
+func TestDrainerRun(t *testing.T) {
+	assert := assert.New(t)
+	var d *Drainer
+	var runs int
+	assert.Nil(d.Run(func() { runs++ }))
+
+	d = NewDrainer(nil)
+	assert.Nil(d.Run(func() { runs++ }))
+	assert.Zero(d.Drain(0))
+	assert.Equal(ErrShuttingDown, d.Run(func() { runs++ }))
+	assert.Equal(2, runs)
+}
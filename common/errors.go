@@ -1,8 +1,12 @@
 package common
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"mime"
 	"net/http"
+	"strings"
 )
 
 // ErrTr1d1umInternal should be the error shown to external API consumers in Internal Server error cases
@@ -36,3 +40,150 @@ func NewCodedError(e error, code int) CodedError {
 		statusCode: code,
 	}
 }
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypePrefix prefixes the error codes in the type URIs of problem details.
+const ProblemTypePrefix = "urn:tr1d1um:error:"
+
+// Error codes of the errors that don't have a more specific one.
+const (
+	ErrorCodeInternal        = "internal"
+	ErrorCodeUnavailable     = "xmidt-unavailable"
+	ErrorCodeInvalidDeviceID = "invalid-device-id"
+)
+
+// ProblemError is a CodedError that additionally describes the problem details API
+// consumers get for it
+type ProblemError interface {
+	CodedError
+
+	// ErrorCode is a stable machine-readable code consumers can match on instead of the message.
+	ErrorCode() string
+
+	// Title is a short summary of the kind of problem. It doesn't change across occurrences.
+	Title() string
+
+	// Fields are the names of the offending request fields, if any.
+	Fields() []string
+}
+
+type problemError struct {
+	codedError
+	code   string
+	title  string
+	fields []string
+}
+
+func (p *problemError) ErrorCode() string { return p.code }
+func (p *problemError) Title() string     { return p.title }
+func (p *problemError) Fields() []string  { return p.fields }
+func (p *problemError) Unwrap() error     { return p.error }
+
+// NewProblemError upgrades an Error to a ProblemError. The message of e is the problem detail.
+// e must not be nil
+func NewProblemError(e error, statusCode int, code, title string, fields ...string) ProblemError {
+	return &problemError{
+		codedError: codedError{error: e, statusCode: statusCode},
+		code:       code,
+		title:      title,
+		fields:     fields,
+	}
+}
+
+// NewUnavailableError is the constructor for the error returned when the XMiDT cluster
+// could not be reached
+func NewUnavailableError(e error) ProblemError {
+	return NewProblemError(e, http.StatusServiceUnavailable, ErrorCodeUnavailable, "XMiDT cluster unavailable")
+}
+
+// NewInvalidDeviceIDError is the constructor for the error returned when the device ID
+// in the request path could not be parsed
+func NewInvalidDeviceIDError(e error) ProblemError {
+	return NewProblemError(e, http.StatusBadRequest, ErrorCodeInvalidDeviceID, "Invalid device ID", "deviceid")
+}
+
+// Problem is the RFC 7807 problem details body of error responses
+type Problem struct {
+	Type   string   `json:"type"`
+	Title  string   `json:"title"`
+	Status int      `json:"status"`
+	Detail string   `json:"detail"`
+	Code   string   `json:"code"`
+	TID    string   `json:"tid,omitempty"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+// NewProblem returns the problem details API consumers get for err, which may wrap a
+// ProblemError or CodedError. As with the legacy error bodies, the details of other errors
+// are masked.
+func NewProblem(err error, tid string) Problem {
+	p := Problem{
+		Status: http.StatusInternalServerError,
+		Detail: ErrTr1d1umInternal.Error(),
+		Code:   ErrorCodeInternal,
+		TID:    tid,
+	}
+
+	var (
+		problemErr ProblemError
+		codedErr   CodedError
+	)
+	switch {
+	case errors.As(err, &problemErr):
+		p.Status, p.Detail, p.Code, p.Title, p.Fields = problemErr.StatusCode(), problemErr.Error(), problemErr.ErrorCode(), problemErr.Title(), problemErr.Fields()
+	case errors.As(err, &codedErr):
+		p.Status, p.Detail, p.Code = codedErr.StatusCode(), codedErr.Error(), statusErrorCode(codedErr.StatusCode())
+	}
+
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	p.Type = ProblemTypePrefix + p.Code
+	return p
+}
+
+// statusErrorCode derives the error code of CodedErrors without one from their status code,
+// e.g. 'not-found' for 404.
+func statusErrorCode(statusCode int) string {
+	text := http.StatusText(statusCode)
+	if text == "" {
+		return ErrorCodeInternal
+	}
+	return strings.ToLower(strings.ReplaceAll(text, " ", "-"))
+}
+
+// WriteError is the error encoder of the tr1d1um go-kit servers. err is written as problem
+// details when the request accepts them and as a {"message": ...} body otherwise.
+func WriteError(ctx context.Context, err error, w http.ResponseWriter) {
+	tid, _ := ctx.Value(ContextKeyRequestTID).(string)
+	accept, _ := ctx.Value(ContextKeyRequestAccept).(string)
+	problem := NewProblem(err, tid)
+
+	w.Header().Set(HeaderWPATID, tid)
+	if AcceptsProblem(accept) {
+		w.Header().Set("Content-Type", ProblemContentType)
+		w.WriteHeader(problem.Status)
+		json.NewEncoder(w).Encode(problem)
+		return
+	}
+
+	//the real error is logged into our system before the error is encoded
+	//the idea behind masking it is to not send the external API consumer internal error messages
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": problem.Detail,
+	})
+}
+
+// AcceptsProblem returns true if the Accept header value lists the problem details media type.
+func AcceptsProblem(accept string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		if mediaType, _, err := mime.ParseMediaType(mediaRange); err == nil && mediaType == ProblemContentType {
+			return true
+		}
+	}
+	return false
+}
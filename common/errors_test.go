@@ -1,7 +1,11 @@
 package common
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -22,3 +26,145 @@ func TestBadRequestError(t *testing.T) {
 	assert.EqualValues(400, ce.StatusCode())
 	assert.EqualValues("test", ce.Error())
 }
+
+func TestNewProblemError(t *testing.T) {
+	assert := assert.New(t)
+	cause := errors.New("test")
+	var pe = NewProblemError(cause, 400, "test-code", "Test", "field")
+	assert.EqualValues(400, pe.StatusCode())
+	assert.EqualValues("test", pe.Error())
+	assert.EqualValues("test-code", pe.ErrorCode())
+	assert.EqualValues("Test", pe.Title())
+	assert.EqualValues([]string{"field"}, pe.Fields())
+	assert.True(errors.Is(pe, cause))
+}
+
+func TestNewProblem(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Err      error
+		Expected Problem
+	}{
+		{
+			Name: "Problem Error",
+			Err:  NewInvalidDeviceIDError(errors.New("bad device")),
+			Expected: Problem{
+				Type:   ProblemTypePrefix + ErrorCodeInvalidDeviceID,
+				Title:  "Invalid device ID",
+				Status: http.StatusBadRequest,
+				Detail: "bad device",
+				Code:   ErrorCodeInvalidDeviceID,
+				TID:    "tid",
+				Fields: []string{"deviceid"},
+			},
+		},
+		{
+			Name: "Coded Error",
+			Err:  NewCodedError(errors.New("gone"), http.StatusNotFound),
+			Expected: Problem{
+				Type:   ProblemTypePrefix + "not-found",
+				Title:  "Not Found",
+				Status: http.StatusNotFound,
+				Detail: "gone",
+				Code:   "not-found",
+				TID:    "tid",
+			},
+		},
+		{
+			Name: "Wrapped Problem Error",
+			Err:  fmt.Errorf("failed to parse: %w", NewInvalidDeviceIDError(errors.New("bad device"))),
+			Expected: Problem{
+				Type:   ProblemTypePrefix + ErrorCodeInvalidDeviceID,
+				Title:  "Invalid device ID",
+				Status: http.StatusBadRequest,
+				Detail: "bad device",
+				Code:   ErrorCodeInvalidDeviceID,
+				TID:    "tid",
+				Fields: []string{"deviceid"},
+			},
+		},
+		{
+			Name: "Wrapped Coded Error",
+			Err:  fmt.Errorf("failed to get: %w", NewCodedError(errors.New("gone"), http.StatusNotFound)),
+			Expected: Problem{
+				Type:   ProblemTypePrefix + "not-found",
+				Title:  "Not Found",
+				Status: http.StatusNotFound,
+				Detail: "gone",
+				Code:   "not-found",
+				TID:    "tid",
+			},
+		},
+		{
+			Name: "Internal Error",
+			Err:  errors.New("internal details"),
+			Expected: Problem{
+				Type:   ProblemTypePrefix + ErrorCodeInternal,
+				Title:  "Internal Server Error",
+				Status: http.StatusInternalServerError,
+				Detail: ErrTr1d1umInternal.Error(),
+				Code:   ErrorCodeInternal,
+				TID:    "tid",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert.Equal(t, testCase.Expected, NewProblem(testCase.Err, "tid"))
+		})
+	}
+}
+
+func TestAcceptsProblem(t *testing.T) {
+	testCases := []struct {
+		Accept   string
+		Expected bool
+	}{
+		{Accept: "", Expected: false},
+		{Accept: "application/json", Expected: false},
+		{Accept: "*/*", Expected: false},
+		{Accept: "application/problem+json", Expected: true},
+		{Accept: "application/json, application/problem+json;q=0.9", Expected: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Accept, func(t *testing.T) {
+			assert.Equal(t, testCase.Expected, AcceptsProblem(testCase.Accept))
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ContextKeyRequestTID, "tid")
+	err := NewUnavailableError(errors.New("connection refused"))
+
+	t.Run("Legacy", func(t *testing.T) {
+		assert := assert.New(t)
+		w := httptest.NewRecorder()
+		WriteError(ctx, err, w)
+
+		assert.Equal(http.StatusServiceUnavailable, w.Code)
+		assert.Equal("application/json; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal("tid", w.Header().Get(HeaderWPATID))
+		assert.JSONEq(`{"message":"connection refused"}`, w.Body.String())
+	})
+
+	t.Run("Problem", func(t *testing.T) {
+		assert := assert.New(t)
+		w := httptest.NewRecorder()
+		WriteError(context.WithValue(ctx, ContextKeyRequestAccept, ProblemContentType), err, w)
+
+		assert.Equal(http.StatusServiceUnavailable, w.Code)
+		assert.Equal(ProblemContentType, w.Header().Get("Content-Type"))
+		assert.Equal("tid", w.Header().Get(HeaderWPATID))
+		assert.JSONEq(`{
+			"type": "urn:tr1d1um:error:xmidt-unavailable",
+			"title": "XMiDT cluster unavailable",
+			"status": 503,
+			"detail": "connection refused",
+			"code": "xmidt-unavailable",
+			"tid": "tid"
+		}`, w.Body.String())
+	})
+}
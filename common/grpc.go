@@ -0,0 +1,191 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kitgrpc "github.com/go-kit/kit/transport/grpc"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/webpa-common/logging"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the error details of gRPC errors.
+const ErrorDomain = "tr1d1um"
+
+// grpcCodes are the gRPC counterparts of the HTTP status codes of errors.
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.Aborted,
+	http.StatusPreconditionFailed:  codes.FailedPrecondition,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	http.StatusInternalServerError: codes.Internal,
+	http.StatusNotImplemented:      codes.Unimplemented,
+	http.StatusBadGateway:          codes.Unavailable,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+	http.StatusGatewayTimeout:      codes.DeadlineExceeded,
+}
+
+// GRPCHeader returns the metadata of a gRPC request as HTTP headers such that the helpers
+// of the HTTP API, i.e. the ones reading partner IDs, apply to it.
+func GRPCHeader(md metadata.MD) http.Header {
+	h := make(http.Header, len(md))
+	for k, v := range md {
+		h[textproto.CanonicalMIMEHeaderKey(k)] = v
+	}
+	return h
+}
+
+// RequestHeader returns the headers of the gRPC request in ctx as captured by CaptureGRPC.
+func RequestHeader(ctx context.Context) http.Header {
+	h, _ := ctx.Value(ContextKeyRequestHeader).(http.Header)
+	return h
+}
+
+// CaptureGRPC is the gRPC counterpart of Welcome and Capture. The TID of the request is
+// sent back as response header metadata right away so callers get it along with errors.
+func CaptureGRPC(logger kitlog.Logger) kitgrpc.ServerRequestFunc {
+	var transactionInfoLogger = logging.Info(logger)
+	return func(ctx context.Context, md metadata.MD) context.Context {
+		header := GRPCHeader(md)
+		tid := header.Get(HeaderWPATID)
+		if tid == "" {
+			tid = NewTID()
+		}
+
+		// fails when ctx has no gRPC stream, i.e. in tests
+		grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(HeaderWPATID), tid))
+
+		ctx = context.WithValue(ctx, ContextKeyRequestArrivalTime, time.Now())
+		ctx = context.WithValue(ctx, ContextKeyRequestTID, tid)
+		ctx = context.WithValue(ctx, ContextKeyRequestHeader, header)
+
+		var satClientID = "N/A"
+		if auth, ok := bascule.FromContext(ctx); ok {
+			satClientID = auth.Token.Principal()
+		}
+
+		var address string
+		if p, ok := peer.FromContext(ctx); ok {
+			address = p.Addr.String()
+		}
+		method, _ := grpc.Method(ctx)
+
+		return context.WithValue(ctx, ContextKeyTransactionInfoLogger, kitlog.WithPrefix(transactionInfoLogger,
+			logging.MessageKey(), "record",
+			"request", transactionRequest{
+				Address: address,
+				Path:    method,
+			},
+			"tid", tid,
+			"satClientID", satClientID,
+		))
+	}
+}
+
+// GRPCTransactionLogging is the gRPC counterpart of TransactionLogging. The response code
+// logged is the gRPC one.
+func GRPCTransactionLogging(ctx context.Context, err error) {
+	transactionInfoLogger, ok := ctx.Value(ContextKeyTransactionInfoLogger).(kitlog.Logger)
+	if !ok {
+		return
+	}
+
+	if requestArrival, ok := ctx.Value(ContextKeyRequestArrivalTime).(time.Time); ok {
+		transactionInfoLogger = kitlog.WithPrefix(transactionInfoLogger, "duration", time.Since(requestArrival))
+	}
+
+	transactionInfoLogger.Log("response", transactionResponse{Code: int(GRPCCode(err))})
+}
+
+// GRPCErrorLogger is the gRPC counterpart of ErrorLogEncoder. It logs errors with the TID of
+// their request.
+func GRPCErrorLogger(logger kitlog.Logger) transport.ErrorHandler {
+	var errorLogger = logging.Error(logger)
+	return transport.ErrorHandlerFunc(func(ctx context.Context, err error) {
+		tid, _ := ctx.Value(ContextKeyRequestTID).(string)
+		errorLogger.Log(logging.ErrorKey(), err.Error(), "tid", tid)
+	})
+}
+
+// NewXmidtResponseError returns the error of a non-200 response of the XMiDT cluster for the
+// transports that cannot forward the response as is.
+func NewXmidtResponseError(resp *XmidtResponse) CodedError {
+	return NewCodedError(fmt.Errorf("XMiDT cluster responded with %d: %s", resp.Code, strings.TrimSpace(string(resp.Body))), resp.Code)
+}
+
+// GRPCCode returns the gRPC code of the error API consumers get for err.
+func GRPCCode(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+
+	if s, ok := status.FromError(err); ok {
+		return s.Code()
+	}
+
+	problemStatus := NewProblem(err, "").Status
+	if code, ok := grpcCodes[problemStatus]; ok {
+		return code
+	}
+	if problemStatus >= http.StatusInternalServerError {
+		return codes.Internal
+	}
+	return codes.FailedPrecondition
+}
+
+// GRPCError returns the gRPC status error API consumers get for err. Its details hold
+// the error code, TID and fields of the problem HTTP API consumers would get.
+func GRPCError(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	tid, _ := ctx.Value(ContextKeyRequestTID).(string)
+	problem := NewProblem(err, tid)
+
+	info := &errdetails.ErrorInfo{
+		Reason:   problem.Code,
+		Domain:   ErrorDomain,
+		Metadata: map[string]string{"tid": tid},
+	}
+	if len(problem.Fields) > 0 {
+		info.Metadata["fields"] = strings.Join(problem.Fields, ",")
+	}
+
+	s, detailsErr := status.New(GRPCCode(err), problem.Detail).WithDetails(info)
+	if detailsErr != nil {
+		return status.Error(GRPCCode(err), problem.Detail)
+	}
+	return s.Err()
+}
+
+// TrackUnary is the gRPC counterpart of Track, as a unary server interceptor. A nil Drainer
+// tracks nothing.
+func (d *Drainer) TrackUnary(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if d == nil {
+		return handler(ctx, req)
+	}
+
+	if !d.start() {
+		d.rejected.Add(1)
+		return nil, GRPCError(ctx, ErrShuttingDown)
+	}
+
+	defer d.done()
+	return handler(ctx, req)
+}
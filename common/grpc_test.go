@@ -0,0 +1,106 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/webpa-common/logging"
+	"github.com/xmidt-org/webpa-common/xmetrics/xmetricstest"
+	"github.com/xmidt-org/wrp-go/v3/wrphttp"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestCaptureGRPC(t *testing.T) {
+	t.Run("Metadata", func(t *testing.T) {
+		assert := assert.New(t)
+		md := metadata.Pairs("x-webpa-transaction-id", "tid", "x-xmidt-partner-id", "partner0", "authorization", "Basic dGVzdDp0ZXN0")
+		ctx := CaptureGRPC(logging.NewTestLogger(nil, t))(context.Background(), md)
+
+		assert.Equal("tid", ctx.Value(ContextKeyRequestTID))
+		assert.Equal("Basic dGVzdDp0ZXN0", RequestHeader(ctx).Get("Authorization"))
+		assert.Equal([]string{"partner0"}, PartnerIDs(ctx, RequestHeader(ctx)))
+		assert.NotNil(ctx.Value(ContextKeyTransactionInfoLogger))
+	})
+
+	t.Run("Generated TID", func(t *testing.T) {
+		ctx := CaptureGRPC(logging.NewTestLogger(nil, t))(context.Background(), nil)
+		assert.NotEmpty(t, ctx.Value(ContextKeyRequestTID))
+		assert.Empty(t, RequestHeader(ctx).Get(wrphttp.PartnerIdHeader))
+	})
+}
+
+func TestGRPCCode(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		Err          error
+		ExpectedCode codes.Code
+	}{
+		{Name: "Nil", ExpectedCode: codes.OK},
+		{Name: "Status", Err: status.Error(codes.Aborted, "aborted"), ExpectedCode: codes.Aborted},
+		{Name: "Bad Request", Err: NewBadRequestError(errors.New("bad")), ExpectedCode: codes.InvalidArgument},
+		{Name: "Forbidden", Err: NewCodedError(errors.New("forbidden"), http.StatusForbidden), ExpectedCode: codes.PermissionDenied},
+		{Name: "Unavailable", Err: ErrShuttingDown, ExpectedCode: codes.Unavailable},
+		{Name: "Other Client Error", Err: NewCodedError(errors.New("gone"), http.StatusGone), ExpectedCode: codes.FailedPrecondition},
+		{Name: "Other Server Error", Err: NewCodedError(errors.New("loop"), http.StatusLoopDetected), ExpectedCode: codes.Internal},
+		{Name: "Uncoded", Err: errors.New("internal"), ExpectedCode: codes.Internal},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.ExpectedCode, GRPCCode(tc.Err))
+		})
+	}
+}
+
+func TestGRPCError(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.WithValue(context.Background(), ContextKeyRequestTID, "tid")
+	err := GRPCError(ctx, NewProblemError(errors.New("bad id"), http.StatusBadRequest, ErrorCodeInvalidDeviceID, "Invalid device ID", "deviceID"))
+
+	s, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(codes.InvalidArgument, s.Code())
+	assert.Equal("bad id", s.Message())
+	require.Len(t, s.Details(), 1)
+
+	info := s.Details()[0].(*errdetails.ErrorInfo)
+	assert.Equal(ErrorCodeInvalidDeviceID, info.Reason)
+	assert.Equal(ErrorDomain, info.Domain)
+	assert.Equal(map[string]string{"tid": "tid", "fields": "deviceID"}, info.Metadata)
+
+	assert.Equal(err, GRPCError(ctx, err))
+}
+
+func TestDrainerTrackUnary(t *testing.T) {
+	handler := func(context.Context, interface{}) (interface{}, error) { return "response", nil }
+
+	t.Run("NilDrainer", func(t *testing.T) {
+		var d *Drainer
+		response, err := d.TrackUnary(context.Background(), nil, nil, handler)
+		assert.Nil(t, err)
+		assert.Equal(t, "response", response)
+	})
+
+	t.Run("Draining", func(t *testing.T) {
+		assert := assert.New(t)
+		p := xmetricstest.NewProvider(nil, Metrics)
+		d := NewDrainer(p)
+
+		response, err := d.TrackUnary(context.Background(), nil, nil, handler)
+		assert.Nil(err)
+		assert.Equal("response", response)
+		assert.Zero(d.InFlight())
+
+		assert.Zero(d.Drain(0))
+		_, err = d.TrackUnary(context.Background(), nil, nil, handler)
+		assert.Equal(codes.Unavailable, status.Code(err))
+		p.Assert(t, DrainRejectedRequestsCounter)(xmetricstest.Value(1))
+	})
+}
@@ -0,0 +1,185 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/provider"
+	"github.com/gorilla/mux"
+	"github.com/xmidt-org/webpa-common/xmetrics"
+)
+
+// Metric names of the API routes and of the requests to the XMiDT cluster.
+const (
+	RouteRequestsCounter          = "route_requests"
+	RouteRequestDurationHistogram = "route_request_duration_seconds"
+	RouteInFlightGauge            = "route_in_flight_requests"
+	DeviceResponsesCounter        = "device_responses"
+	XmidtRequestDurationHistogram = "xmidt_request_duration_seconds"
+)
+
+// Metric labels.
+const (
+	RouteLabel   = "route"
+	MethodLabel  = "method"
+	CommandLabel = "command"
+	ServiceLabel = "service"
+	CodeLabel    = "code"
+	TargetLabel  = "target"
+)
+
+// Targets of the requests to the XMiDT cluster.
+const (
+	StatTarget   = "stat"
+	DeviceTarget = "device"
+)
+
+// errorCode is the code label value of requests to the XMiDT cluster that got no response.
+const errorCode = "error"
+
+// durationBuckets cover responses from devices, which may take as long as the request timeouts.
+var durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 135}
+
+// Metrics returns the metrics relevant to this package.
+func Metrics() []xmetrics.Metric {
+	return []xmetrics.Metric{
+		{
+			Name:       RouteRequestsCounter,
+			Type:       xmetrics.CounterType,
+			Help:       "Count of requests to the translation and stat routes.",
+			LabelNames: []string{RouteLabel, MethodLabel, CommandLabel, ServiceLabel, CodeLabel},
+		},
+		{
+			Name:       RouteRequestDurationHistogram,
+			Type:       xmetrics.HistogramType,
+			Help:       "Latency of the requests to the translation and stat routes.",
+			LabelNames: []string{RouteLabel, MethodLabel, CommandLabel, ServiceLabel, CodeLabel},
+			Buckets:    durationBuckets,
+		},
+		{
+			Name:       RouteInFlightGauge,
+			Type:       xmetrics.GaugeType,
+			Help:       "Number of requests to the translation and stat routes being served.",
+			LabelNames: []string{RouteLabel},
+		},
+		{
+			Name:       DeviceResponsesCounter,
+			Type:       xmetrics.CounterType,
+			Help:       "Count of device responses by the status code the devices reported.",
+			LabelNames: []string{ServiceLabel, CommandLabel, CodeLabel},
+		},
+		{
+			Name:       XmidtRequestDurationHistogram,
+			Type:       xmetrics.HistogramType,
+			Help:       "Latency of the requests to the XMiDT cluster by response code.",
+			LabelNames: []string{TargetLabel, CodeLabel},
+			Buckets:    durationBuckets,
+		},
+		{
+			Name: DrainRejectedRequestsCounter,
+			Type: xmetrics.CounterType,
+			Help: "Count of translation and stat requests rejected while draining at shutdown.",
+		},
+		{
+			Name: DrainAbandonedRequestsCounter,
+			Type: xmetrics.CounterType,
+			Help: "Count of translation and stat requests still in flight when the shutdown grace period ended.",
+		},
+	}
+}
+
+// RouteMetrics measures the requests of the routes served by a go-kit server.
+type RouteMetrics struct {
+	requests        metrics.Counter
+	duration        metrics.Histogram
+	inFlight        metrics.Gauge
+	deviceResponses metrics.Counter
+}
+
+// NewRouteMetrics returns the route metrics of the provider. A nil provider discards them.
+func NewRouteMetrics(p provider.Provider) *RouteMetrics {
+	if p == nil {
+		p = provider.NewDiscardProvider()
+	}
+
+	return &RouteMetrics{
+		requests:        p.NewCounter(RouteRequestsCounter),
+		duration:        p.NewHistogram(RouteRequestDurationHistogram, 0),
+		inFlight:        p.NewGauge(RouteInFlightGauge),
+		deviceResponses: p.NewCounter(DeviceResponsesCounter),
+	}
+}
+
+type routeObservationKey struct{}
+
+// routeObservation collects the label values of a request known only once it is decoded
+// or encoded.
+type routeObservation struct {
+	start        time.Time
+	route        string
+	service      string
+	command      string
+	deviceStatus int
+}
+
+// SetRouteLabels sets the device service and WDMP command labels of the request being
+// measured. They are only set once the request is validated to keep the number of label
+// values bounded.
+func SetRouteLabels(ctx context.Context, service, command string) {
+	if o, ok := ctx.Value(routeObservationKey{}).(*routeObservation); ok {
+		o.service, o.command = service, command
+	}
+}
+
+// SetDeviceStatus records the status code the device reported for the request being measured.
+func SetDeviceStatus(ctx context.Context, code int) {
+	if o, ok := ctx.Value(routeObservationKey{}).(*routeObservation); ok {
+		o.deviceStatus = code
+	}
+}
+
+// Before starts measuring a request. It is meant to be a go-kit server before function.
+func (m *RouteMetrics) Before(ctx context.Context, r *http.Request) context.Context {
+	o := &routeObservation{start: time.Now(), route: routeTemplate(r)}
+	m.inFlight.With(RouteLabel, o.route).Add(1)
+	return context.WithValue(ctx, routeObservationKey{}, o)
+}
+
+// Finalizer records the measurements of a request started by Before. It is meant to be a
+// go-kit server finalizer.
+func (m *RouteMetrics) Finalizer(ctx context.Context, code int, r *http.Request) {
+	o, ok := ctx.Value(routeObservationKey{}).(*routeObservation)
+	if !ok {
+		return
+	}
+
+	labels := []string{
+		RouteLabel, o.route,
+		MethodLabel, r.Method,
+		CommandLabel, o.command,
+		ServiceLabel, o.service,
+		CodeLabel, strconv.Itoa(code),
+	}
+
+	m.inFlight.With(RouteLabel, o.route).Add(-1)
+	m.requests.With(labels...).Add(1)
+	m.duration.With(labels...).Observe(time.Since(o.start).Seconds())
+
+	if o.deviceStatus != 0 {
+		m.deviceResponses.With(ServiceLabel, o.service, CommandLabel, o.command, CodeLabel, strconv.Itoa(o.deviceStatus)).Add(1)
+	}
+}
+
+// routeTemplate returns the path template of the route matching the request so the
+// route label doesn't grow with every device.
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unknown"
+}
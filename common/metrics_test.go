@@ -0,0 +1,110 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/webpa-common/xmetrics/xmetricstest"
+)
+
+func TestRouteMetrics(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		Service      string
+		Command      string
+		DeviceStatus int
+	}{
+		{
+			Name: "Invalid Request",
+		},
+		{
+			Name:    "No Device Status",
+			Service: "config",
+			Command: "GET",
+		},
+		{
+			Name:         "Device Status",
+			Service:      "config",
+			Command:      "SET",
+			DeviceStatus: 520,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			p := xmetricstest.NewProvider(nil, Metrics)
+			m := NewRouteMetrics(p)
+
+			router := mux.NewRouter()
+			router.HandleFunc("/device/{deviceid}/{service}", func(w http.ResponseWriter, r *http.Request) {
+				ctx := m.Before(r.Context(), r)
+				p.Assert(t, RouteInFlightGauge, RouteLabel, "/device/{deviceid}/{service}")(xmetricstest.Value(1))
+
+				if testCase.Service != "" {
+					SetRouteLabels(ctx, testCase.Service, testCase.Command)
+				}
+
+				if testCase.DeviceStatus != 0 {
+					SetDeviceStatus(ctx, testCase.DeviceStatus)
+				}
+
+				m.Finalizer(ctx, http.StatusOK, r)
+			})
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/device/mac:112233445566/config", nil))
+
+			p.Assert(t, RouteInFlightGauge, RouteLabel, "/device/{deviceid}/{service}")(xmetricstest.Value(0))
+			p.Assert(t, RouteRequestsCounter,
+				RouteLabel, "/device/{deviceid}/{service}",
+				MethodLabel, http.MethodGet,
+				CommandLabel, testCase.Command,
+				ServiceLabel, testCase.Service,
+				CodeLabel, "200",
+			)(xmetricstest.Value(1))
+
+			if testCase.DeviceStatus != 0 {
+				p.Assert(t, DeviceResponsesCounter,
+					ServiceLabel, testCase.Service,
+					CommandLabel, testCase.Command,
+					CodeLabel, "520",
+				)(xmetricstest.Value(1))
+			}
+
+			assert.True(p.AssertExpectations(t))
+		})
+	}
+}
+
+func TestRouteMetricsUnknownRoute(t *testing.T) {
+	var (
+		p = xmetricstest.NewProvider(nil, Metrics)
+		m = NewRouteMetrics(p)
+		r = httptest.NewRequest(http.MethodGet, "/anything", nil)
+	)
+
+	m.Finalizer(m.Before(context.Background(), r), http.StatusNotFound, r)
+	p.Assert(t, RouteRequestsCounter,
+		RouteLabel, "unknown",
+		MethodLabel, http.MethodGet,
+		CommandLabel, "",
+		ServiceLabel, "",
+		CodeLabel, "404",
+	)(xmetricstest.Value(1))
+}
+
+func TestRouteMetricsWithoutBefore(t *testing.T) {
+	assert := assert.New(t)
+	m := NewRouteMetrics(nil)
+	ctx := context.Background()
+
+	assert.NotPanics(func() {
+		SetRouteLabels(ctx, "config", "GET")
+		SetDeviceStatus(ctx, http.StatusOK)
+		m.Finalizer(ctx, http.StatusOK, httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
@@ -0,0 +1,56 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	kithttp "github.com/go-kit/kit/transport/http"
+)
+
+// RuntimeOptions are the options which may change while tr1d1um runs.
+type RuntimeOptions struct {
+	// ValidServices are the device services the translation routes accept.
+	ValidServices []string
+
+	// ReducedLoggingResponseCodes are the response codes whose transactions are
+	// logged without headers.
+	ReducedLoggingResponseCodes []int
+
+	// Retries and RetryInterval configure how requests to the XMiDT cluster are retried.
+	Retries       int
+	RetryInterval time.Duration
+}
+
+// Runtime holds the current RuntimeOptions. Consumers load a snapshot for every request
+// so reloads swap the options atomically without affecting requests in flight.
+type Runtime struct {
+	snapshot atomic.Value
+}
+
+// NewRuntime returns a Runtime holding the given options.
+func NewRuntime(o RuntimeOptions) *Runtime {
+	r := new(Runtime)
+	r.Store(o)
+	return r
+}
+
+// Load returns the current options.
+func (r *Runtime) Load() RuntimeOptions {
+	return r.snapshot.Load().(RuntimeOptions)
+}
+
+// Store replaces the current options.
+func (r *Runtime) Store(o RuntimeOptions) {
+	r.snapshot.Store(o)
+}
+
+// RuntimeTransactionLogging is TransactionLogging with the reduced logging response codes
+// of the current runtime options.
+func RuntimeTransactionLogging(r *Runtime, logger kitlog.Logger) kithttp.ServerFinalizerFunc {
+	return func(ctx context.Context, code int, req *http.Request) {
+		TransactionLogging(r.Load().ReducedLoggingResponseCodes, logger)(ctx, code, req)
+	}
+}
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Names of the spans tr1d1um adds under the span of an API request.
+const (
+	DecodeRequestSpan  = "tr1d1um.decodeRequest"
+	BuildWDMPSpan      = "tr1d1um.buildWDMP"
+	EncodeWRPSpan      = "tr1d1um.encodeWRP"
+	XmidtRequestSpan   = "tr1d1um.xmidtRequest"
+	XmidtAttemptSpan   = "tr1d1um.xmidtAttempt"
+	DecodeResponseSpan = "tr1d1um.decodeResponse"
+)
+
+// Span attributes.
+const (
+	DeviceIDAttribute       = attribute.Key("tr1d1um.device_id")
+	ServiceAttribute        = attribute.Key("tr1d1um.service")
+	PartnerIDsAttribute     = attribute.Key("tr1d1um.partner_ids")
+	CommandAttribute        = attribute.Key("wdmp.command")
+	ParameterCountAttribute = attribute.Key("wdmp.parameter_count")
+	DeviceStatusAttribute   = attribute.Key("wdmp.device_status_code")
+	AttemptAttribute        = attribute.Key("tr1d1um.attempt")
+	ErrorCodeAttribute      = attribute.Key("tr1d1um.error_code")
+)
+
+// StartSpan starts a child of the span in ctx. The child is created by the tracer of its
+// parent so it's a noop when tracing is disabled.
+func StartSpan(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return trace.SpanFromContext(ctx).Tracer().Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// EndSpan ends the span. A non-nil err marks the span as an error with the status and
+// error codes of the problem API consumers get for it. As in the responses, the messages
+// of internal errors are masked while the original error is recorded as a span event.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		problem := NewProblem(err, "")
+		span.SetAttributes(
+			semconv.HTTPStatusCodeKey.Int(problem.Status),
+			ErrorCodeAttribute.String(problem.Code),
+		)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, problem.Detail)
+	}
+	span.End()
+}
+
+// TraceAttempts decorates the function performing the HTTP transactions of a single
+// request such that each attempt, retries included, gets its own span numbered from 1.
+func TraceAttempts(do func(*http.Request) (*http.Response, error)) func(*http.Request) (*http.Response, error) {
+	var attempt int
+	return func(r *http.Request) (*http.Response, error) {
+		attempt++
+		ctx, span := StartSpan(r.Context(), XmidtAttemptSpan, AttemptAttribute.Int(attempt))
+		resp, err := do(r.WithContext(ctx))
+		if resp != nil {
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+		}
+
+		// the error is reported as Transact does so retries still see the original one
+		var spanErr error
+		if err != nil {
+			spanErr = NewUnavailableError(err)
+		}
+		EndSpan(span, spanErr)
+		return resp, err
+	}
+}
@@ -0,0 +1,124 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/oteltest"
+	"go.opentelemetry.io/otel/semconv"
+)
+
+// newTracedContext returns a context with a recorded parent span.
+func newTracedContext() (context.Context, *oteltest.SpanRecorder) {
+	recorder := new(oteltest.SpanRecorder)
+	ctx, _ := oteltest.NewTracerProvider(oteltest.WithSpanRecorder(recorder)).Tracer("test").Start(context.Background(), "parent")
+	return ctx, recorder
+}
+
+func TestStartSpan(t *testing.T) {
+	t.Run("Traced", func(t *testing.T) {
+		assert := assert.New(t)
+		ctx, recorder := newTracedContext()
+
+		_, span := StartSpan(ctx, EncodeWRPSpan, ServiceAttribute.String("config"))
+		EndSpan(span, nil)
+
+		completed := recorder.Completed()
+		require.Len(t, completed, 1)
+		assert.Equal(EncodeWRPSpan, completed[0].Name())
+		assert.Equal(recorder.Started()[0].SpanContext().SpanID(), completed[0].ParentSpanID())
+		assert.Equal("config", completed[0].Attributes()[ServiceAttribute].AsString())
+		assert.Equal(codes.Unset, completed[0].StatusCode())
+	})
+
+	t.Run("NotTraced", func(t *testing.T) {
+		assert := assert.New(t)
+		_, span := StartSpan(context.Background(), EncodeWRPSpan)
+		assert.False(span.IsRecording())
+	})
+}
+
+func TestEndSpan(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		Err             error
+		ExpectedCode    int64
+		ExpectedMessage string
+	}{
+		{
+			Name:            "Coded Error",
+			Err:             NewBadRequestError(errors.New("bad device ID")),
+			ExpectedCode:    http.StatusBadRequest,
+			ExpectedMessage: "bad device ID",
+		},
+		{
+			Name:            "Internal Error",
+			Err:             errors.New("internal details"),
+			ExpectedCode:    http.StatusInternalServerError,
+			ExpectedMessage: ErrTr1d1umInternal.Error(),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			ctx, recorder := newTracedContext()
+
+			_, span := StartSpan(ctx, DecodeRequestSpan)
+			EndSpan(span, testCase.Err)
+
+			completed := recorder.Completed()
+			require.Len(t, completed, 1)
+			assert.Equal(codes.Error, completed[0].StatusCode())
+			assert.Equal(testCase.ExpectedMessage, completed[0].StatusMessage())
+			assert.Equal(testCase.ExpectedCode, completed[0].Attributes()[semconv.HTTPStatusCodeKey].AsInt64())
+			assert.Len(completed[0].Events(), 1)
+		})
+	}
+}
+
+func TestTraceAttempts(t *testing.T) {
+	assert := assert.New(t)
+	ctx, recorder := newTracedContext()
+
+	var (
+		networkErr = errors.New("network test error")
+		results    = []error{networkErr, nil}
+	)
+
+	do := TraceAttempts(func(r *http.Request) (*http.Response, error) {
+		err := results[0]
+		results = results[1:]
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "localhost:6003/test", nil).WithContext(ctx)
+
+	_, err := do(r)
+	assert.Equal(networkErr, err)
+
+	resp, err := do(r)
+	assert.NoError(err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	completed := recorder.Completed()
+	require.Len(t, completed, 2)
+
+	assert.Equal(XmidtAttemptSpan, completed[0].Name())
+	assert.Equal(int64(1), completed[0].Attributes()[AttemptAttribute].AsInt64())
+	assert.Equal(codes.Error, completed[0].StatusCode())
+	assert.Equal(int64(http.StatusServiceUnavailable), completed[0].Attributes()[semconv.HTTPStatusCodeKey].AsInt64())
+
+	assert.Equal(int64(2), completed[1].Attributes()[AttemptAttribute].AsInt64())
+	assert.Equal(codes.Unset, completed[1].StatusCode())
+	assert.Equal(int64(http.StatusOK), completed[1].Attributes()[semconv.HTTPStatusCodeKey].AsInt64())
+}
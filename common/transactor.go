@@ -4,7 +4,11 @@ import (
 	"context"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"go.opentelemetry.io/otel/semconv"
 )
 
 // XmidtResponse represents the data that a tr1d1um transactor keeps from an HTTP request to
@@ -36,26 +40,38 @@ type Tr1d1umTransactorOptions struct {
 
 	//Do is the core responsible to perform the actual HTTP request
 	Do func(*http.Request) (*http.Response, error)
+
+	//Duration observes the latency of the transactions labeled by their response code.
+	//(Optional)
+	Duration metrics.Histogram
 }
 
 func NewTr1d1umTransactor(o *Tr1d1umTransactorOptions) Tr1d1umTransactor {
 	return &tr1d1umTransactor{
 		Do:             o.Do,
 		RequestTimeout: o.RequestTimeout,
+		Duration:       o.Duration,
 	}
 }
 
 type tr1d1umTransactor struct {
 	RequestTimeout time.Duration
 	Do             func(*http.Request) (*http.Response, error)
+	Duration       metrics.Histogram
 }
 
 func (t *tr1d1umTransactor) Transact(req *http.Request) (result *XmidtResponse, err error) {
 	ctx, cancel := context.WithTimeout(req.Context(), t.RequestTimeout)
 	defer cancel()
 
-	var resp *http.Response
-	if resp, err = t.Do(req.WithContext(ctx)); err == nil {
+	ctx, span := StartSpan(ctx, XmidtRequestSpan, semconv.HTTPMethodKey.String(req.Method))
+	defer func() { EndSpan(span, err) }()
+
+	start := time.Now()
+	resp, err := t.Do(req.WithContext(ctx))
+	t.observe(start, resp)
+
+	if err == nil {
 		result = &XmidtResponse{
 			ForwardedHeaders: make(http.Header),
 			Body:             []byte{},
@@ -63,6 +79,7 @@ func (t *tr1d1umTransactor) Transact(req *http.Request) (result *XmidtResponse,
 
 		ForwardHeadersByPrefix("X", resp.Header, result.ForwardedHeaders)
 		result.Code = resp.StatusCode
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
 
 		defer resp.Body.Close()
 
@@ -71,6 +88,18 @@ func (t *tr1d1umTransactor) Transact(req *http.Request) (result *XmidtResponse,
 	}
 
 	//Timeout, network errors, etc.
-	err = NewCodedError(err, http.StatusServiceUnavailable)
+	err = NewUnavailableError(err)
 	return
 }
+
+func (t *tr1d1umTransactor) observe(start time.Time, resp *http.Response) {
+	if t.Duration == nil {
+		return
+	}
+
+	code := errorCode
+	if resp != nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	t.Duration.With(CodeLabel, code).Observe(time.Since(start).Seconds())
+}
@@ -9,13 +9,14 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/webpa-common/xmetrics/xmetricstest"
 )
 
 func TestTransactError(t *testing.T) {
 	assert := assert.New(t)
 
 	plainErr := errors.New("network test error")
-	expectedErr := NewCodedError(plainErr, 503)
+	expectedErr := NewUnavailableError(plainErr)
 
 	transactor := NewTr1d1umTransactor(&Tr1d1umTransactorOptions{
 		Do: func(_ *http.Request) (*http.Response, error) {
@@ -58,3 +59,40 @@ func TestTransactIdeal(t *testing.T) {
 	assert.Nil(e)
 	assert.EqualValues(expected, actual)
 }
+
+func TestTransactDuration(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		Response     *http.Response
+		Err          error
+		ExpectedCode string
+	}{
+		{
+			Name:         "Response",
+			Response:     &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(new(bytes.Buffer))},
+			ExpectedCode: "200",
+		},
+		{
+			Name:         "Network Error",
+			Err:          errors.New("network test error"),
+			ExpectedCode: "error",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			p := xmetricstest.NewProvider(nil, Metrics)
+
+			transactor := NewTr1d1umTransactor(&Tr1d1umTransactorOptions{
+				Do: func(_ *http.Request) (*http.Response, error) {
+					return testCase.Response, testCase.Err
+				},
+				Duration: p.NewHistogram(XmidtRequestDurationHistogram, 0).With(TargetLabel, DeviceTarget),
+			})
+
+			transactor.Transact(httptest.NewRequest(http.MethodGet, "localhost:6003/test", nil))
+			assert.True(p.Assert(t, XmidtRequestDurationHistogram, TargetLabel, DeviceTarget, CodeLabel, testCase.ExpectedCode)(xmetricstest.Histogram))
+		})
+	}
+}
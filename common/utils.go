@@ -126,10 +126,11 @@ func Capture(logger kitlog.Logger) kithttp.RequestFunc {
 		var tid string
 
 		if tid = r.Header.Get(HeaderWPATID); tid == "" {
-			tid = genTID()
+			tid = NewTID()
 		}
 
 		nctx = context.WithValue(ctx, ContextKeyRequestTID, tid)
+		nctx = context.WithValue(nctx, ContextKeyRequestAccept, r.Header.Get("Accept"))
 
 		var satClientID = "N/A"
 
@@ -155,9 +156,9 @@ func Capture(logger kitlog.Logger) kithttp.RequestFunc {
 	}
 }
 
-// genTID generates a 16-byte long string
+// NewTID generates a 16-byte long string to be used as a transaction ID
 // it returns "N/A" in the extreme case the random string could not be generated
-func genTID() (tid string) {
+func NewTID() (tid string) {
 	buf := make([]byte, 16)
 	tid = "N/A"
 	if _, err := rand.Read(buf); err == nil {
@@ -99,10 +99,18 @@ func TestCapture(t *testing.T) {
 		ctx := Capture(logging.NewTestLogger(nil, t))(context.TODO(), r)
 		assert.NotEmpty(ctx.Value(ContextKeyRequestTID).(string))
 	})
+
+	t.Run("Accept", func(t *testing.T) {
+		assert := assert.New(t)
+		r := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+		r.Header.Set("Accept", ProblemContentType)
+		ctx := Capture(logging.NewTestLogger(nil, t))(context.TODO(), r)
+		assert.EqualValues(ProblemContentType, ctx.Value(ContextKeyRequestAccept))
+	})
 }
 
-func TestGenTID(t *testing.T) {
+func TestNewTID(t *testing.T) {
 	assert := assert.New(t)
-	tid := genTID()
+	tid := NewTID()
 	assert.NotEmpty(tid)
 }
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/webpa-common/device"
+	"github.com/xmidt-org/webpa-common/logging"
+)
+
+var (
+	errDeviceClaimMissing = errors.New("token has no allowed devices claim")
+	errDeviceNotAllowed   = errors.New("device is not allowed by token")
+)
+
+// defaultDeviceClaimKeys is the nested path of the allowed devices claim in JWT attributes.
+var defaultDeviceClaimKeys = []string{"allowedResources", "allowedDevices"}
+
+// DeviceAccessConfig provides the details needed to restrict bearer tokens to the devices
+// listed in one of their claims. The type can be "monitor" or "enforce" just like the one of
+// capabilityCheck.  If it is empty or a different value, no checking is done.
+type DeviceAccessConfig struct {
+	Type string
+
+	// ClaimKeys is the nested path of the claim listing the device IDs or ID
+	// regular expressions a token may access.
+	// (Optional) Defaults to allowedResources.allowedDevices.
+	ClaimKeys []string
+
+	// RequireClaim rejects tokens without the claim. Otherwise, such tokens may access any device.
+	RequireClaim bool
+}
+
+// deviceAccessValidator checks that the device of '/device/{deviceid}/...' requests is
+// one of the devices allowed by the token. Handlers getting device IDs from elsewhere, such
+// as the body of schedules, check them with requestDeviceAccess.
+type deviceAccessValidator struct {
+	claimKeys    []string
+	requireClaim bool
+	enforce      bool
+}
+
+func newDeviceAccessValidator(c DeviceAccessConfig) (deviceAccessValidator, bool) {
+	if c.Type != "enforce" && c.Type != "monitor" {
+		return deviceAccessValidator{}, false
+	}
+
+	if len(c.ClaimKeys) == 0 {
+		c.ClaimKeys = defaultDeviceClaimKeys
+	}
+
+	return deviceAccessValidator{
+		claimKeys:    c.ClaimKeys,
+		requireClaim: c.RequireClaim,
+		enforce:      c.Type == "enforce",
+	}, true
+}
+
+func (d deviceAccessValidator) Check(ctx context.Context, token bascule.Token) error {
+	auth, ok := bascule.FromContext(ctx)
+	if !ok || auth.Request.URL == nil {
+		return nil
+	}
+
+	deviceID, ok := requestDeviceID(auth.Request.URL.Path)
+	if !ok {
+		return nil
+	}
+	return d.authorize(ctx, token, deviceID)
+}
+
+// authorize checks token may access deviceID. Failures are only logged unless the check
+// is enforced.
+func (d deviceAccessValidator) authorize(ctx context.Context, token bascule.Token, deviceID device.ID) error {
+	err := d.check(ctx, token, deviceID)
+	if err == nil {
+		return nil
+	}
+
+	if !d.enforce {
+		logging.Warn(logging.GetLogger(ctx)).Log(logging.MessageKey(), "device access check failed",
+			"principal", token.Principal(), "deviceID", deviceID, logging.ErrorKey(), err)
+		return nil
+	}
+	return err
+}
+
+func (d deviceAccessValidator) check(ctx context.Context, token bascule.Token, deviceID device.ID) error {
+	claim, ok := bascule.GetNestedAttribute(token.Attributes(), d.claimKeys...)
+	if !ok {
+		if d.requireClaim {
+			return errDeviceClaimMissing
+		}
+		return nil
+	}
+
+	allowed, err := cast.ToStringSliceE(claim)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errDeviceClaimMissing, err)
+	}
+
+	// the entries of the claim are compiled once and cached across requests
+	devices := common.NewAllowedDevices(allowed)
+	if len(devices.Invalid) > 0 {
+		logging.Warn(logging.GetLogger(ctx)).Log(logging.MessageKey(), "ignoring invalid allowed devices claim entries",
+			"principal", token.Principal(), "invalid", devices.Invalid)
+	}
+
+	if devices.Allows(deviceID) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", errDeviceNotAllowed, deviceID)
+}
+
+// allowedDevices returns the allowed devices claim of token when it's enforced.
+func (d deviceAccessValidator) allowedDevices(token bascule.Token) ([]string, bool) {
+	if !d.enforce {
+		return nil, false
+	}
+
+	claim, ok := bascule.GetNestedAttribute(token.Attributes(), d.claimKeys...)
+	if !ok {
+		return nil, false
+	}
+
+	allowed, err := cast.ToStringSliceE(claim)
+	return allowed, err == nil
+}
+
+type deviceAccessContextKey struct{}
+
+// decorate is an Alice-style constructor making d available to requestDeviceAccess. It
+// belongs to the auth chain so that reloads swap it along with the rules of the enforcer.
+func (d deviceAccessValidator) decorate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), deviceAccessContextKey{}, d)))
+	})
+}
+
+// requestDeviceAccess checks the devices of requests against the deviceAccessValidator of
+// their auth chain, if any. Like the enforcer, it only applies to bearer tokens.
+type requestDeviceAccess struct{}
+
+func (requestDeviceAccess) validator(ctx context.Context) (deviceAccessValidator, bascule.Token, bool) {
+	d, ok := ctx.Value(deviceAccessContextKey{}).(deviceAccessValidator)
+	if !ok {
+		return deviceAccessValidator{}, nil, false
+	}
+
+	auth, ok := bascule.FromContext(ctx)
+	if !ok || auth.Authorization != "Bearer" {
+		return deviceAccessValidator{}, nil, false
+	}
+	return d, auth.Token, true
+}
+
+// Authorize returns an error if the token of ctx may not access deviceID.
+func (a requestDeviceAccess) Authorize(ctx context.Context, deviceID device.ID) error {
+	d, token, ok := a.validator(ctx)
+	if !ok {
+		return nil
+	}
+	return d.authorize(ctx, token, deviceID)
+}
+
+// AllowedDevices returns the allowed devices claim of the token of ctx when it's enforced.
+func (a requestDeviceAccess) AllowedDevices(ctx context.Context) ([]string, bool) {
+	d, token, ok := a.validator(ctx)
+	if !ok {
+		return nil, false
+	}
+	return d.allowedDevices(token)
+}
+
+// requestDeviceID returns the device ID of '/device/{deviceid}/...' request paths.
+// The API prefix is expected to be removed already.
+func requestDeviceID(path string) (device.ID, bool) {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "device" {
+		return "", false
+	}
+
+	id, err := device.ParseID(parts[1])
+	if err != nil {
+		// let the handlers respond with their own bad request error
+		return "", false
+	}
+	return id, true
+}
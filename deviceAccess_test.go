@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/webpa-common/logging"
+)
+
+func TestNewDeviceAccessValidator(t *testing.T) {
+	assert := assert.New(t)
+
+	_, ok := newDeviceAccessValidator(DeviceAccessConfig{})
+	assert.False(ok)
+
+	v, ok := newDeviceAccessValidator(DeviceAccessConfig{Type: "monitor"})
+	assert.True(ok)
+	assert.Equal(defaultDeviceClaimKeys, v.claimKeys)
+	assert.False(v.enforce)
+}
+
+func TestDeviceAccessValidator(t *testing.T) {
+	claims := func(devices ...interface{}) bascule.Attributes {
+		return bascule.NewAttributes(map[string]interface{}{
+			"allowedResources": map[string]interface{}{
+				"allowedDevices": devices,
+			},
+		})
+	}
+
+	testCases := []struct {
+		Description  string
+		Type         string
+		RequireClaim bool
+		Path         string
+		Attributes   bascule.Attributes
+		ExpectedErr  error
+	}{
+		{
+			Description: "Allowed device",
+			Type:        "enforce",
+			Path:        "device/mac:112233445566/stat",
+			Attributes:  claims("mac:112233445566"),
+		},
+		{
+			Description: "Allowed device in another format",
+			Type:        "enforce",
+			Path:        "device/mac:11-22-33-44-55-66/config",
+			Attributes:  claims("mac:112233445566"),
+		},
+		{
+			Description: "Allowed pattern",
+			Type:        "enforce",
+			Path:        "device/mac:112233445566/stat",
+			Attributes:  claims("serial:.*", "mac:1122334455.."),
+		},
+		{
+			Description: "Device not allowed",
+			Type:        "enforce",
+			Path:        "device/mac:112233445566/stat",
+			Attributes:  claims("mac:aabbccddeeff"),
+			ExpectedErr: errDeviceNotAllowed,
+		},
+		{
+			Description: "Device not allowed in monitor mode",
+			Type:        "monitor",
+			Path:        "device/mac:112233445566/stat",
+			Attributes:  claims("mac:aabbccddeeff"),
+		},
+		{
+			Description: "Not a device route",
+			Type:        "enforce",
+			Path:        "hooks",
+			Attributes:  claims("mac:aabbccddeeff"),
+		},
+		{
+			Description: "No claim",
+			Type:        "enforce",
+			Path:        "device/mac:112233445566/stat",
+			Attributes:  bascule.NewAttributes(nil),
+		},
+		{
+			Description:  "Required claim",
+			Type:         "enforce",
+			RequireClaim: true,
+			Path:         "device/mac:112233445566/stat",
+			Attributes:   bascule.NewAttributes(nil),
+			ExpectedErr:  errDeviceClaimMissing,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			assert := assert.New(t)
+			v, ok := newDeviceAccessValidator(DeviceAccessConfig{Type: tc.Type, RequireClaim: tc.RequireClaim})
+			assert.True(ok)
+
+			token := bascule.NewToken("jwt", "client0", tc.Attributes)
+			ctx := bascule.WithAuthentication(context.Background(), bascule.Authentication{
+				Token:   token,
+				Request: bascule.Request{URL: &url.URL{Path: tc.Path}},
+			})
+
+			err := v.Check(ctx, token)
+			if tc.ExpectedErr == nil {
+				assert.Nil(err)
+			} else {
+				assert.True(errors.Is(err, tc.ExpectedErr))
+			}
+		})
+	}
+}
+
+func TestRequestDeviceAccess(t *testing.T) {
+	restricted := bascule.NewAttributes(map[string]interface{}{
+		"allowedResources": map[string]interface{}{
+			"allowedDevices": []interface{}{"mac:112233445566"},
+		},
+	})
+
+	testCases := []struct {
+		Description     string
+		Type            string
+		Authorization   bascule.Authorization
+		ExpectedErr     error
+		ExpectedAllowed []string
+	}{
+		{
+			Description:     "Foreign device",
+			Type:            "enforce",
+			Authorization:   "Bearer",
+			ExpectedErr:     errDeviceNotAllowed,
+			ExpectedAllowed: []string{"mac:112233445566"},
+		},
+		{
+			Description:   "Monitored",
+			Type:          "monitor",
+			Authorization: "Bearer",
+		},
+		{
+			Description:   "Basic auth",
+			Type:          "enforce",
+			Authorization: "Basic",
+		},
+		{
+			Description:   "Not configured",
+			Authorization: "Bearer",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Description, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var ctx context.Context
+			handler := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				ctx = r.Context()
+			})
+			if v, ok := newDeviceAccessValidator(DeviceAccessConfig{Type: tc.Type}); ok {
+				handler = v.decorate(handler).ServeHTTP
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/api/v2/schedules", nil)
+			handler.ServeHTTP(httptest.NewRecorder(), r.WithContext(bascule.WithAuthentication(r.Context(), bascule.Authentication{
+				Authorization: tc.Authorization,
+				Token:         bascule.NewToken("jwt", "client0", restricted),
+			})))
+
+			err := requestDeviceAccess{}.Authorize(ctx, "mac:aabbccddeeff")
+			if tc.ExpectedErr == nil {
+				assert.Nil(err)
+			} else {
+				assert.True(errors.Is(err, tc.ExpectedErr))
+			}
+			assert.Nil(requestDeviceAccess{}.Authorize(ctx, "mac:112233445566"))
+
+			allowed, ok := requestDeviceAccess{}.AllowedDevices(ctx)
+			assert.Equal(tc.ExpectedAllowed != nil, ok)
+			assert.Equal(tc.ExpectedAllowed, allowed)
+		})
+	}
+}
+
+func TestDeviceAccessValidatorLogsInvalidEntries(t *testing.T) {
+	assert := assert.New(t)
+	v, ok := newDeviceAccessValidator(DeviceAccessConfig{Type: "monitor"})
+	assert.True(ok)
+
+	var logged []interface{}
+	ctx := logging.WithLogger(context.Background(), log.LoggerFunc(func(keyvals ...interface{}) error {
+		logged = append(logged, keyvals...)
+		return nil
+	}))
+
+	token := bascule.NewToken("jwt", "client0", bascule.NewAttributes(map[string]interface{}{
+		"allowedResources": map[string]interface{}{
+			"allowedDevices": []interface{}{"(bad", "mac:112233445566"},
+		},
+	}))
+
+	assert.Nil(v.authorize(ctx, token, "mac:112233445566"))
+	assert.Contains(logged, []string{"(bad"})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics/provider"
+	"github.com/spf13/viper"
+	"github.com/xmidt-org/bascule/acquire"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/healthcheck"
+	"github.com/xmidt-org/tr1d1um/webhook"
+)
+
+// newHealthMonitor returns a monitor of the dependencies tr1d1um is configured with. The
+// acquirer is nil when outgoing requests are not authenticated. Only the shutdown drain is
+// critical by default: upstream dependencies being down degrade tr1d1um, as taking it out of
+// rotation doesn't bring them back, unless healthChecks.critical says otherwise.
+func newHealthMonitor(v *viper.Viper, logger log.Logger, p provider.Provider, drainer *common.Drainer, acquirer acquire.Acquirer) (*healthcheck.Monitor, error) {
+	var config healthcheck.Config
+	if err := v.UnmarshalKey(healthChecksConfigKey, &config); err != nil {
+		return nil, err
+	}
+
+	// probes are not traced as they'd drown the spans of actual requests
+	client := new(http.Client)
+	m := healthcheck.New(config, p, logger)
+
+	m.Add(healthcheck.Component{
+		Name:     healthcheck.ShutdownComponent,
+		Check:    healthcheck.ShutdownCheck(drainer.Ready),
+		Critical: true,
+		Passive:  true,
+	})
+
+	m.Add(healthcheck.Component{
+		Name:  healthcheck.XmidtComponent,
+		Check: healthcheck.HTTPCheck(client, v.GetString(targetURLKey)),
+	})
+
+	if v.IsSet(webhookConfigKey) {
+		var storeConfig webhook.StoreConfig
+		if err := v.UnmarshalKey(webhookStoreConfigKey, &storeConfig); err != nil {
+			return nil, err
+		}
+
+		if storeConfig.Type == "" || storeConfig.Type == webhook.ArgusStoreType {
+			m.Add(healthcheck.Component{
+				Name:  healthcheck.ArgusComponent,
+				Check: healthcheck.HTTPCheck(client, v.GetString(webhookArgusAddressKey)),
+			})
+		}
+	}
+
+	var jwtVal JWTValidator
+	if err := v.UnmarshalKey("jwtValidator", &jwtVal); err != nil {
+		return nil, err
+	}
+	if jwtVal.Keys.URI != "" {
+		m.Add(healthcheck.Component{
+			Name:  healthcheck.KeyServerComponent,
+			Check: healthcheck.KeyServerCheck(jwtVal.Keys, DefaultKeyID),
+		})
+	}
+
+	if acquirer != nil {
+		m.Add(healthcheck.Component{
+			Name:  healthcheck.AcquirerComponent,
+			Check: healthcheck.AcquirerCheck(acquirer),
+		})
+	}
+
+	return m, nil
+}
@@ -0,0 +1,95 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/xmidt-org/bascule/acquire"
+	"github.com/xmidt-org/bascule/key"
+)
+
+// Names of the components tr1d1um checks. They are lowercase as config map keys are.
+const (
+	XmidtComponent     = "xmidt"
+	ArgusComponent     = "argus"
+	KeyServerComponent = "keyserver"
+	AcquirerComponent  = "acquirer"
+	ShutdownComponent  = "shutdown"
+)
+
+var (
+	errEmptyToken = errors.New("acquired an empty token")
+	errDraining   = errors.New("draining requests before shutting down")
+)
+
+// HTTPCheck checks a server is reachable with a GET of url. Any response but a 5xx one
+// counts as the server being up as the probed URL is not meant to be an API route.
+func HTTPCheck(client *http.Client, url string) Check {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return func(ctx context.Context) error {
+		r, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(r.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// KeyServerCheck checks the key server serves the key with keyID. Each check uses a new
+// resolver, so it checks the server itself rather than the keys cached by the JWT validator,
+// which may keep validating tokens for a while after the server goes down.
+func KeyServerCheck(factory key.ResolverFactory, keyID string) Check {
+	return func(ctx context.Context) error {
+		resolver, err := factory.NewResolver()
+		if err != nil {
+			return err
+		}
+
+		_, err = resolver.ResolveKey(ctx, keyID)
+		return err
+	}
+}
+
+// AcquirerCheck checks the acquirer provides a token. Acquirers caching their token
+// only request a new one when it is about to expire.
+func AcquirerCheck(a acquire.Acquirer) Check {
+	return func(context.Context) error {
+		token, err := a.Acquire()
+		if err != nil {
+			return err
+		}
+		if token == "" {
+			return errEmptyToken
+		}
+		return nil
+	}
+}
+
+// ShutdownCheck fails once ready returns false, which the drainer of the requests in flight
+// does when shutdown starts. It's cheap enough for a passive component.
+func ShutdownCheck(ready func() bool) Check {
+	return func(context.Context) error {
+		if !ready() {
+			return errDraining
+		}
+		return nil
+	}
+}
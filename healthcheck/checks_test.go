@@ -0,0 +1,64 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPCheck(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Code        int
+		ExpectedErr bool
+	}{
+		{Name: "OK", Code: http.StatusOK},
+		{Name: "Not Found", Code: http.StatusNotFound},
+		{Name: "Unavailable", Code: http.StatusServiceUnavailable, ExpectedErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tc.Code)
+			}))
+			defer server.Close()
+
+			err := HTTPCheck(nil, server.URL)(context.Background())
+			if tc.ExpectedErr {
+				assert.NotNil(err)
+				return
+			}
+			assert.Nil(err)
+		})
+	}
+
+	t.Run("Unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+		assert.NotNil(t, HTTPCheck(nil, server.URL)(context.Background()))
+	})
+}
+
+type testAcquirer struct {
+	token string
+	err   error
+}
+
+func (a testAcquirer) Acquire() (string, error) {
+	return a.token, a.err
+}
+
+func TestAcquirerCheck(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(AcquirerCheck(testAcquirer{token: "token"})(context.Background()))
+	assert.Equal(errEmptyToken, AcquirerCheck(testAcquirer{})(context.Background()))
+
+	acquireErr := errors.New("acquire test error")
+	assert.Equal(acquireErr, AcquirerCheck(testAcquirer{err: acquireErr})(context.Background()))
+}
@@ -0,0 +1,325 @@
+// Package healthcheck periodically checks the dependencies of tr1d1um and reports their
+// state in a JSON health document served with liveness and readiness semantics.
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/provider"
+	"github.com/xmidt-org/webpa-common/logging"
+	"github.com/xmidt-org/webpa-common/xmetrics"
+)
+
+// Routes serving the health document.
+const (
+	LivePath  = "/live"
+	ReadyPath = "/ready"
+)
+
+// Metric names of the dependency checks.
+const (
+	DependencyUpGauge                = "dependency_up"
+	DependencyCheckDurationHistogram = "dependency_check_duration_seconds"
+	DependencyCheckFailuresCounter   = "dependency_check_failures"
+)
+
+// ComponentLabel is the metric label holding the component name.
+const ComponentLabel = "component"
+
+// Statuses of the components and of the health document.
+const (
+	StatusUp      = "up"
+	StatusDown    = "down"
+	StatusUnknown = "unknown"
+
+	// StatusDegraded means only non-critical components are not up.
+	StatusDegraded = "degraded"
+)
+
+// Defaults of Config.
+const (
+	DefaultInterval = 30 * time.Second
+	DefaultTimeout  = 5 * time.Second
+)
+
+var errCheckTimeout = errors.New("check timed out")
+
+// Metrics returns the metrics relevant to this package.
+func Metrics() []xmetrics.Metric {
+	return []xmetrics.Metric{
+		{
+			Name:       DependencyUpGauge,
+			Type:       xmetrics.GaugeType,
+			Help:       "Whether the last check of a dependency succeeded (1) or not (0).",
+			LabelNames: []string{ComponentLabel},
+		},
+		{
+			Name:       DependencyCheckDurationHistogram,
+			Type:       xmetrics.HistogramType,
+			Help:       "Latency of the dependency checks.",
+			LabelNames: []string{ComponentLabel},
+			Buckets:    []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		},
+		{
+			Name:       DependencyCheckFailuresCounter,
+			Type:       xmetrics.CounterType,
+			Help:       "Count of failed dependency checks.",
+			LabelNames: []string{ComponentLabel},
+		},
+	}
+}
+
+// Config configures the dependency checks.
+type Config struct {
+	// Interval is the time between two checks of a dependency.
+	// (Optional) Defaults to 30s.
+	Interval time.Duration
+
+	// Timeout bounds each check. Checks taking longer fail.
+	// (Optional) Defaults to 5s.
+	Timeout time.Duration
+
+	// Critical overrides, by component name, whether a checked dependency being down fails
+	// readiness.
+	// (Optional)
+	Critical map[string]bool
+}
+
+// Check returns an error when the dependency it checks is not usable.
+type Check func(context.Context) error
+
+// Component is a dependency of tr1d1um.
+type Component struct {
+	Name  string
+	Check Check
+
+	// Critical components fail readiness unless they are up.
+	Critical bool
+
+	// Passive components are cheap in-process checks evaluated whenever the health
+	// document is requested rather than periodically. Config.Critical does not apply to them.
+	Passive bool
+}
+
+// ComponentStatus is the state of a component in the health document.
+type ComponentStatus struct {
+	Status      string     `json:"status"`
+	Critical    bool       `json:"critical"`
+	LastChecked *time.Time `json:"lastChecked,omitempty"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	Latency     string     `json:"latency,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// Document is the health document. Status is down when a critical component is not up and
+// degraded when only non-critical ones are not.
+type Document struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+type component struct {
+	Component
+	status ComponentStatus
+}
+
+// Monitor runs the checks of the components and keeps their latest results.
+type Monitor struct {
+	interval time.Duration
+	timeout  time.Duration
+	critical map[string]bool
+	logger   log.Logger
+
+	lock       sync.RWMutex
+	components []*component
+
+	up       metrics.Gauge
+	duration metrics.Histogram
+	failures metrics.Counter
+}
+
+// New returns a Monitor using the metrics of the provider. A nil provider discards them.
+func New(c Config, p provider.Provider, logger log.Logger) *Monitor {
+	if c.Interval <= 0 {
+		c.Interval = DefaultInterval
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if p == nil {
+		p = provider.NewDiscardProvider()
+	}
+	if logger == nil {
+		logger = logging.DefaultLogger()
+	}
+
+	return &Monitor{
+		interval: c.Interval,
+		timeout:  c.Timeout,
+		critical: c.Critical,
+		logger:   logger,
+		up:       p.NewGauge(DependencyUpGauge),
+		duration: p.NewHistogram(DependencyCheckDurationHistogram, 10),
+		failures: p.NewCounter(DependencyCheckFailuresCounter),
+	}
+}
+
+// Add registers a component. Components must be added before Start is called.
+func (m *Monitor) Add(c Component) {
+	if critical, ok := m.critical[c.Name]; ok && !c.Passive {
+		c.Critical = critical
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.components = append(m.components, &component{
+		Component: c,
+		status:    ComponentStatus{Status: StatusUnknown, Critical: c.Critical},
+	})
+}
+
+// Start checks the components right away and then periodically until the returned
+// function is called.
+func (m *Monitor) Start() func() {
+	var (
+		done = make(chan struct{})
+		wg   sync.WaitGroup
+	)
+
+	m.lock.RLock()
+	for _, c := range m.components {
+		if c.Passive {
+			continue
+		}
+
+		wg.Add(1)
+		go func(c *component) {
+			defer wg.Done()
+			ticker := time.NewTicker(m.interval)
+			defer ticker.Stop()
+
+			for {
+				m.check(c)
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+				}
+			}
+		}(c)
+	}
+	m.lock.RUnlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+// check runs the check of c, bounded by the timeout even when the check ignores its context.
+func (m *Monitor) check(c *component) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	start := time.Now()
+	result := make(chan error, 1)
+	go func() {
+		result <- c.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		err = errCheckTimeout
+	}
+
+	latency := time.Since(start)
+	m.duration.With(ComponentLabel, c.Name).Observe(latency.Seconds())
+	if err != nil {
+		m.failures.With(ComponentLabel, c.Name).Add(1)
+		logging.Error(m.logger).Log(logging.MessageKey(), "dependency check failed", ComponentLabel, c.Name, logging.ErrorKey(), err)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.record(c, start, err)
+	c.status.Latency = latency.String()
+}
+
+// record sets the status of c to the outcome of a check. The lock must be held.
+func (m *Monitor) record(c *component, checked time.Time, err error) {
+	c.status.LastChecked = &checked
+	if err != nil {
+		c.status.Status = StatusDown
+		c.status.Error = err.Error()
+		m.up.With(ComponentLabel, c.Name).Set(0)
+		return
+	}
+
+	c.status.Status = StatusUp
+	c.status.Error = ""
+	c.status.LastSuccess = &checked
+	m.up.With(ComponentLabel, c.Name).Set(1)
+}
+
+// Document evaluates the passive components and returns the health document.
+func (m *Monitor) Document() Document {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	d := Document{
+		Status:     StatusUp,
+		Components: make(map[string]ComponentStatus, len(m.components)),
+	}
+
+	for _, c := range m.components {
+		if c.Passive {
+			m.record(c, time.Now(), c.Check(context.Background()))
+		}
+
+		d.Components[c.Name] = c.status
+		switch {
+		case c.status.Status == StatusUp:
+		case c.Critical:
+			d.Status = StatusDown
+		case d.Status == StatusUp:
+			d.Status = StatusDegraded
+		}
+	}
+	return d
+}
+
+// Live serves the health document with a 200 as long as tr1d1um is able to respond. Failing
+// dependencies do not fail liveness as restarting tr1d1um does not fix them.
+func (m *Monitor) Live(w http.ResponseWriter, _ *http.Request) {
+	writeDocument(w, m.Document(), http.StatusOK)
+}
+
+// Ready serves the health document with a 200 when tr1d1um can serve requests and a 503 when
+// a critical component is not up.
+func (m *Monitor) Ready(w http.ResponseWriter, _ *http.Request) {
+	d := m.Document()
+	code := http.StatusOK
+	if d.Status == StatusDown {
+		code = http.StatusServiceUnavailable
+	}
+	writeDocument(w, d, code)
+}
+
+func writeDocument(w http.ResponseWriter, d Document, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(d)
+}
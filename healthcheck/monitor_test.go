@@ -0,0 +1,162 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/webpa-common/logging"
+	"github.com/xmidt-org/webpa-common/xmetrics/xmetricstest"
+)
+
+func succeed(context.Context) error { return nil }
+
+func fail(context.Context) error { return errors.New("test dependency error") }
+
+func TestMonitorDocument(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Components     []Component
+		Critical       map[string]bool
+		ExpectedStatus string
+		ExpectedCode   int
+	}{
+		{
+			Name: "Up",
+			Components: []Component{
+				{Name: XmidtComponent, Check: succeed, Critical: true},
+				{Name: ArgusComponent, Check: succeed},
+			},
+			ExpectedStatus: StatusUp,
+			ExpectedCode:   http.StatusOK,
+		},
+		{
+			Name: "Degraded",
+			Components: []Component{
+				{Name: XmidtComponent, Check: succeed, Critical: true},
+				{Name: ArgusComponent, Check: fail},
+			},
+			ExpectedStatus: StatusDegraded,
+			ExpectedCode:   http.StatusOK,
+		},
+		{
+			Name: "Critical Down",
+			Components: []Component{
+				{Name: XmidtComponent, Check: fail, Critical: true},
+				{Name: ArgusComponent, Check: succeed},
+			},
+			ExpectedStatus: StatusDown,
+			ExpectedCode:   http.StatusServiceUnavailable,
+		},
+		{
+			Name: "Critical Override",
+			Components: []Component{
+				{Name: XmidtComponent, Check: succeed, Critical: true},
+				{Name: ArgusComponent, Check: fail},
+			},
+			Critical:       map[string]bool{ArgusComponent: true},
+			ExpectedStatus: StatusDown,
+			ExpectedCode:   http.StatusServiceUnavailable,
+		},
+		{
+			Name: "Passive Down",
+			Components: []Component{
+				{Name: XmidtComponent, Check: succeed, Critical: true},
+				{Name: ShutdownComponent, Check: ShutdownCheck(func() bool { return false }), Critical: true, Passive: true},
+			},
+			Critical:       map[string]bool{ShutdownComponent: false},
+			ExpectedStatus: StatusDown,
+			ExpectedCode:   http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			m := New(Config{Interval: time.Hour, Critical: tc.Critical}, nil, logging.NewTestLogger(nil, t))
+			for _, c := range tc.Components {
+				m.Add(c)
+			}
+			m.Start()()
+
+			w := httptest.NewRecorder()
+			m.Ready(w, httptest.NewRequest(http.MethodGet, ReadyPath, nil))
+			assert.Equal(tc.ExpectedCode, w.Code)
+			assert.Equal("application/json", w.Header().Get("Content-Type"))
+
+			var d Document
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &d))
+			assert.Equal(tc.ExpectedStatus, d.Status)
+			assert.Len(d.Components, len(tc.Components))
+
+			w = httptest.NewRecorder()
+			m.Live(w, httptest.NewRequest(http.MethodGet, LivePath, nil))
+			assert.Equal(http.StatusOK, w.Code)
+		})
+	}
+}
+
+func TestMonitorCheck(t *testing.T) {
+	t.Run("Unknown", func(t *testing.T) {
+		assert := assert.New(t)
+		m := New(Config{}, nil, nil)
+		m.Add(Component{Name: XmidtComponent, Check: succeed, Critical: true})
+
+		d := m.Document()
+		assert.Equal(StatusDown, d.Status)
+		assert.Equal(StatusUnknown, d.Components[XmidtComponent].Status)
+		assert.Nil(d.Components[XmidtComponent].LastChecked)
+	})
+
+	t.Run("Recovered", func(t *testing.T) {
+		assert := assert.New(t)
+		p := xmetricstest.NewProvider(nil, Metrics)
+		m := New(Config{}, p, logging.NewTestLogger(nil, t))
+		results := []error{errors.New("test dependency error"), nil}
+		m.Add(Component{Name: XmidtComponent, Check: func(context.Context) error {
+			err := results[0]
+			results = results[1:]
+			return err
+		}})
+
+		c := m.components[0]
+		m.check(c)
+		status := m.Document().Components[XmidtComponent]
+		assert.Equal(StatusDown, status.Status)
+		assert.Equal("test dependency error", status.Error)
+		assert.NotNil(status.LastChecked)
+		assert.Nil(status.LastSuccess)
+		p.Assert(t, DependencyUpGauge, ComponentLabel, XmidtComponent)(xmetricstest.Value(0))
+		p.Assert(t, DependencyCheckFailuresCounter, ComponentLabel, XmidtComponent)(xmetricstest.Value(1))
+
+		m.check(c)
+		status = m.Document().Components[XmidtComponent]
+		assert.Equal(StatusUp, status.Status)
+		assert.Empty(status.Error)
+		assert.Equal(status.LastChecked, status.LastSuccess)
+		p.Assert(t, DependencyUpGauge, ComponentLabel, XmidtComponent)(xmetricstest.Value(1))
+		p.Assert(t, DependencyCheckFailuresCounter, ComponentLabel, XmidtComponent)(xmetricstest.Value(1))
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		assert := assert.New(t)
+		m := New(Config{Timeout: 10 * time.Millisecond}, nil, logging.NewTestLogger(nil, t))
+		release := make(chan struct{})
+		defer close(release)
+		m.Add(Component{Name: KeyServerComponent, Check: func(context.Context) error {
+			<-release // ignores its context
+			return nil
+		}})
+
+		m.check(m.components[0])
+		status := m.Document().Components[KeyServerComponent]
+		assert.Equal(StatusDown, status.Status)
+		assert.Equal(errCheckTimeout.Error(), status.Error)
+	})
+}
@@ -30,11 +30,18 @@ import (
 	"os/signal"
 	"regexp"
 	"runtime"
+	"syscall"
 	"time"
 
+	"github.com/xmidt-org/tr1d1um/audit"
 	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/healthcheck"
+	"github.com/xmidt-org/tr1d1um/openapi"
+	"github.com/xmidt-org/tr1d1um/rpc"
+	"github.com/xmidt-org/tr1d1um/schedule"
 	"github.com/xmidt-org/tr1d1um/stat"
 	"github.com/xmidt-org/tr1d1um/translation"
+	"github.com/xmidt-org/tr1d1um/webhook"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
@@ -43,6 +50,7 @@ import (
 	"github.com/goph/emperror"
 	"github.com/gorilla/mux"
 	"github.com/justinas/alice"
+	"github.com/spf13/cast"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"github.com/xmidt-org/ancla"
@@ -57,8 +65,8 @@ import (
 	"github.com/xmidt-org/webpa-common/concurrent"
 	"github.com/xmidt-org/webpa-common/logging"
 	"github.com/xmidt-org/webpa-common/server"
-	"github.com/xmidt-org/webpa-common/xhttp"
 	"github.com/xmidt-org/webpa-common/xmetrics"
+	"google.golang.org/grpc"
 )
 
 // convenient global values
@@ -80,7 +88,23 @@ const (
 	reducedTransactionLoggingCodesKey = "log.reducedLoggingResponseCodes"
 	authAcquirerKey                   = "authAcquirer"
 	webhookConfigKey                  = "webhook"
+	webhookAdminCapabilityKey         = "webhook.adminCapability"
+	webhookValidationConfigKey        = "webhook.validation"
+	webhookStoreConfigKey             = "webhook.store"
+	webhookExpiryWarningWindowKey     = "webhook.expiryWarningWindow"
 	tracingConfigKey                  = "tracing"
+	statWatchConfigKey                = "statWatch"
+	statCacheConfigKey                = "statCache"
+	partnerPolicyConfigKey            = "partnerPolicy"
+	parameterPolicyConfigKey          = "parameterPolicy"
+	sensitiveParametersConfigKey      = "sensitiveParameters"
+	auditConfigKey                    = "audit"
+	shutdownGracePeriodKey            = "shutdownGracePeriod"
+	healthChecksConfigKey             = "healthChecks"
+	webhookArgusAddressKey            = "webhook.argus.address"
+	grpcConfigKey                     = "grpc"
+	consoleConfigKey                  = "console"
+	schedulerConfigKey                = "scheduler"
 )
 
 var (
@@ -100,13 +124,17 @@ var defaults = map[string]interface{}{
 	reqMaxRetriesKey:       2,
 	wrpSourceKey:           "dns:localhost",
 	hooksSchemeKey:         "https",
+	shutdownGracePeriodKey: "30s",
 }
 
+// metricModules are the metrics of all the tr1d1um components.
+var metricModules = []xmetrics.Module{ancla.Metrics, basculechecks.Metrics, basculemetrics.Metrics, common.Metrics, healthcheck.Metrics, schedule.Metrics, stat.Metrics, translation.Metrics, webhook.Metrics}
+
 func tr1d1um(arguments []string) (exitCode int) {
 
 	var (
 		f, v                                = pflag.NewFlagSet(applicationName, pflag.ContinueOnError), viper.New()
-		logger, metricsRegistry, webPA, err = server.Initialize(applicationName, arguments, f, v, ancla.Metrics, basculechecks.Metrics, basculemetrics.Metrics)
+		logger, metricsRegistry, webPA, err = server.Initialize(applicationName, arguments, f, v, metricModules...)
 	)
 
 	// This allows us to communicate the version of the binary upon request.
@@ -132,17 +160,39 @@ func tr1d1um(arguments []string) (exitCode int) {
 
 	infoLogger.Log("configurationFile", v.ConfigFileUsed())
 
+	// startup self-check: problems such as misspelled keys are otherwise silently ignored
+	report := new(configReport)
+	report.decodeSections(v)
+	report.logWarnings(logger)
+
 	tracing, err := loadTracing(v, applicationName)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Unable to build tracing component: %v \n", err)
 		return 1
 	}
 	infoLogger.Log(logging.MessageKey(), "tracing status", "enabled", !tracing.IsNoop())
-	authenticate, err = authenticationHandler(v, logger, metricsRegistry)
+	initialRuntime, err := runtimeOptions(v)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to parse runtime config values: %s\n", err.Error())
+		return 1
+	}
+	runtimeConfig := common.NewRuntime(initialRuntime)
+
+	gracePeriod, err := shutdownGracePeriod(v)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to parse shutdown grace period: %s\n", err.Error())
+		return 1
+	}
+
+	authDone := make(chan struct{})
+	authChain, err := authenticationHandler(v, logger, metricsRegistry, authDone)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Unable to build authentication handler: %s\n", err.Error())
 		return 1
 	}
+	reloadableAuth := newReloadableAuth(authChain, authDone)
+	reloadableChain := alice.New(reloadableAuth.constructor)
+	authenticate = &reloadableChain
 
 	rootRouter := mux.NewRouter()
 	otelMuxOptions := []otelmux.Option{
@@ -152,6 +202,50 @@ func tr1d1um(arguments []string) (exitCode int) {
 	rootRouter.Use(otelmux.Middleware("mainSpan", otelMuxOptions...), candlelight.EchoFirstTraceNodeInfo(tracing.Propagator()))
 
 	APIRouter := rootRouter.PathPrefix(fmt.Sprintf("/%s/", apiBase)).Subrouter()
+	var routes apiRoutes
+
+	// translation and stat requests in flight get to complete on shutdown
+	drainer := common.NewDrainer(metricsRegistry)
+
+	var partnerPolicy *common.PartnerPolicy
+	if v.IsSet(partnerPolicyConfigKey) {
+		partnerPolicy = new(common.PartnerPolicy)
+		if err := v.UnmarshalKey(partnerPolicyConfigKey, partnerPolicy); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to parse partner policy config values: %s \n", err.Error())
+			return 1
+		}
+	}
+
+	var auditSink audit.Sink
+	if v.IsSet(auditConfigKey) {
+		var auditConfig audit.Config
+		if err := v.UnmarshalKey(auditConfigKey, &auditConfig); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to parse audit config values: %s \n", err.Error())
+			return 1
+		}
+
+		if auditSink, err = audit.NewSink(auditConfig); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to create audit sink: %s \n", err.Error())
+			return 1
+		}
+
+		if closer, ok := auditSink.(io.Closer); ok {
+			defer closer.Close()
+		}
+
+		if reader, ok := auditSink.(audit.Reader); ok && auditConfig.ReadCapability != "" {
+			routes.audit = &audit.Options{
+				R:                           reader,
+				Authenticate:                authenticate,
+				Log:                         logger,
+				ReducedLoggingResponseCodes: v.GetIntSlice(reducedTransactionLoggingCodesKey),
+				ReadCapability:              auditConfig.ReadCapability,
+				AdminCapability:             auditConfig.AdminCapability,
+				Runtime:                     runtimeConfig,
+			}
+		}
+		infoLogger.Log(logging.MessageKey(), "Audit trail enabled")
+	}
 
 	//
 	// Webhooks (if not configured, handlers are not set up)
@@ -174,18 +268,41 @@ func tr1d1um(arguments []string) (exitCode int) {
 		}
 		webhookConfig.Argus.HTTPClient = newHTTPClient(argusClientTimeout, tracing)
 
-		svc, stopWatch, err := ancla.Initialize(webhookConfig, getLogger)
+		var validationOptions webhook.ValidationOptions
+		if err := v.UnmarshalKey(webhookValidationConfigKey, &validationOptions); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to decode config for webhook validation: %s\n", err.Error())
+			return 1
+		}
+		validationOptions.Schemes = v.GetStringSlice(hooksSchemeKey)
+		validationOptions.ProbeSource = v.GetString(wrpSourceKey)
+
+		var storeConfig webhook.StoreConfig
+		if err := v.UnmarshalKey(webhookStoreConfigKey, &storeConfig); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to decode config for webhook store: %s\n", err.Error())
+			return 1
+		}
+
+		expiryWatch := webhook.NewExpiryWatch(v.GetDuration(webhookExpiryWarningWindowKey), metricsRegistry, logger)
+
+		svc, stopWatch, err := webhook.Initialize(webhookConfig, storeConfig, getLogger, expiryWatch)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to initialize webhook service: %s\n", err.Error())
 			return 1
 		}
 		defer stopWatch()
 
-		addWebhookHandler := ancla.NewAddWebhookHandler(svc, ancla.HandlerConfig{MetricsProvider: metricsRegistry})
-		getAllWebhooksHandler := ancla.NewGetAllWebhooksHandler(svc)
-
-		APIRouter.Handle("/hook", authenticate.Then(addWebhookHandler)).Methods(http.MethodPost)
-		APIRouter.Handle("/hooks", authenticate.Then(getAllWebhooksHandler)).Methods(http.MethodGet)
+		routes.webhook = &webhook.Options{
+			S:                           svc,
+			Authenticate:                authenticate,
+			Log:                         logger,
+			ReducedLoggingResponseCodes: v.GetIntSlice(reducedTransactionLoggingCodesKey),
+			MetricsProvider:             metricsRegistry,
+			AdminCapability:             v.GetString(webhookAdminCapabilityKey),
+			Validation:                  validationOptions,
+			PartnerPolicy:               partnerPolicy,
+			Audit:                       auditSink,
+			Runtime:                     runtimeConfig,
+		}
 
 		infoLogger.Log(logging.MessageKey(), "Webhook service enabled")
 	} else {
@@ -198,20 +315,16 @@ func tr1d1um(arguments []string) (exitCode int) {
 		return 1
 	}
 	xmidtHTTPClient := newHTTPClient(xmidtClientTimeout, tracing)
+	xmidtDuration := metricsRegistry.NewHistogram(common.XmidtRequestDurationHistogram, 0)
 
 	// Stat Service configs
 	//
 	statServiceOptions := &stat.ServiceOptions{
 		HTTPTransactor: common.NewTr1d1umTransactor(
 			&common.Tr1d1umTransactorOptions{
-				Do: xhttp.RetryTransactor(
-					xhttp.RetryOptions{
-						Logger:   logger,
-						Retries:  v.GetInt(reqMaxRetriesKey),
-						Interval: v.GetDuration(reqRetryIntervalKey),
-					},
-					xmidtHTTPClient.Do),
+				Do:             retryTransactor(runtimeConfig, logger, xmidtHTTPClient.Do),
 				RequestTimeout: xmidtClientTimeout.RequestTimeout,
+				Duration:       xmidtDuration.With(common.TargetLabel, common.StatTarget),
 			}),
 		XmidtStatURL: fmt.Sprintf("%s/%s/device/${device}/stat", v.GetString(targetURLKey), apiBase),
 	}
@@ -225,22 +338,19 @@ func tr1d1um(arguments []string) (exitCode int) {
 		Tr1d1umTransactor: common.NewTr1d1umTransactor(
 			&common.Tr1d1umTransactorOptions{
 				RequestTimeout: xmidtClientTimeout.RequestTimeout,
-				Do: xhttp.RetryTransactor(
-					xhttp.RetryOptions{
-						Logger:   logger,
-						Retries:  v.GetInt(reqMaxRetriesKey),
-						Interval: v.GetDuration(reqRetryIntervalKey),
-					},
-					xmidtHTTPClient.Do),
+				Do:             retryTransactor(runtimeConfig, logger, xmidtHTTPClient.Do),
+				Duration:       xmidtDuration.With(common.TargetLabel, common.DeviceTarget),
 			}),
 	}
 
 	reducedLoggingResponseCodes := v.GetIntSlice(reducedTransactionLoggingCodesKey)
 
+	var acquirer acquire.Acquirer
 	if v.IsSet(authAcquirerKey) {
-		acquirer, err := createAuthAcquirer(v)
+		acquirer, err = createAuthAcquirer(v)
 		if err != nil {
 			errorLogger.Log(logging.MessageKey(), "Could not configure auth acquirer", logging.ErrorKey(), err)
+			acquirer = nil // errors come along with typed nil acquirers
 		} else {
 			translationOptions.AuthAcquirer = acquirer
 			statServiceOptions.AuthAcquirer = acquirer
@@ -251,23 +361,181 @@ func tr1d1um(arguments []string) (exitCode int) {
 	ss := stat.NewService(statServiceOptions)
 	ts := translation.NewService(translationOptions)
 
-	// Must be called before translation.ConfigHandler due to mux path specificity (https://github.com/gorilla/mux#matching-routes).
-	stat.ConfigHandler(&stat.Options{
+	var statWatchOptions *stat.WatchOptions
+	if v.IsSet(statWatchConfigKey) {
+		statWatchOptions = new(stat.WatchOptions)
+		if err := v.UnmarshalKey(statWatchConfigKey, statWatchOptions); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to parse stat watch config values: %s \n", err.Error())
+			return 1
+		}
+		infoLogger.Log(logging.MessageKey(), "Device presence watch enabled")
+	}
+
+	var statCacheOptions *stat.CacheOptions
+	if v.IsSet(statCacheConfigKey) {
+		statCacheOptions = new(stat.CacheOptions)
+		if err := v.UnmarshalKey(statCacheConfigKey, statCacheOptions); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to parse stat cache config values: %s \n", err.Error())
+			return 1
+		}
+		infoLogger.Log(logging.MessageKey(), "Device stat snapshot cache enabled")
+	}
+
+	routes.stat = &stat.Options{
 		S:                           ss,
-		APIRouter:                   APIRouter,
 		Authenticate:                authenticate,
 		Log:                         logger,
 		ReducedLoggingResponseCodes: reducedLoggingResponseCodes,
-	})
+		Watch:                       statWatchOptions,
+		Cache:                       statCacheOptions,
+		MetricsProvider:             metricsRegistry,
+		Runtime:                     runtimeConfig,
+		Drainer:                     drainer,
+	}
+
+	var parameterPolicy *translation.ParameterPolicy
+	if v.IsSet(parameterPolicyConfigKey) {
+		parameterPolicy = new(translation.ParameterPolicy)
+		if err := v.UnmarshalKey(parameterPolicyConfigKey, parameterPolicy); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to parse parameter policy config values: %s \n", err.Error())
+			return 1
+		}
+	}
+
+	var sensitiveParameters *translation.SensitiveParameters
+	if v.IsSet(sensitiveParametersConfigKey) {
+		sensitiveParameters = new(translation.SensitiveParameters)
+		if err := v.UnmarshalKey(sensitiveParametersConfigKey, sensitiveParameters); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to parse sensitive parameters config values: %s \n", err.Error())
+			return 1
+		}
+	}
 
-	translation.ConfigHandler(&translation.Options{
+	var consoleOptions *translation.ConsoleOptions
+	if v.IsSet(consoleConfigKey) {
+		consoleOptions = new(translation.ConsoleOptions)
+		if err := v.UnmarshalKey(consoleConfigKey, consoleOptions); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to parse console config values: %s \n", err.Error())
+			return 1
+		}
+		infoLogger.Log(logging.MessageKey(), "Device console enabled")
+	}
+
+	routes.translation = &translation.Options{
 		S:                           ts,
-		APIRouter:                   APIRouter,
 		Authenticate:                authenticate,
 		Log:                         logger,
 		ValidServices:               v.GetStringSlice(translationServicesKey),
 		ReducedLoggingResponseCodes: reducedLoggingResponseCodes,
-	})
+		PartnerPolicy:               partnerPolicy,
+		ParameterPolicy:             parameterPolicy,
+		SensitiveParameters:         sensitiveParameters,
+		Audit:                       auditSink,
+		Runtime:                     runtimeConfig,
+		MetricsProvider:             metricsRegistry,
+		Drainer:                     drainer,
+		Console:                     consoleOptions,
+	}
+
+	if v.IsSet(schedulerConfigKey) {
+		var schedulerConfig schedule.Config
+		if err := v.UnmarshalKey(schedulerConfigKey, &schedulerConfig); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to parse scheduler config values: %s \n", err.Error())
+			return 1
+		}
+
+		store, err := schedule.NewStore(schedulerConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to create schedule store: %s \n", err.Error())
+			return 1
+		}
+
+		schedulerOptions := &schedule.Options{
+			S:                           ts,
+			Store:                       store,
+			Authenticate:                authenticate,
+			Log:                         logger,
+			ReducedLoggingResponseCodes: reducedLoggingResponseCodes,
+			MetricsProvider:             metricsRegistry,
+			ValidServices:               v.GetStringSlice(translationServicesKey),
+			PartnerPolicy:               partnerPolicy,
+			ParameterPolicy:             parameterPolicy,
+			DeviceAccess:                requestDeviceAccess{},
+			SensitiveParameters:         sensitiveParameters,
+			Drainer:                     drainer,
+			Runtime:                     runtimeConfig,
+		}
+		scheduler := schedule.NewScheduler(schedulerConfig, schedulerOptions)
+		routes.scheduler, routes.schedule = scheduler, schedulerOptions
+
+		// scheduled operations in flight are drained along with requests before the scheduler stops
+		stopScheduler := scheduler.Start()
+		defer stopScheduler()
+
+		if acquirer == nil {
+			logging.Warn(logger).Log(logging.MessageKey(), "Scheduled operations are sent without authorization as no auth acquirer is configured")
+		}
+		infoLogger.Log(logging.MessageKey(), "Scheduled operations enabled")
+	}
+
+	var grpcServer *grpc.Server
+	var grpcListener net.Listener
+	if v.IsSet(grpcConfigKey) {
+		var grpcConfig rpc.Config
+		if err := v.UnmarshalKey(grpcConfigKey, &grpcConfig); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to parse gRPC config values: %s \n", err.Error())
+			return 1
+		}
+
+		grpcServer, err = rpc.NewServer(grpcConfig, rpc.Options{
+			Translation: translation.NewGRPCHandlers(&translation.GRPCOptions{
+				S:                   ts,
+				Log:                 logger,
+				ValidServices:       v.GetStringSlice(translationServicesKey),
+				PartnerPolicy:       partnerPolicy,
+				ParameterPolicy:     parameterPolicy,
+				SensitiveParameters: sensitiveParameters,
+				Audit:               auditSink,
+				Runtime:             runtimeConfig,
+			}),
+			Stat:         stat.NewGRPCHandler(&stat.GRPCOptions{S: ss, Log: logger}),
+			Authenticate: authenticate,
+			Drainer:      drainer,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to build gRPC server: %s \n", err.Error())
+			return 1
+		}
+
+		if grpcListener, err = net.Listen("tcp", grpcConfig.Address); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to listen for gRPC requests: %s \n", err.Error())
+			return 1
+		}
+		infoLogger.Log(logging.MessageKey(), "gRPC API enabled", "address", grpcListener.Addr().String())
+	}
+
+	routes.openapi = &openapi.Options{
+		Log:     logger,
+		Version: Version,
+	}
+	routes.register(APIRouter)
+
+	healthMonitor, err := newHealthMonitor(v, logger, metricsRegistry, drainer, acquirer)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to parse health checks config values: %s \n", err.Error())
+		return 1
+	}
+	rootRouter.HandleFunc(healthcheck.LivePath, healthMonitor.Live).Methods(http.MethodGet)
+	rootRouter.HandleFunc(healthcheck.ReadyPath, healthMonitor.Ready).Methods(http.MethodGet)
+	stopHealthChecks := healthMonitor.Start()
+	defer stopHealthChecks()
+
+	// runtime config is reloaded whenever the config file changes or on SIGHUP
+	var configReloader *reloader
+	if v.ConfigFileUsed() != "" {
+		configReloader = newReloader(v, f, logger, metricsRegistry, runtimeConfig, reloadableAuth)
+		configReloader.watch(v)
+	}
 
 	var (
 		_, tr1d1umServer, done = webPA.Prepare(logger, nil, metricsRegistry, rootRouter)
@@ -284,24 +552,102 @@ func tr1d1um(arguments []string) (exitCode int) {
 		return 4
 	}
 
-	signal.Notify(signals, os.Kill, os.Interrupt)
+	grpcDone := make(chan struct{})
+	if grpcServer != nil {
+		go func() {
+			defer close(grpcDone)
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				errorLogger.Log(logging.MessageKey(), "gRPC server exited", logging.ErrorKey(), err)
+			}
+		}()
+	}
+
+	signal.Notify(signals, os.Kill, os.Interrupt, syscall.SIGTERM)
+	if configReloader != nil {
+		signal.Notify(signals, syscall.SIGHUP)
+	}
+
+	var drain bool
 	for exit := false; !exit; {
 		select {
 		case s := <-signals:
+			if s == syscall.SIGHUP {
+				configReloader.reloadAndLog("SIGHUP")
+				continue
+			}
+
 			logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "exiting due to signal", "signal", s)
-			exit = true
+			exit, drain = true, true
 		case <-done:
 			logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "one or more servers exited")
 			exit = true
+		case <-grpcDone:
+			logger.Log(level.Key(), level.ErrorValue(), logging.MessageKey(), "gRPC server exited")
+			exit = true
 		}
 	}
 
+	// the servers are still up when exiting due to a signal so requests in flight can complete
+	stopDeadline := time.Now().Add(gracePeriod)
+	if drain {
+		infoLogger.Log(logging.MessageKey(), "draining requests", "inFlight", drainer.InFlight(), "gracePeriod", gracePeriod)
+		if abandoned := drainer.Drain(gracePeriod); abandoned > 0 {
+			errorLogger.Log(logging.MessageKey(), "abandoning requests still in flight at the end of the grace period", "abandoned", abandoned)
+		} else {
+			infoLogger.Log(logging.MessageKey(), "drained requests")
+		}
+	}
+
+	if grpcServer != nil && stopGracefully(grpcServer, time.Until(stopDeadline)) {
+		errorLogger.Log(logging.MessageKey(), "stopped the gRPC server at the end of the grace period, abandoning the RPCs in flight")
+	}
+
 	close(shutdown)
 	waitGroup.Wait()
 
 	return 0
 }
 
+// gracefulStopper is the part of *grpc.Server stopping it.
+type gracefulStopper interface {
+	GracefulStop()
+	Stop()
+}
+
+// stopGracefully gracefully stops s, which waits for the RPCs in flight to complete, for up to
+// timeout. s is stopped right away at the end of the timeout so that the RPCs the drain
+// abandoned can't keep tr1d1um from exiting. It returns true if s had to be stopped.
+func stopGracefully(s gracefulStopper, timeout time.Duration) bool {
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		s.GracefulStop()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-stopped:
+		return false
+	case <-timer.C:
+	}
+
+	// Stop also makes GracefulStop return
+	s.Stop()
+	<-stopped
+	return true
+}
+
+// shutdownGracePeriod returns how long the requests in flight may take to complete on shutdown.
+func shutdownGracePeriod(v *viper.Viper) (time.Duration, error) {
+	gracePeriod, err := cast.ToDurationE(v.Get(shutdownGracePeriodKey))
+	if err != nil || gracePeriod < 0 {
+		return 0, invalidValue(shutdownGracePeriodKey, err)
+	}
+	return gracePeriod, nil
+}
+
 func newXmidtClientTimeout(v *viper.Viper) (httpClientTimeout, error) {
 	var timeouts httpClientTimeout
 	err := v.UnmarshalKey("xmidtClientTimeout", &timeouts)
@@ -423,8 +769,9 @@ type CapabilityConfig struct {
 	EndpointBuckets []string
 }
 
-// authenticationHandler configures the authorization requirements for requests to reach the main handler
-func authenticationHandler(v *viper.Viper, logger log.Logger, registry xmetrics.Registry) (*alice.Chain, error) {
+// authenticationHandler configures the authorization requirements for requests to reach the main handler.
+// Background work of the chain, such as watching the basic auth credentials file, stops once done is closed.
+func authenticationHandler(v *viper.Viper, logger log.Logger, registry xmetrics.Registry, done <-chan struct{}) (*alice.Chain, error) {
 	if registry == nil {
 		return nil, errors.New("nil registry")
 	}
@@ -434,8 +781,8 @@ func authenticationHandler(v *viper.Viper, logger log.Logger, registry xmetrics.
 	listener := basculemetrics.NewMetricListener(basculeMeasures)
 
 	basicAllowed := make(map[string]string)
-	basicAuth := v.GetStringSlice("authHeader")
-	for _, a := range basicAuth {
+	authHeaders := v.GetStringSlice("authHeader")
+	for _, a := range authHeaders {
 		decoded, err := base64.StdEncoding.DecodeString(a)
 		if err != nil {
 			logging.Info(logger).Log(logging.MessageKey(), "failed to decode auth header", "authHeader", a, logging.ErrorKey(), err.Error())
@@ -447,14 +794,25 @@ func authenticationHandler(v *viper.Viper, logger log.Logger, registry xmetrics.
 			basicAllowed[string(decoded[:i])] = string(decoded[i+1:])
 		}
 	}
-	logging.Debug(logger).Log(logging.MessageKey(), "Created list of allowed basic auths", "allowed", basicAllowed, "config", basicAuth)
+	logging.Debug(logger).Log(logging.MessageKey(), "Created list of allowed basic auths", "allowed", basicAllowed, "config", authHeaders)
 
 	options := []basculehttp.COption{
 		basculehttp.WithCLogger(getLogger),
 		basculehttp.WithCErrorResponseFunc(listener.OnErrorResponse),
 		basculehttp.WithParseURLFunc(basculehttp.CreateRemovePrefixURLFunc("/"+apiBase+"/", basculehttp.DefaultParseURLFunc)),
 	}
-	if len(basicAllowed) > 0 {
+
+	var basicAuth BasicAuthConfig
+	v.UnmarshalKey("basicAuth", &basicAuth)
+	if len(basicAuth.Credentials) > 0 || basicAuth.File != "" {
+		f := newHashedBasicTokenFactory(basicAllowed, basicAuth)
+		if basicAuth.File != "" {
+			if err := f.watchFile(basicAuth.File, logger, done); err != nil {
+				return nil, emperror.With(err, "failed to load basic auth credentials file")
+			}
+		}
+		options = append(options, basculehttp.WithTokenFactory("Basic", f))
+	} else if len(basicAllowed) > 0 {
 		options = append(options, basculehttp.WithTokenFactory("Basic", basculehttp.BasicTokenFactory(basicAllowed)))
 	}
 	var jwtVal JWTValidator
@@ -507,6 +865,14 @@ func authenticationHandler(v *viper.Viper, logger log.Logger, registry xmetrics.
 		bearerRules = append(bearerRules, m.CreateValidator(capabilityCheck.Type == "enforce"))
 	}
 
+	// only add device access check if the configuration is set
+	var deviceAccess DeviceAccessConfig
+	v.UnmarshalKey("deviceAccessCheck", &deviceAccess)
+	deviceAccessValidator, checkDeviceAccess := newDeviceAccessValidator(deviceAccess)
+	if checkDeviceAccess {
+		bearerRules = append(bearerRules, deviceAccessValidator)
+	}
+
 	authEnforcer := basculehttp.NewEnforcer(
 		basculehttp.WithELogger(getLogger),
 		basculehttp.WithRules("Basic", bascule.Validators{
@@ -515,7 +881,10 @@ func authenticationHandler(v *viper.Viper, logger log.Logger, registry xmetrics.
 		basculehttp.WithRules("Bearer", bearerRules),
 		basculehttp.WithEErrorResponseFunc(listener.OnErrorResponse),
 	)
-	constructors := []alice.Constructor{setLogger(logger), authConstructor, authEnforcer, basculehttp.NewListenerDecorator(listener)}
+	constructors := []alice.Constructor{setLogger(logger), authErrors, authConstructor, authEnforcer, authPassed, basculehttp.NewListenerDecorator(listener)}
+	if checkDeviceAccess {
+		constructors = append(constructors, deviceAccessValidator.decorate)
+	}
 
 	chain := alice.New(constructors...)
 	return &chain, nil
@@ -523,6 +892,7 @@ func authenticationHandler(v *viper.Viper, logger log.Logger, registry xmetrics.
 
 func printVersion(f *pflag.FlagSet, arguments []string) (error, bool) {
 	printVer := f.BoolP("version", "v", false, "displays the version number")
+	validate := f.Bool(validateConfigFlag, false, "validates the configuration without starting tr1d1um and exits with a non-zero code on errors")
 	if err := f.Parse(arguments); err != nil {
 		return err, true
 	}
@@ -531,6 +901,14 @@ func printVersion(f *pflag.FlagSet, arguments []string) (error, bool) {
 		printVersionInfo(os.Stdout)
 		return nil, true
 	}
+
+	if *validate {
+		// the report printed explains the failure
+		if err := validateConfig(arguments, os.Stdout); err != nil {
+			os.Exit(1)
+		}
+		return nil, true
+	}
 	return nil, false
 }
 
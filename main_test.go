@@ -0,0 +1,143 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/bascule/acquire"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/healthcheck"
+	"github.com/xmidt-org/tr1d1um/webhook"
+)
+
+func TestShutdownGracePeriod(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Value       interface{}
+		Expected    time.Duration
+		ExpectedErr bool
+	}{
+		{Name: "Default", Expected: 30 * time.Second},
+		{Name: "Set", Value: "1m", Expected: time.Minute},
+		{Name: "Disabled", Value: "0s"},
+		{Name: "Bad", Value: "soon", ExpectedErr: true},
+		{Name: "Negative", Value: "-1s", ExpectedErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			v := viper.New()
+			for k, va := range defaults {
+				v.SetDefault(k, va)
+			}
+			if tc.Value != nil {
+				v.Set(shutdownGracePeriodKey, tc.Value)
+			}
+
+			gracePeriod, err := shutdownGracePeriod(v)
+			if tc.ExpectedErr {
+				assert.NotNil(err)
+				return
+			}
+
+			assert.Nil(err)
+			assert.Equal(tc.Expected, gracePeriod)
+		})
+	}
+}
+
+func TestNewHealthMonitor(t *testing.T) {
+	testCases := []struct {
+		Name               string
+		Config             map[string]interface{}
+		Acquirer           acquire.Acquirer
+		ExpectedComponents map[string]bool
+	}{
+		{
+			Name:               "Minimal",
+			ExpectedComponents: map[string]bool{healthcheck.ShutdownComponent: true, healthcheck.XmidtComponent: false},
+		},
+		{
+			Name: "All",
+			Config: map[string]interface{}{
+				webhookConfigKey: map[string]interface{}{"argus": map[string]interface{}{"address": "http://localhost:6600"}},
+				"jwtValidator":   map[string]interface{}{"keys": map[string]interface{}{"factory": map[string]interface{}{"uri": "http://localhost:6500/keys/{keyId}"}}},
+			},
+			Acquirer: new(acquire.DefaultAcquirer),
+			ExpectedComponents: map[string]bool{
+				healthcheck.AcquirerComponent: false, healthcheck.ArgusComponent: false, healthcheck.KeyServerComponent: false,
+				healthcheck.ShutdownComponent: true, healthcheck.XmidtComponent: false,
+			},
+		},
+		{
+			Name: "Critical Dependency",
+			Config: map[string]interface{}{
+				healthChecksConfigKey: map[string]interface{}{"critical": map[string]interface{}{healthcheck.XmidtComponent: true}},
+			},
+			ExpectedComponents: map[string]bool{healthcheck.ShutdownComponent: true, healthcheck.XmidtComponent: true},
+		},
+		{
+			Name: "Local Webhook Store",
+			Config: map[string]interface{}{
+				webhookConfigKey: map[string]interface{}{"store": map[string]interface{}{"type": webhook.MemoryStoreType}},
+			},
+			ExpectedComponents: map[string]bool{healthcheck.ShutdownComponent: true, healthcheck.XmidtComponent: false},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			v := viper.New()
+			for k, va := range defaults {
+				v.SetDefault(k, va)
+			}
+			for k, va := range tc.Config {
+				v.Set(k, va)
+			}
+
+			m, err := newHealthMonitor(v, nil, nil, common.NewDrainer(nil), tc.Acquirer)
+			assert.Nil(err)
+
+			components := make(map[string]bool)
+			for name, status := range m.Document().Components {
+				components[name] = status.Critical
+			}
+			assert.Equal(tc.ExpectedComponents, components)
+		})
+	}
+}
+
+type testStopper struct {
+	release chan struct{}
+	stopped bool
+}
+
+func (s *testStopper) GracefulStop() { <-s.release }
+
+func (s *testStopper) Stop() {
+	s.stopped = true
+	close(s.release)
+}
+
+func TestStopGracefully(t *testing.T) {
+	t.Run("Graceful", func(t *testing.T) {
+		assert := assert.New(t)
+		s := &testStopper{release: make(chan struct{})}
+		close(s.release)
+
+		assert.False(stopGracefully(s, time.Minute))
+		assert.False(s.stopped)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		assert := assert.New(t)
+		s := &testStopper{release: make(chan struct{})}
+
+		assert.True(stopGracefully(s, time.Millisecond))
+		assert.True(s.stopped)
+	})
+}
@@ -0,0 +1,166 @@
+// Package openapi describes the tr1d1um API in an OpenAPI 3 document and serves it.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+	"github.com/xmidt-org/webpa-common/logging"
+)
+
+// Path is the route the document is served at, relative to the API router.
+const Path = "/openapi.json"
+
+// Document is an OpenAPI 3 document. Only the fields tr1d1um uses are supported.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers,omitempty"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security,omitempty"`
+	Tags       []Tag                 `json:"tags,omitempty"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL of the API.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag groups operations.
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path keyed by their lowercase HTTP method.
+type PathItem map[string]*Operation
+
+// Operation describes a route.
+type Operation struct {
+	OperationID string            `json:"operationId"`
+	Summary     string            `json:"summary"`
+	Description string            `json:"description,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Parameters  []*Parameter      `json:"parameters,omitempty"`
+	RequestBody *RequestBody      `json:"requestBody,omitempty"`
+	Responses   map[int]*Response `json:"responses"`
+
+	// Security overrides the document security requirements when set. An empty
+	// slice marks the operation as unauthenticated.
+	Security *[]SecurityRequirement `json:"security,omitempty"`
+}
+
+// Parameter is a path, query or header parameter.
+type Parameter struct {
+	Ref         string  `json:"$ref,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	In          string  `json:"in,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+// MediaType is the schema of a body in a given media type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Response describes a response.
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Headers     map[string]*Header   `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Header describes a response header.
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Schema is a JSON schema.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Example              interface{}        `json:"example,omitempty"`
+}
+
+// Components holds the definitions referenced throughout the document.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	Parameters      map[string]*Parameter      `json:"parameters,omitempty"`
+	Responses       map[string]*Response       `json:"responses,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme is a way callers authenticate.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// SecurityRequirement lists the security schemes a request must satisfy.
+type SecurityRequirement map[string][]string
+
+// Operation returns the operation of the path and HTTP method, if any.
+func (d *Document) Operation(path, method string) (*Operation, bool) {
+	o, ok := d.Paths[path][methodKey(method)]
+	return o, ok
+}
+
+// Options wraps the properties needed to serve the OpenAPI document
+type Options struct {
+	//APIRouter is assumed to be a subrouter with the API prefix path (i.e. 'api/v2')
+	APIRouter *mux.Router
+	Log       kitlog.Logger
+
+	//Version is the API version reported by the document.
+	Version string
+}
+
+// ConfigHandler sets up the route serving the OpenAPI document. The document describes
+// the API and is served without authentication.
+func ConfigHandler(c *Options) {
+	spec, err := json.Marshal(NewDocument(c.Version))
+	if err != nil {
+		// the document is built from static values so this is a programming error
+		panic(err)
+	}
+
+	c.APIRouter.Handle(Path, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(spec); err != nil {
+			logging.Debug(c.Log).Log(logging.MessageKey(), "could not write OpenAPI document", logging.ErrorKey(), err)
+		}
+	})).Methods(http.MethodGet)
+}
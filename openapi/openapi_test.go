@@ -0,0 +1,93 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const apiPrefix = "/api/v2"
+
+// newRouter registers the OpenAPI document route the way tr1d1um does.
+func newRouter() *mux.Router {
+	rootRouter := mux.NewRouter()
+	ConfigHandler(&Options{
+		APIRouter: rootRouter.PathPrefix(apiPrefix + "/").Subrouter(),
+		Log:       log.NewNopLogger(),
+		Version:   "test",
+	})
+
+	return rootRouter
+}
+
+func TestDocumentReferences(t *testing.T) {
+	assert := assert.New(t)
+
+	data, err := json.Marshal(NewDocument("test"))
+	require.NoError(t, err)
+
+	var document map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &document))
+
+	var refs []string
+	collectRefs(document, &refs)
+	assert.NotEmpty(refs)
+
+	for _, ref := range refs {
+		var (
+			target interface{} = document
+			ok                 = strings.HasPrefix(ref, "#/")
+		)
+
+		for _, segment := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			if !ok {
+				break
+			}
+			target, ok = target.(map[string]interface{})[segment]
+		}
+		assert.True(ok, "%s does not resolve", ref)
+	}
+}
+
+func collectRefs(v interface{}, refs *[]string) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, value := range t {
+			if ref, ok := value.(string); ok && k == "$ref" {
+				*refs = append(*refs, ref)
+				continue
+			}
+			collectRefs(value, refs)
+		}
+	case []interface{}:
+		for _, value := range t {
+			collectRefs(value, refs)
+		}
+	}
+}
+
+func TestConfigHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	recorder := httptest.NewRecorder()
+	newRouter().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, apiPrefix+Path, nil))
+
+	assert.Equal(http.StatusOK, recorder.Code)
+	assert.Equal("application/json", recorder.Header().Get("Content-Type"))
+
+	var document Document
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &document))
+	assert.Equal("3.0.3", document.OpenAPI)
+	assert.Equal("test", document.Info.Version)
+
+	o, ok := document.Operation("/device/{deviceid}/{service}", http.MethodPatch)
+	require.True(t, ok)
+	assert.Contains(o.Responses, http.StatusOK)
+}
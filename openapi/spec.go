@@ -0,0 +1,734 @@
+package openapi
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/translation"
+	"github.com/xmidt-org/wrp-go/v3/wrphttp"
+)
+
+const (
+	jsonMediaType        = "application/json"
+	eventStreamMediaType = "text/event-stream"
+
+	basicAuthScheme  = "basicAuth"
+	bearerAuthScheme = "bearerAuth"
+)
+
+// Tags of the operations.
+const (
+	deviceTag   = "device"
+	statTag     = "stat"
+	webhookTag  = "webhook"
+	auditTag    = "audit"
+	scheduleTag = "schedule"
+	docsTag     = "docs"
+)
+
+// NewDocument returns the document describing the routes registered by the translation,
+// stat, webhook, audit and schedule handlers.
+func NewDocument(version string) *Document {
+	return &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title: "Tr1d1um",
+			Description: "Tr1d1um translates RESTful requests into WRP messages for the devices " +
+				"connected to the XMiDT cluster and manages webhook registrations.",
+			Version: version,
+		},
+		Servers:  []Server{{URL: "/api/v2"}},
+		Security: []SecurityRequirement{{basicAuthScheme: {}}, {bearerAuthScheme: {}}},
+		Tags: []Tag{
+			{Name: deviceTag, Description: "Device parameters and tables, sent to devices as WDMP commands"},
+			{Name: statTag, Description: "Device statistics and presence"},
+			{Name: webhookTag, Description: "Event listener registrations"},
+			{Name: auditTag, Description: "Audit trail of mutating requests. Only served when audit is configured"},
+			{Name: scheduleTag, Description: "Scheduled and deferred device operations. Only served when the scheduler is configured"},
+			{Name: docsTag, Description: "API documentation"},
+		},
+		Paths: map[string]PathItem{
+			"/device/{deviceid}/{service}": {
+				"get": {
+					OperationID: "getParameters",
+					Summary:     "Gets the values or attributes of device parameters",
+					Description: "Sends a GET, or a GET_ATTRIBUTES when attributes is set, to the device. " +
+						"Values of sensitive parameters may be masked.",
+					Tags: []string{deviceTag},
+					Parameters: []*Parameter{
+						parameterRef("deviceID"),
+						parameterRef("service"),
+						{Name: "names", In: "query", Required: true, Description: "Comma separated parameter names", Schema: stringSchema("")},
+						{Name: "attributes", In: "query", Description: "Comma separated attribute names, i.e. notify", Schema: stringSchema("")},
+						parameterRef("partnerIDs"),
+						parameterRef("transactionID"),
+					},
+					Responses: deviceResponses(),
+				},
+				"patch": {
+					OperationID: "setParameters",
+					Summary:     "Sets the values or attributes of device parameters",
+					Description: "Sends a SET, SET_ATTRIBUTES or, when the CID headers are set, a TEST_AND_SET to the device.",
+					Tags:        []string{deviceTag},
+					Parameters: []*Parameter{
+						parameterRef("deviceID"),
+						parameterRef("service"),
+						parameterRef("newCID"),
+						parameterRef("oldCID"),
+						parameterRef("syncCMC"),
+						parameterRef("partnerIDs"),
+						parameterRef("transactionID"),
+					},
+					RequestBody: jsonBody("The parameters to set", schemaRef("SetRequest")),
+					Responses:   deviceResponses(),
+				},
+			},
+			"/device/{deviceid}/{service}/{parameter}": {
+				"post": {
+					OperationID: "addRow",
+					Summary:     "Adds a row to a device table",
+					Description: "Sends an ADD_ROW to the device. The parameter is the table name.",
+					Tags:        []string{deviceTag},
+					Parameters:  tableParameters(),
+					RequestBody: jsonBody("The row to add", schemaRef("Row")),
+					Responses:   deviceResponses(),
+				},
+				"put": {
+					OperationID: "replaceRows",
+					Summary:     "Replaces the rows of a device table",
+					Description: "Sends a REPLACE_ROWS to the device. The parameter is the table name.",
+					Tags:        []string{deviceTag},
+					Parameters:  tableParameters(),
+					RequestBody: jsonBody("The rows of the table keyed by their index", schemaRef("IndexedRows")),
+					Responses:   deviceResponses(),
+				},
+				"delete": {
+					OperationID: "deleteRow",
+					Summary:     "Deletes a device table row",
+					Description: "Sends a DELETE_ROW to the device. The parameter is the row name.",
+					Tags:        []string{deviceTag},
+					Parameters:  tableParameters(),
+					Responses:   deviceResponses(),
+				},
+			},
+			"/device/{deviceid}/stat": {
+				"get": {
+					OperationID: "getStat",
+					Summary:     "Gets the statistics of a device",
+					Description: "Forwards the response of the XMiDT cluster.",
+					Tags:        []string{statTag},
+					Parameters: []*Parameter{
+						parameterRef("deviceID"),
+						{
+							Name: "allowStale", In: "query", Schema: &Schema{Type: "boolean"},
+							Description: "Serves the last known statistics when the device is not found or the XMiDT cluster fails. " +
+								"Only honored when the stat cache is configured.",
+						},
+						parameterRef("transactionID"),
+					},
+					Responses: map[int]*Response{
+						http.StatusOK: {
+							Description: "The statistics of the device",
+							Headers:     transactionIDHeaders(),
+							Content:     jsonContent(&Schema{Type: "object", Description: "The statistics as reported by the XMiDT cluster"}),
+						},
+						http.StatusBadRequest:          responseRef("BadRequest"),
+						http.StatusUnauthorized:        responseRef("Unauthorized"),
+						http.StatusForbidden:           responseRef("Forbidden"),
+						http.StatusNotFound:            {Description: "The device is not connected"},
+						http.StatusInternalServerError: responseRef("InternalError"),
+					},
+				},
+			},
+			"/device/{deviceid}/stat/watch": {
+				"get": {
+					OperationID: "watchPresence",
+					Summary:     "Streams the presence of a device",
+					Description: "Server-Sent Events stream with a presence event whenever the device connects or disconnects. " +
+						"Only served when the stat watch is configured.",
+					Tags: []string{statTag},
+					Parameters: []*Parameter{
+						parameterRef("deviceID"),
+						{Name: "interval", In: "query", Description: "Poll interval, i.e. 30s. It is bounded by the configured limits", Schema: stringSchema("duration")},
+					},
+					Responses: map[int]*Response{
+						http.StatusOK: {
+							Description: "The presence events",
+							Content:     map[string]MediaType{eventStreamMediaType: {Schema: schemaRef("Presence")}},
+						},
+						http.StatusBadRequest:         responseRef("BadRequest"),
+						http.StatusUnauthorized:       responseRef("Unauthorized"),
+						http.StatusForbidden:          responseRef("Forbidden"),
+						http.StatusServiceUnavailable: responseRef("Error"),
+					},
+				},
+			},
+			"/device/{deviceid}/console": {
+				"get": {
+					OperationID: "openConsole",
+					Summary:     "Opens a console session with a device",
+					Description: "WebSocket session authenticated once by this request. Text frames are JSON commands with a client-supplied id, " +
+						"a WDMP command, a service and the properties of the command, i.e. names for GET. " +
+						"Responses carry the id, tid, statusCode and the WDMP response of the device, or a problem details error. " +
+						"Only served when the console is configured.",
+					Tags:       []string{deviceTag},
+					Parameters: []*Parameter{parameterRef("deviceID"), parameterRef("partnerIDs")},
+					Responses: map[int]*Response{
+						http.StatusSwitchingProtocols: {Description: "The session is open"},
+						http.StatusBadRequest:         responseRef("BadRequest"),
+						http.StatusUnauthorized:       responseRef("Unauthorized"),
+						http.StatusForbidden:          responseRef("Forbidden"),
+					},
+				},
+			},
+			"/hook": {
+				"post": {
+					OperationID: "registerWebhook",
+					Summary:     "Registers a webhook",
+					Description: "Registrations of an existing config URL replace it.",
+					Tags:        []string{webhookTag},
+					Parameters:  []*Parameter{parameterRef("partnerIDs")},
+					RequestBody: jsonBody("The webhook", schemaRef("Webhook")),
+					Responses: map[int]*Response{
+						http.StatusOK:                  {Description: "The webhook is registered", Content: jsonContent(schemaRef("Message"))},
+						http.StatusBadRequest:          responseRef("BadRequest"),
+						http.StatusUnauthorized:        responseRef("Unauthorized"),
+						http.StatusForbidden:           responseRef("Forbidden"),
+						http.StatusInternalServerError: responseRef("InternalError"),
+					},
+				},
+			},
+			"/hooks": {
+				"get": {
+					OperationID: "listWebhooks",
+					Summary:     "Lists the webhooks of the caller",
+					Description: "Callers with the admin capability see all webhooks.",
+					Tags:        []string{webhookTag},
+					Parameters: []*Parameter{
+						{Name: "event", In: "query", Description: "Regular expression matched against the registered event patterns", Schema: stringSchema("")},
+						{Name: "url", In: "query", Description: "Substring of the config URL", Schema: stringSchema("")},
+						{Name: "expiresBefore", In: "query", Schema: stringSchema("date-time")},
+						{Name: "expiresAfter", In: "query", Schema: stringSchema("date-time")},
+						parameterRef("partnerIDs"),
+					},
+					Responses: map[int]*Response{
+						http.StatusOK: {
+							Description: "The webhooks",
+							Headers:     transactionIDHeaders(),
+							Content:     jsonContent(&Schema{Type: "array", Items: schemaRef("Registration")}),
+						},
+						http.StatusBadRequest:          responseRef("BadRequest"),
+						http.StatusUnauthorized:        responseRef("Unauthorized"),
+						http.StatusForbidden:           responseRef("Forbidden"),
+						http.StatusInternalServerError: responseRef("InternalError"),
+					},
+				},
+			},
+			"/hook/{id}": {
+				"get": {
+					OperationID: "getWebhook",
+					Summary:     "Gets a webhook",
+					Tags:        []string{webhookTag},
+					Parameters:  []*Parameter{parameterRef("webhookID"), parameterRef("partnerIDs")},
+					Responses:   registrationResponses(),
+				},
+				"put": {
+					OperationID: "updateWebhook",
+					Summary:     "Updates a webhook",
+					Description: "The config URL of the webhook must still match its ID.",
+					Tags:        []string{webhookTag},
+					Parameters:  []*Parameter{parameterRef("webhookID"), parameterRef("partnerIDs")},
+					RequestBody: jsonBody("The webhook", schemaRef("Webhook")),
+					Responses:   registrationResponses(),
+				},
+				"delete": {
+					OperationID: "removeWebhook",
+					Summary:     "Removes a webhook",
+					Tags:        []string{webhookTag},
+					Parameters:  []*Parameter{parameterRef("webhookID"), parameterRef("partnerIDs")},
+					Responses:   registrationResponses(),
+				},
+			},
+			"/hook/{id}/renew": {
+				"post": {
+					OperationID: "renewWebhook",
+					Summary:     "Extends a webhook for its duration",
+					Tags:        []string{webhookTag},
+					Parameters:  []*Parameter{parameterRef("webhookID"), parameterRef("partnerIDs")},
+					Responses:   registrationResponses(),
+				},
+			},
+			"/audit": {
+				"get": {
+					OperationID: "queryAudit",
+					Summary:     "Queries the audit trail",
+					Description: "Returns up to 1000 of the most recent records, oldest first. Requires the audit read capability. " +
+						"Callers without the audit admin capability only get their own records and the ones sharing one of their token partner IDs.",
+					Tags: []string{auditTag},
+					Parameters: []*Parameter{
+						{Name: "deviceID", In: "query", Schema: stringSchema("")},
+						{Name: "principal", In: "query", Schema: stringSchema("")},
+						{Name: "from", In: "query", Schema: stringSchema("date-time")},
+						{Name: "to", In: "query", Schema: stringSchema("date-time")},
+						{Name: "limit", In: "query", Description: "Keeps the most recent records only", Schema: &Schema{Type: "integer", Minimum: minimum(1)}},
+					},
+					Responses: map[int]*Response{
+						http.StatusOK: {
+							Description: "The records",
+							Headers:     transactionIDHeaders(),
+							Content:     jsonContent(&Schema{Type: "array", Items: schemaRef("AuditRecord")}),
+						},
+						http.StatusBadRequest:          responseRef("BadRequest"),
+						http.StatusUnauthorized:        responseRef("Unauthorized"),
+						http.StatusForbidden:           responseRef("Forbidden"),
+						http.StatusInternalServerError: responseRef("InternalError"),
+					},
+				},
+			},
+			"/schedules": {
+				"post": {
+					OperationID: "createSchedule",
+					Summary:     "Schedules an operation on devices",
+					Description: "The operation runs once at runAt or repeatedly on the cron schedule. It is checked against the supported services " +
+						"and the partner and parameter policies as it is scheduled. Operations are sent with the token of the auth acquirer.",
+					Tags:        []string{scheduleTag},
+					Parameters:  []*Parameter{parameterRef("partnerIDs")},
+					RequestBody: jsonBody("The schedule", schemaRef("ScheduleRequest")),
+					Responses: map[int]*Response{
+						http.StatusCreated: {
+							Description: "The schedule",
+							Headers:     transactionIDHeaders(),
+							Content:     jsonContent(schemaRef("Schedule")),
+						},
+						http.StatusBadRequest:          responseRef("BadRequest"),
+						http.StatusUnauthorized:        responseRef("Unauthorized"),
+						http.StatusForbidden:           responseRef("Forbidden"),
+						http.StatusInternalServerError: responseRef("InternalError"),
+					},
+				},
+				"get": {
+					OperationID: "listSchedules",
+					Summary:     "Lists the schedules of the caller",
+					Description: "Callers with the admin capability see all schedules. Schedules are listed oldest first.",
+					Tags:        []string{scheduleTag},
+					Parameters: []*Parameter{
+						{Name: "owner", In: "query", Description: "Principal of the schedules. Only applies to admins", Schema: stringSchema("")},
+						{Name: "deviceID", In: "query", Schema: stringSchema("")},
+						{Name: "status", In: "query", Schema: &Schema{Type: "string", Enum: []interface{}{"active", "completed", "canceled"}}},
+					},
+					Responses: map[int]*Response{
+						http.StatusOK: {
+							Description: "The schedules",
+							Headers:     transactionIDHeaders(),
+							Content:     jsonContent(&Schema{Type: "array", Items: schemaRef("Schedule")}),
+						},
+						http.StatusBadRequest:          responseRef("BadRequest"),
+						http.StatusUnauthorized:        responseRef("Unauthorized"),
+						http.StatusForbidden:           responseRef("Forbidden"),
+						http.StatusInternalServerError: responseRef("InternalError"),
+					},
+				},
+			},
+			"/schedules/{id}": {
+				"get": {
+					OperationID: "getSchedule",
+					Summary:     "Gets a schedule",
+					Tags:        []string{scheduleTag},
+					Parameters:  []*Parameter{parameterRef("scheduleID")},
+					Responses:   scheduleResponses(),
+				},
+				"delete": {
+					OperationID: "cancelSchedule",
+					Summary:     "Cancels a schedule",
+					Description: "Operations of a run in flight which were not sent yet are canceled too. Canceled schedules are kept along with their runs.",
+					Tags:        []string{scheduleTag},
+					Parameters:  []*Parameter{parameterRef("scheduleID")},
+					Responses:   cancelScheduleResponses(),
+				},
+			},
+			"/schedules/{id}/runs": {
+				"get": {
+					OperationID: "listScheduleRuns",
+					Summary:     "Lists the runs of a schedule",
+					Description: "Returns the most recent runs first.",
+					Tags:        []string{scheduleTag},
+					Parameters:  []*Parameter{parameterRef("scheduleID")},
+					Responses: map[int]*Response{
+						http.StatusOK: {
+							Description: "The runs",
+							Headers:     transactionIDHeaders(),
+							Content:     jsonContent(&Schema{Type: "array", Items: schemaRef("Run")}),
+						},
+						http.StatusUnauthorized:        responseRef("Unauthorized"),
+						http.StatusForbidden:           responseRef("Forbidden"),
+						http.StatusNotFound:            responseRef("NotFound"),
+						http.StatusInternalServerError: responseRef("InternalError"),
+					},
+				},
+			},
+			Path: {
+				"get": {
+					OperationID: "getOpenAPIDocument",
+					Summary:     "Gets this document",
+					Tags:        []string{docsTag},
+					Security:    &[]SecurityRequirement{},
+					Responses: map[int]*Response{
+						http.StatusOK: {Description: "The OpenAPI document", Content: jsonContent(&Schema{Type: "object"})},
+					},
+				},
+			},
+		},
+		Components: Components{
+			SecuritySchemes: map[string]*SecurityScheme{
+				basicAuthScheme:  {Type: "http", Scheme: "basic", Description: "Credentials of authHeader or basicAuth"},
+				bearerAuthScheme: {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "Tokens verified with the jwtValidator keys"},
+			},
+			Parameters: map[string]*Parameter{
+				"deviceID": {Name: "deviceid", In: "path", Required: true, Description: "The device ID, i.e. mac:112233445566", Schema: stringSchema("")},
+				"service":  {Name: "service", In: "path", Required: true, Description: "The device service. It must be one of the supported services", Schema: stringSchema("")},
+				"parameter": {
+					Name: "parameter", In: "path", Required: true, Schema: stringSchema(""),
+					Description: "The table name for additions and replacements or the row name for deletions",
+				},
+				"webhookID": {Name: "id", In: "path", Required: true, Description: "Hex encoded SHA256 checksum of the config URL", Schema: stringSchema("")},
+				"newCID":    {Name: translation.HeaderWPASyncNewCID, In: "header", Description: "The new CID of a TEST_AND_SET", Schema: stringSchema("")},
+				"oldCID":    {Name: translation.HeaderWPASyncOldCID, In: "header", Description: "The old CID of a TEST_AND_SET", Schema: stringSchema("")},
+				"syncCMC":   {Name: translation.HeaderWPASyncCMC, In: "header", Description: "The CMC of a TEST_AND_SET", Schema: stringSchema("")},
+				"partnerIDs": {
+					Name: wrphttp.PartnerIdHeader, In: "header", Schema: stringSchema(""),
+					Description: "Comma separated partner IDs. Whether they are used depends on the partner policy",
+				},
+				"scheduleID":    {Name: "id", In: "path", Required: true, Description: "The schedule ID", Schema: stringSchema("")},
+				"transactionID": {Name: common.HeaderWPATID, In: "header", Description: "Transaction ID. One is generated if missing", Schema: stringSchema("")},
+			},
+			Responses: map[string]*Response{
+				"BadRequest":    errorResponse("The request is invalid"),
+				"Unauthorized":  errorResponse("The credentials are missing or invalid"),
+				"Forbidden":     errorResponse("The caller may not access the device, parameters, partners, webhook or schedule"),
+				"NotFound":      errorResponse("The resource is not found"),
+				"InternalError": errorResponse("Tr1d1um failed to process the request"),
+				"Error":         errorResponse("The request failed"),
+			},
+			Schemas: map[string]*Schema{
+				"Error": {
+					Type:        "object",
+					Description: "The error of a request",
+					Properties:  map[string]*Schema{"message": stringSchema("")},
+					Required:    []string{"message"},
+				},
+				"Problem": {
+					Type:        "object",
+					Description: "The RFC 7807 problem details of a request error",
+					Properties: map[string]*Schema{
+						"type":   {Type: "string", Format: "uri", Example: common.ProblemTypePrefix + common.ErrorCodeInvalidDeviceID},
+						"title":  {Type: "string", Description: "A short summary of the kind of problem"},
+						"status": {Type: "integer", Description: "The HTTP status code of the response"},
+						"detail": {Type: "string", Description: "The error message"},
+						"code":   {Type: "string", Description: "A stable machine-readable error code", Example: common.ErrorCodeInvalidDeviceID},
+						"tid":    {Type: "string", Description: "The transaction ID of the request"},
+						"fields": {Type: "array", Items: stringSchema(""), Description: "The names of the offending request fields"},
+					},
+					Required: []string{"type", "title", "status", "detail", "code"},
+				},
+				"Message": {
+					Type:       "object",
+					Properties: map[string]*Schema{"message": stringSchema("")},
+				},
+				"SetRequest": {
+					Type:       "object",
+					Properties: map[string]*Schema{"parameters": {Type: "array", Items: schemaRef("SetParameter")}},
+					Required:   []string{"parameters"},
+				},
+				"SetParameter": {
+					Type:        "object",
+					Description: "A parameter to set. Parameters with attributes set their attributes instead of their value",
+					Properties: map[string]*Schema{
+						"name":       stringSchema(""),
+						"dataType":   {Type: "integer", Description: "The WDMP data type of the value, i.e. 0 for strings and 3 for booleans", Minimum: minimum(0)},
+						"value":      {Description: "The value to set"},
+						"attributes": {Type: "object", Description: "The attributes to set, i.e. notify"},
+					},
+					Required: []string{"name"},
+				},
+				"Row": {
+					Type:                 "object",
+					Description:          "Table row values keyed by their column name",
+					AdditionalProperties: stringSchema(""),
+				},
+				"IndexedRows": {
+					Type:                 "object",
+					Description:          "Table rows keyed by their index",
+					AdditionalProperties: schemaRef("Row"),
+				},
+				"DeviceResponse": {
+					Type:        "object",
+					Description: "The WDMP response of the device. Its statusCode is used as the response code",
+					Properties: map[string]*Schema{
+						"statusCode": {Type: "integer"},
+						"message":    stringSchema(""),
+						"parameters": {Type: "array", Items: &Schema{Type: "object"}},
+					},
+				},
+				"Webhook": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"registered_from_address": stringSchema(""),
+						"config": {
+							Type: "object",
+							Properties: map[string]*Schema{
+								"url":          stringSchema("uri"),
+								"content_type": stringSchema(""),
+								"secret":       stringSchema(""),
+								"alt_urls":     {Type: "array", Items: stringSchema("uri")},
+							},
+							Required: []string{"url"},
+						},
+						"failure_url": stringSchema("uri"),
+						"events":      {Type: "array", Items: stringSchema(""), Description: "Regular expressions matched against event types"},
+						"matcher": {
+							Type:       "object",
+							Properties: map[string]*Schema{"device_id": {Type: "array", Items: stringSchema("")}},
+						},
+						"duration": {Type: "integer"},
+						"until":    stringSchema("date-time"),
+					},
+					Required: []string{"config", "events"},
+				},
+				"Registration": {
+					AllOf: []*Schema{
+						schemaRef("Webhook"),
+						{
+							Type: "object",
+							Properties: map[string]*Schema{
+								"id":          stringSchema(""),
+								"owner":       stringSchema(""),
+								"partner_ids": {Type: "array", Items: stringSchema("")},
+								"expires_in":  {Type: "integer", Description: "Seconds left before the webhook expires"},
+							},
+						},
+					},
+				},
+				"Presence": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"deviceID":    stringSchema(""),
+						"state":       {Type: "string", Enum: []interface{}{"online", "offline", "unknown"}},
+						"code":        {Type: "integer"},
+						"connectedAt": stringSchema(""),
+						"observedAt":  stringSchema("date-time"),
+					},
+				},
+				"AuditRecord": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"time":        stringSchema("date-time"),
+						"tid":         stringSchema(""),
+						"satClientID": stringSchema(""),
+						"partnerIDs":  {Type: "array", Items: stringSchema("")},
+						"deviceID":    stringSchema(""),
+						"operation":   stringSchema(""),
+						"parameters": {
+							Type: "array",
+							Items: &Schema{
+								Type:       "object",
+								Properties: map[string]*Schema{"name": stringSchema(""), "value": {}},
+							},
+						},
+						"target": stringSchema(""),
+						"code":   {Type: "integer"},
+					},
+				},
+				"Operation": {
+					Type:        "object",
+					Description: "A WDMP command. Its properties follow the WDMP ones",
+					Properties: map[string]*Schema{
+						"command": {
+							Type: "string",
+							Enum: []interface{}{"GET", "GET_ATTRIBUTES", "SET", "SET_ATTRIBUTES", "TEST_AND_SET", "ADD_ROW", "REPLACE_ROWS", "DELETE_ROW"},
+						},
+						"service":    stringSchema(""),
+						"names":      {Type: "array", Items: stringSchema(""), Description: "GET and GET_ATTRIBUTES"},
+						"attributes": stringSchema(""),
+						"parameters": {Type: "array", Items: &Schema{Type: "object"}, Description: "SET, SET_ATTRIBUTES and TEST_AND_SET"},
+						"new-cid":    stringSchema(""),
+						"old-cid":    stringSchema(""),
+						"sync-cmc":   stringSchema(""),
+						"table":      {Type: "string", Description: "ADD_ROW and REPLACE_ROWS"},
+						"row":        {Description: "The row object of ADD_ROW or the row name of DELETE_ROW"},
+						"rows":       {Type: "object", Description: "The rows of REPLACE_ROWS by index"},
+					},
+					Required: []string{"command", "service"},
+				},
+				"ScheduleRequest": {
+					Type:        "object",
+					Description: "Exactly one of runAt and cron is required",
+					Properties: map[string]*Schema{
+						"deviceIDs": {Type: "array", Items: stringSchema(""), Description: "Bounded by the configured maximum"},
+						"operation": schemaRef("Operation"),
+						"runAt":     stringSchema("date-time"),
+						"cron":      {Type: "string", Description: "5-field cron expression (minute hour day-of-month month day-of-week) or @yearly, @monthly, @weekly, @daily or @hourly"},
+						"timeZone":  {Type: "string", Description: "IANA time zone of the cron expression. Defaults to UTC"},
+					},
+					Required: []string{"deviceIDs", "operation"},
+				},
+				"Schedule": {
+					AllOf: []*Schema{
+						schemaRef("ScheduleRequest"),
+						{
+							Type: "object",
+							Properties: map[string]*Schema{
+								"id":         stringSchema(""),
+								"owner":      stringSchema(""),
+								"partnerIDs": {Type: "array", Items: stringSchema("")},
+								"allowedDevices": {
+									Type:        "array",
+									Items:       stringSchema(""),
+									Description: "The enforced allowed devices claim of the token of the owner. Operations are only sent to the devices it allows",
+								},
+								"status":    {Type: "string", Enum: []interface{}{"active", "completed", "canceled"}},
+								"createdAt": stringSchema("date-time"),
+								"nextRun":   stringSchema("date-time"),
+								"endedAt":   stringSchema("date-time"),
+							},
+						},
+					},
+				},
+				"Run": {
+					Type: "object",
+					Properties: map[string]*Schema{
+						"scheduleID":  stringSchema(""),
+						"scheduledAt": stringSchema("date-time"),
+						"startedAt":   stringSchema("date-time"),
+						"finishedAt":  stringSchema("date-time"),
+						"skipped":     {Type: "string", Enum: []interface{}{"misfire", "overlap"}, Description: "Why the run was skipped, if it was"},
+						"results": {
+							Type: "array",
+							Items: &Schema{
+								Type: "object",
+								Properties: map[string]*Schema{
+									"deviceID":   stringSchema(""),
+									"tid":        stringSchema(""),
+									"statusCode": {Type: "integer"},
+									"response":   schemaRef("DeviceResponse"),
+									"error":      schemaRef("Problem"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func methodKey(method string) string {
+	return strings.ToLower(method)
+}
+
+func tableParameters() []*Parameter {
+	return []*Parameter{
+		parameterRef("deviceID"),
+		parameterRef("service"),
+		parameterRef("parameter"),
+		parameterRef("partnerIDs"),
+		parameterRef("transactionID"),
+	}
+}
+
+func deviceResponses() map[int]*Response {
+	return map[int]*Response{
+		http.StatusOK: {
+			Description: "The device response. Its status code is the one the device reported",
+			Headers:     transactionIDHeaders(),
+			Content:     jsonContent(schemaRef("DeviceResponse")),
+		},
+		http.StatusBadRequest:          responseRef("BadRequest"),
+		http.StatusUnauthorized:        responseRef("Unauthorized"),
+		http.StatusForbidden:           responseRef("Forbidden"),
+		http.StatusNotFound:            {Description: "The device is not connected"},
+		http.StatusInternalServerError: responseRef("InternalError"),
+		http.StatusServiceUnavailable:  {Description: "The XMiDT cluster is unavailable"},
+		http.StatusGatewayTimeout:      {Description: "The device did not respond in time"},
+	}
+}
+
+func registrationResponses() map[int]*Response {
+	return map[int]*Response{
+		http.StatusOK: {
+			Description: "The webhook",
+			Headers:     transactionIDHeaders(),
+			Content:     jsonContent(schemaRef("Registration")),
+		},
+		http.StatusBadRequest:          responseRef("BadRequest"),
+		http.StatusUnauthorized:        responseRef("Unauthorized"),
+		http.StatusForbidden:           responseRef("Forbidden"),
+		http.StatusNotFound:            responseRef("NotFound"),
+		http.StatusInternalServerError: responseRef("InternalError"),
+	}
+}
+
+func scheduleResponses() map[int]*Response {
+	return map[int]*Response{
+		http.StatusOK: {
+			Description: "The schedule",
+			Headers:     transactionIDHeaders(),
+			Content:     jsonContent(schemaRef("Schedule")),
+		},
+		http.StatusUnauthorized:        responseRef("Unauthorized"),
+		http.StatusForbidden:           responseRef("Forbidden"),
+		http.StatusNotFound:            responseRef("NotFound"),
+		http.StatusInternalServerError: responseRef("InternalError"),
+	}
+}
+
+func cancelScheduleResponses() map[int]*Response {
+	responses := scheduleResponses()
+	responses[http.StatusConflict] = errorResponse("The schedule is already completed or canceled")
+	return responses
+}
+
+func transactionIDHeaders() map[string]*Header {
+	return map[string]*Header{common.HeaderWPATID: {Description: "The transaction ID", Schema: stringSchema("")}}
+}
+
+// errorResponse describes the error bodies. Callers accepting problem details get them
+// instead of the legacy error body.
+func errorResponse(description string) *Response {
+	return &Response{
+		Description: description,
+		Headers:     transactionIDHeaders(),
+		Content: map[string]MediaType{
+			jsonMediaType:             {Schema: schemaRef("Error")},
+			common.ProblemContentType: {Schema: schemaRef("Problem")},
+		},
+	}
+}
+
+func jsonBody(description string, schema *Schema) *RequestBody {
+	return &RequestBody{Description: description, Required: true, Content: jsonContent(schema)}
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{jsonMediaType: {Schema: schema}}
+}
+
+func stringSchema(format string) *Schema {
+	return &Schema{Type: "string", Format: format}
+}
+
+func minimum(m float64) *float64 {
+	return &m
+}
+
+func schemaRef(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func parameterRef(name string) *Parameter {
+	return &Parameter{Ref: "#/components/parameters/" + name}
+}
+
+func responseRef(name string) *Response {
+	return &Response{Ref: "#/components/responses/" + name}
+}
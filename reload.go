@@ -0,0 +1,267 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-kit/kit/log"
+	"github.com/justinas/alice"
+	"github.com/spf13/cast"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/webpa-common/logging"
+	"github.com/xmidt-org/webpa-common/xhttp"
+	"github.com/xmidt-org/webpa-common/xmetrics"
+)
+
+var errNegativeValue = errors.New("must not be negative")
+
+// runtimeKeys are the config keys of the common.RuntimeOptions.
+var runtimeKeys = []string{
+	translationServicesKey,
+	reducedTransactionLoggingCodesKey,
+	reqMaxRetriesKey,
+	reqRetryIntervalKey,
+}
+
+// authKeys are the config keys the auth chain is built from.
+var authKeys = []string{
+	"authHeader",
+	"basicAuth",
+	"jwtValidator",
+	"capabilityCheck",
+	"deviceAccessCheck",
+}
+
+// runtimeOptions reads the runtime options, rejecting values of the wrong type
+// which viper would otherwise silently turn into zero values.
+func runtimeOptions(v *viper.Viper) (o common.RuntimeOptions, err error) {
+	if o.ValidServices, err = cast.ToStringSliceE(v.Get(translationServicesKey)); err != nil {
+		return o, invalidValue(translationServicesKey, err)
+	}
+
+	if v.IsSet(reducedTransactionLoggingCodesKey) {
+		if o.ReducedLoggingResponseCodes, err = cast.ToIntSliceE(v.Get(reducedTransactionLoggingCodesKey)); err != nil {
+			return o, invalidValue(reducedTransactionLoggingCodesKey, err)
+		}
+	}
+
+	if o.Retries, err = cast.ToIntE(v.Get(reqMaxRetriesKey)); err != nil || o.Retries < 0 {
+		return o, invalidValue(reqMaxRetriesKey, err)
+	}
+
+	if o.RetryInterval, err = cast.ToDurationE(v.Get(reqRetryIntervalKey)); err != nil || o.RetryInterval < 0 {
+		return o, invalidValue(reqRetryIntervalKey, err)
+	}
+	return o, nil
+}
+
+// invalidValue describes why the value of a config key is invalid. A nil err stands for a negative value.
+func invalidValue(key string, err error) error {
+	if err == nil {
+		err = errNegativeValue
+	}
+	return fmt.Errorf("invalid %s: %w", key, err)
+}
+
+// reloadableAuth is an auth chain which can be swapped at runtime.
+type reloadableAuth struct {
+	current atomic.Value // *authSnapshot
+
+	lock sync.Mutex
+	done chan struct{}
+}
+
+type authSnapshot struct {
+	chain      *alice.Chain
+	generation uint64
+}
+
+func newReloadableAuth(chain *alice.Chain, done chan struct{}) *reloadableAuth {
+	a := &reloadableAuth{done: done}
+	a.current.Store(&authSnapshot{chain: chain})
+	return a
+}
+
+// swap replaces the chain and stops the background work of the previous one.
+func (a *reloadableAuth) swap(chain *alice.Chain, done chan struct{}) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	previous := a.current.Load().(*authSnapshot)
+	a.current.Store(&authSnapshot{chain: chain, generation: previous.generation + 1})
+
+	if a.done != nil {
+		close(a.done)
+	}
+	a.done = done
+}
+
+// constructor decorates handlers with the current chain. The decorated handler is
+// cached until the chain is swapped.
+func (a *reloadableAuth) constructor(next http.Handler) http.Handler {
+	type decorated struct {
+		handler    http.Handler
+		generation uint64
+	}
+
+	var cached atomic.Value
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := a.current.Load().(*authSnapshot)
+		d, ok := cached.Load().(*decorated)
+		if !ok || d.generation != current.generation {
+			d = &decorated{handler: current.chain.Then(next), generation: current.generation}
+			cached.Store(d)
+		}
+		d.handler.ServeHTTP(w, r)
+	})
+}
+
+// reloader swaps the runtime options and the auth chain whenever the config file changes.
+// Reloads leaving them invalid are rejected and the previous ones are kept. Other config
+// changes require a restart.
+type reloader struct {
+	configFile string
+	flags      *pflag.FlagSet
+	logger     log.Logger
+	registry   xmetrics.Registry
+	runtime    *common.Runtime
+	auth       *reloadableAuth
+
+	lock   sync.Mutex
+	values map[string]interface{}
+}
+
+func newReloader(v *viper.Viper, flags *pflag.FlagSet, logger log.Logger, registry xmetrics.Registry, runtime *common.Runtime, auth *reloadableAuth) *reloader {
+	return &reloader{
+		configFile: v.ConfigFileUsed(),
+		flags:      flags,
+		logger:     logger,
+		registry:   registry,
+		runtime:    runtime,
+		auth:       auth,
+		values:     reloadableValues(v),
+	}
+}
+
+func reloadableValues(v *viper.Viper) map[string]interface{} {
+	values := make(map[string]interface{}, len(runtimeKeys)+len(authKeys))
+	for _, k := range runtimeKeys {
+		values[k] = v.Get(k)
+	}
+	for _, k := range authKeys {
+		values[k] = v.Get(k)
+	}
+	return values
+}
+
+func changedKeys(keys []string, previous, current map[string]interface{}) (changed []string) {
+	for _, k := range keys {
+		if !reflect.DeepEqual(previous[k], current[k]) {
+			changed = append(changed, k)
+		}
+	}
+	return
+}
+
+// readConfig reads the config file into a new viper with the same defaults, environment
+// overrides and command-line flags as the one tr1d1um started with. The startup viper is
+// left alone as it is not safe for concurrent use.
+func (r *reloader) readConfig() (*viper.Viper, error) {
+	v := viper.New()
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.SetEnvPrefix(applicationName)
+	v.AutomaticEnv()
+	for k, va := range defaults {
+		v.SetDefault(k, va)
+	}
+
+	if r.flags != nil {
+		if err := v.BindPFlags(r.flags); err != nil {
+			return nil, err
+		}
+	}
+
+	v.SetConfigFile(r.configFile)
+	return v, v.ReadInConfig()
+}
+
+// reload reads the config file and swaps the runtime options and, if its config changed,
+// the auth chain. It returns the keys that changed.
+func (r *reloader) reload() ([]string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	v, err := r.readConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	values := reloadableValues(v)
+	changedAuth := changedKeys(authKeys, r.values, values)
+	changed := append(changedKeys(runtimeKeys, r.values, values), changedAuth...)
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	options, err := runtimeOptions(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changedAuth) > 0 {
+		done := make(chan struct{})
+		chain, err := authenticationHandler(v, r.logger, r.registry, done)
+		if err != nil {
+			close(done)
+			return nil, err
+		}
+
+		r.auth.swap(chain, done)
+	}
+
+	r.runtime.Store(options)
+	r.values = values
+	return changed, nil
+}
+
+// reloadAndLog reloads the config and logs the outcome.
+func (r *reloader) reloadAndLog(trigger string) {
+	changed, err := r.reload()
+	switch {
+	case err != nil:
+		logging.Error(r.logger).Log(logging.MessageKey(), "Rejected config reload, keeping the previous config",
+			"trigger", trigger, logging.ErrorKey(), err)
+	case len(changed) == 0:
+		logging.Info(r.logger).Log(logging.MessageKey(), "Config reloaded without runtime changes", "trigger", trigger)
+	default:
+		logging.Info(r.logger).Log(logging.MessageKey(), "Reloaded runtime config", "trigger", trigger, "changed", changed)
+	}
+}
+
+// watch reloads the config whenever viper notices the config file changed.
+func (r *reloader) watch(v *viper.Viper) {
+	v.OnConfigChange(func(fsnotify.Event) {
+		r.reloadAndLog("file")
+	})
+	v.WatchConfig()
+}
+
+// retryTransactor is xhttp.RetryTransactor with the retry settings of the current runtime options.
+func retryTransactor(runtime *common.Runtime, logger log.Logger, do func(*http.Request) (*http.Response, error)) func(*http.Request) (*http.Response, error) {
+	return func(r *http.Request) (*http.Response, error) {
+		o := runtime.Load()
+		return xhttp.RetryTransactor(xhttp.RetryOptions{
+			Logger:   logger,
+			Retries:  o.Retries,
+			Interval: o.RetryInterval,
+		}, common.TraceAttempts(do))(r)
+	}
+}
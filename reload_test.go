@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/justinas/alice"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/webpa-common/basculechecks"
+	"github.com/xmidt-org/webpa-common/basculemetrics"
+	"github.com/xmidt-org/webpa-common/xmetrics"
+)
+
+func TestRuntimeOptions(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Values      map[string]interface{}
+		Expected    common.RuntimeOptions
+		ExpectedErr bool
+	}{
+		{
+			Name:     "Defaults",
+			Expected: common.RuntimeOptions{ValidServices: []string{}, Retries: 2, RetryInterval: 2 * time.Second},
+		},
+		{
+			Name: "Set",
+			Values: map[string]interface{}{
+				translationServicesKey:            []string{"config"},
+				reducedTransactionLoggingCodesKey: []interface{}{200, 504},
+				reqMaxRetriesKey:                  "0",
+				reqRetryIntervalKey:               "1s",
+			},
+			Expected: common.RuntimeOptions{ValidServices: []string{"config"}, ReducedLoggingResponseCodes: []int{200, 504}, RetryInterval: time.Second},
+		},
+		{Name: "Bad retries", Values: map[string]interface{}{reqMaxRetriesKey: "many"}, ExpectedErr: true},
+		{Name: "Negative retries", Values: map[string]interface{}{reqMaxRetriesKey: -1}, ExpectedErr: true},
+		{Name: "Bad interval", Values: map[string]interface{}{reqRetryIntervalKey: "soon"}, ExpectedErr: true},
+		{Name: "Bad codes", Values: map[string]interface{}{reducedTransactionLoggingCodesKey: []string{"ok"}}, ExpectedErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			v := viper.New()
+			for k, va := range defaults {
+				v.SetDefault(k, va)
+			}
+			for k, va := range tc.Values {
+				v.Set(k, va)
+			}
+
+			o, err := runtimeOptions(v)
+			if tc.ExpectedErr {
+				assert.NotNil(err)
+				return
+			}
+			assert.Nil(err)
+			assert.Equal(tc.Expected, o)
+		})
+	}
+}
+
+func TestReloadableAuth(t *testing.T) {
+	assert := assert.New(t)
+
+	chain := func(header string) *alice.Chain {
+		c := alice.New(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Chain", header)
+				next.ServeHTTP(w, r)
+			})
+		})
+		return &c
+	}
+
+	firstDone := make(chan struct{})
+	a := newReloadableAuth(chain("first"), firstDone)
+	handler := alice.New(a.constructor).ThenFunc(func(http.ResponseWriter, *http.Request) {})
+
+	serve := func() string {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		return recorder.Header().Get("X-Chain")
+	}
+
+	assert.Equal("first", serve())
+	assert.Equal("first", serve())
+
+	a.swap(chain("second"), make(chan struct{}))
+	assert.Equal("second", serve())
+
+	select {
+	case <-firstDone:
+	default:
+		assert.Fail("the previous chain was not stopped")
+	}
+}
+
+func TestReloader(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "reload")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "tr1d1um.yaml")
+	writeConfig := func(config string) {
+		require.NoError(ioutil.WriteFile(file, []byte(config), 0600))
+	}
+
+	writeConfig("supportedServices: [config]\nauthHeader: [" + base64.StdEncoding.EncodeToString([]byte("user:first")) + "]\n")
+
+	v := viper.New()
+	for k, va := range defaults {
+		v.SetDefault(k, va)
+	}
+	v.SetConfigFile(file)
+	require.NoError(v.ReadInConfig())
+
+	registry, err := xmetrics.NewRegistry(nil, basculechecks.Metrics, basculemetrics.Metrics)
+	require.NoError(err)
+
+	initial, err := runtimeOptions(v)
+	require.NoError(err)
+	runtime := common.NewRuntime(initial)
+
+	done := make(chan struct{})
+	chain, err := authenticationHandler(v, log.NewNopLogger(), registry, done)
+	require.NoError(err)
+	auth := newReloadableAuth(chain, done)
+	handler := alice.New(auth.constructor).ThenFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	status := func(password string) int {
+		r := httptest.NewRequest(http.MethodGet, "/api/v2/device/mac:112233445566/stat", nil)
+		r.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("user:"+password)))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, r)
+		return recorder.Code
+	}
+
+	r := newReloader(v, nil, log.NewNopLogger(), registry, runtime, auth)
+
+	changed, err := r.reload()
+	assert.Nil(err)
+	assert.Empty(changed)
+
+	// runtime options and credentials are swapped
+	writeConfig("supportedServices: [config, stat]\nauthHeader: [" + base64.StdEncoding.EncodeToString([]byte("user:second")) + "]\nWRPSource: changed\n")
+	changed, err = r.reload()
+	assert.Nil(err)
+	assert.Equal([]string{translationServicesKey, "authHeader"}, changed)
+	assert.Equal([]string{"config", "stat"}, runtime.Load().ValidServices)
+	assert.Equal(http.StatusOK, status("second"))
+	assert.NotEqual(http.StatusOK, status("first"))
+
+	// invalid reloads keep the previous config
+	writeConfig("supportedServices: [config]\nrequestMaxRetries: -1\n")
+	_, err = r.reload()
+	assert.NotNil(err)
+	assert.Equal([]string{"config", "stat"}, runtime.Load().ValidServices)
+	assert.Equal(http.StatusOK, status("second"))
+
+	writeConfig("supportedServices: [")
+	_, err = r.reload()
+	assert.NotNil(err)
+	assert.Equal([]string{"config", "stat"}, runtime.Load().ValidServices)
+}
+
+func TestReloaderKeepsFlags(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "reload")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "tr1d1um.yaml")
+	require.NoError(ioutil.WriteFile(file, []byte("supportedServices: [config]\nrequestMaxRetries: 1\n"), 0600))
+
+	f := pflag.NewFlagSet(applicationName, pflag.ContinueOnError)
+	f.Int(reqMaxRetriesKey, 0, "")
+	require.NoError(f.Parse([]string{"--" + reqMaxRetriesKey + "=5"}))
+
+	v := viper.New()
+	for k, va := range defaults {
+		v.SetDefault(k, va)
+	}
+	require.NoError(v.BindPFlags(f))
+	v.SetConfigFile(file)
+	require.NoError(v.ReadInConfig())
+
+	initial, err := runtimeOptions(v)
+	require.NoError(err)
+	require.Equal(5, initial.Retries)
+	runtime := common.NewRuntime(initial)
+
+	r := newReloader(v, f, log.NewNopLogger(), nil, runtime, newReloadableAuth(&alice.Chain{}, nil))
+	require.NoError(ioutil.WriteFile(file, []byte("supportedServices: [config, stat]\nrequestMaxRetries: 1\n"), 0600))
+
+	// values given as flags still override the file after a reload
+	changed, err := r.reload()
+	assert.Nil(err)
+	assert.Equal([]string{translationServicesKey}, changed)
+	assert.Equal(5, runtime.Load().Retries)
+}
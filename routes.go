@@ -0,0 +1,58 @@
+package main
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/xmidt-org/tr1d1um/audit"
+	"github.com/xmidt-org/tr1d1um/openapi"
+	"github.com/xmidt-org/tr1d1um/schedule"
+	"github.com/xmidt-org/tr1d1um/stat"
+	"github.com/xmidt-org/tr1d1um/translation"
+	"github.com/xmidt-org/tr1d1um/webhook"
+)
+
+// apiRoutes holds the options of the API handlers tr1d1um serves. The routes of the
+// handlers whose options are nil are not registered.
+type apiRoutes struct {
+	audit       *audit.Options
+	webhook     *webhook.Options
+	stat        *stat.Options
+	translation *translation.Options
+	scheduler   *schedule.Scheduler
+	schedule    *schedule.Options
+	openapi     *openapi.Options
+}
+
+// register sets up the API routes on router, which is assumed to be a subrouter with
+// the API prefix path (i.e. 'api/v2').
+func (r apiRoutes) register(router *mux.Router) {
+	if r.audit != nil {
+		r.audit.APIRouter = router
+		audit.ConfigHandler(r.audit)
+	}
+
+	if r.webhook != nil {
+		r.webhook.APIRouter = router
+		webhook.ConfigHandler(r.webhook)
+	}
+
+	// Must be called before translation.ConfigHandler due to mux path specificity (https://github.com/gorilla/mux#matching-routes).
+	if r.stat != nil {
+		r.stat.APIRouter = router
+		stat.ConfigHandler(r.stat)
+	}
+
+	if r.translation != nil {
+		r.translation.APIRouter = router
+		translation.ConfigHandler(r.translation)
+	}
+
+	if r.scheduler != nil {
+		r.schedule.APIRouter = router
+		schedule.ConfigHandler(r.scheduler, r.schedule)
+	}
+
+	if r.openapi != nil {
+		r.openapi.APIRouter = router
+		openapi.ConfigHandler(r.openapi)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/tr1d1um/audit"
+	"github.com/xmidt-org/tr1d1um/openapi"
+	"github.com/xmidt-org/tr1d1um/schedule"
+	"github.com/xmidt-org/tr1d1um/stat"
+	"github.com/xmidt-org/tr1d1um/translation"
+	"github.com/xmidt-org/tr1d1um/webhook"
+)
+
+// newAPIRoutes returns the API routes with every optional route enabled.
+func newAPIRoutes() apiRoutes {
+	var (
+		chain        = alice.New()
+		authenticate = &chain
+		logger       = log.NewNopLogger()
+	)
+
+	scheduleOptions := &schedule.Options{Authenticate: authenticate, Log: logger}
+	return apiRoutes{
+		audit:   &audit.Options{Authenticate: authenticate, Log: logger},
+		webhook: &webhook.Options{Authenticate: authenticate, Log: logger},
+		stat: &stat.Options{
+			Authenticate: authenticate,
+			Log:          logger,
+			Watch:        new(stat.WatchOptions),
+			Cache:        new(stat.CacheOptions),
+		},
+		translation: &translation.Options{
+			Authenticate: authenticate,
+			Log:          logger,
+			Console:      new(translation.ConsoleOptions),
+		},
+		scheduler: schedule.NewScheduler(schedule.Config{}, scheduleOptions),
+		schedule:  scheduleOptions,
+		openapi:   &openapi.Options{Log: logger, Version: "test"},
+	}
+}
+
+func TestAPIRoutesDocumented(t *testing.T) {
+	var (
+		assert     = assert.New(t)
+		document   = openapi.NewDocument("test")
+		rootRouter = mux.NewRouter()
+		apiPrefix  = "/" + apiBase
+		routes     int
+	)
+
+	newAPIRoutes().register(rootRouter.PathPrefix(apiPrefix + "/").Subrouter())
+
+	err := rootRouter.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			// subrouters don't have methods
+			return nil
+		}
+
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+
+		path := strings.TrimPrefix(template, apiPrefix)
+		for _, method := range methods {
+			routes++
+			o, ok := document.Operation(path, method)
+			if assert.True(ok, "%s %s is missing from the OpenAPI document", method, path) {
+				assert.NotEmpty(o.Responses, "%s %s has no responses", method, path)
+			}
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.NotZero(routes)
+}
@@ -0,0 +1,110 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/justinas/alice"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/rpc/tr1d1umpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const apiBase = "/api/v2"
+
+var (
+	errUnauthenticated = errors.New("request could not be authenticated")
+	errUnauthorized    = errors.New("request is not authorized")
+	errUnknownRequest  = errors.New("request has no HTTP counterpart")
+)
+
+// httpRoute returns the method and path of the HTTP request an RPC request mirrors.
+func httpRoute(request interface{}) (string, string, error) {
+	switch r := request.(type) {
+	case *tr1d1umpb.GetRequest:
+		return http.MethodGet, devicePath(r.DeviceId, r.Service), nil
+	case *tr1d1umpb.SetRequest:
+		return http.MethodPatch, devicePath(r.DeviceId, r.Service), nil
+	case *tr1d1umpb.TestAndSetRequest:
+		return http.MethodPatch, devicePath(r.DeviceId, r.Service), nil
+	case *tr1d1umpb.AddRowRequest:
+		return http.MethodPost, devicePath(r.DeviceId, r.Service, r.Table), nil
+	case *tr1d1umpb.ReplaceRowsRequest:
+		return http.MethodPut, devicePath(r.DeviceId, r.Service, r.Table), nil
+	case *tr1d1umpb.DeleteRowRequest:
+		return http.MethodDelete, devicePath(r.DeviceId, r.Service, r.Row), nil
+	case *tr1d1umpb.StatRequest:
+		return http.MethodGet, devicePath(r.DeviceId, "stat"), nil
+	default:
+		return "", "", errUnknownRequest
+	}
+}
+
+func devicePath(deviceID string, segments ...string) string {
+	path := fmt.Sprintf("%s/device/%s", apiBase, url.PathEscape(deviceID))
+	for _, s := range segments {
+		path += "/" + url.PathEscape(s)
+	}
+	return path
+}
+
+// statusRecorder keeps the status code the auth chain responds with when it rejects a request.
+type statusRecorder struct {
+	header http.Header
+	code   int
+}
+
+func (r *statusRecorder) Header() http.Header         { return r.header }
+func (r *statusRecorder) Write(b []byte) (int, error) { return len(b), nil }
+func (r *statusRecorder) WriteHeader(code int)        { r.code = code }
+
+// authenticate returns the interceptor running RPCs through the auth chain of the HTTP API.
+// Each RPC request is presented to the chain as the HTTP request it mirrors, with the
+// request metadata as headers. Authorized RPCs run with the context the chain hands over,
+// which carries the bascule authentication.
+func authenticate(chain *alice.Chain) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if chain == nil {
+			return handler(ctx, request)
+		}
+
+		method, path, err := httpRoute(request)
+		if err != nil {
+			return nil, common.GRPCError(ctx, common.NewCodedError(err, http.StatusNotImplemented))
+		}
+
+		r, err := http.NewRequestWithContext(ctx, method, path, nil)
+		if err != nil {
+			return nil, common.GRPCError(ctx, common.NewCodedError(err, http.StatusBadRequest))
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		r.Header = common.GRPCHeader(md)
+		if p, ok := peer.FromContext(ctx); ok {
+			r.RemoteAddr = p.Addr.String()
+		}
+
+		var (
+			authorized context.Context
+			recorder   = &statusRecorder{header: make(http.Header)}
+		)
+
+		chain.Then(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			authorized = r.Context()
+		})).ServeHTTP(recorder, r)
+
+		if authorized != nil {
+			return handler(authorized, request)
+		}
+
+		if recorder.code == http.StatusForbidden {
+			return nil, common.GRPCError(ctx, common.NewCodedError(errUnauthorized, http.StatusForbidden))
+		}
+		return nil, common.GRPCError(ctx, common.NewCodedError(errUnauthenticated, http.StatusUnauthorized))
+	}
+}
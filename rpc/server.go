@@ -0,0 +1,122 @@
+// Package rpc serves the gRPC API of tr1d1um. Its RPCs share the services, auth rules and
+// error codes of the HTTP API.
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+
+	kitgrpc "github.com/go-kit/kit/transport/grpc"
+	"github.com/justinas/alice"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/rpc/tr1d1umpb"
+	"github.com/xmidt-org/tr1d1um/translation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+var errIncompleteTLS = errors.New("both certificateFile and keyFile are needed for TLS")
+
+// Config is the configuration of the gRPC listener.
+type Config struct {
+	// Address is the address to listen on (i.e. ":6400").
+	Address string
+
+	// CertificateFile and KeyFile enable TLS when set.
+	// (Optional)
+	CertificateFile string
+	KeyFile         string
+}
+
+// Options wraps the properties needed to set up the gRPC server.
+type Options struct {
+	Translation *translation.GRPCHandlers
+	Stat        kitgrpc.Handler
+
+	// Authenticate is the auth chain of the HTTP API. RPCs go through it as the HTTP
+	// requests they mirror.
+	Authenticate *alice.Chain
+
+	// Drainer tracks the RPCs that must complete before shutting down and rejects the
+	// ones arriving while draining.
+	// (Optional)
+	Drainer *common.Drainer
+}
+
+// NewServer builds the gRPC server of the Device service. It's up to the caller to serve it.
+func NewServer(c Config, o Options) (*grpc.Server, error) {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(o.Drainer.TrackUnary, authenticate(o.Authenticate)),
+	}
+
+	if c.CertificateFile != "" || c.KeyFile != "" {
+		if c.CertificateFile == "" || c.KeyFile == "" {
+			return nil, errIncompleteTLS
+		}
+
+		certificate, err := tls.LoadX509KeyPair(c.CertificateFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewServerTLSFromCert(&certificate)))
+	}
+
+	s := grpc.NewServer(opts...)
+	tr1d1umpb.RegisterDeviceServer(s, &server{o: o})
+	return s, nil
+}
+
+// server adapts the go-kit handlers to the generated service interface.
+type server struct {
+	tr1d1umpb.UnimplementedDeviceServer
+	o Options
+}
+
+// serve runs h and turns its errors into the ones API consumers get.
+func serve(ctx context.Context, h kitgrpc.Handler, request interface{}) (interface{}, error) {
+	retctx, response, err := h.ServeGRPC(ctx, request)
+	if err != nil {
+		return nil, common.GRPCError(retctx, err)
+	}
+	return response, nil
+}
+
+func (s *server) Get(ctx context.Context, r *tr1d1umpb.GetRequest) (*tr1d1umpb.DeviceResponse, error) {
+	return deviceResponse(serve(ctx, s.o.Translation.Get, r))
+}
+
+func (s *server) Set(ctx context.Context, r *tr1d1umpb.SetRequest) (*tr1d1umpb.DeviceResponse, error) {
+	return deviceResponse(serve(ctx, s.o.Translation.Set, r))
+}
+
+func (s *server) TestAndSet(ctx context.Context, r *tr1d1umpb.TestAndSetRequest) (*tr1d1umpb.DeviceResponse, error) {
+	return deviceResponse(serve(ctx, s.o.Translation.TestAndSet, r))
+}
+
+func (s *server) AddRow(ctx context.Context, r *tr1d1umpb.AddRowRequest) (*tr1d1umpb.DeviceResponse, error) {
+	return deviceResponse(serve(ctx, s.o.Translation.AddRow, r))
+}
+
+func (s *server) ReplaceRows(ctx context.Context, r *tr1d1umpb.ReplaceRowsRequest) (*tr1d1umpb.DeviceResponse, error) {
+	return deviceResponse(serve(ctx, s.o.Translation.ReplaceRows, r))
+}
+
+func (s *server) DeleteRow(ctx context.Context, r *tr1d1umpb.DeleteRowRequest) (*tr1d1umpb.DeviceResponse, error) {
+	return deviceResponse(serve(ctx, s.o.Translation.DeleteRow, r))
+}
+
+func (s *server) Stat(ctx context.Context, r *tr1d1umpb.StatRequest) (*tr1d1umpb.StatResponse, error) {
+	response, err := serve(ctx, s.o.Stat, r)
+	if err != nil {
+		return nil, err
+	}
+	return response.(*tr1d1umpb.StatResponse), nil
+}
+
+func deviceResponse(response interface{}, err error) (*tr1d1umpb.DeviceResponse, error) {
+	if err != nil {
+		return nil, err
+	}
+	return response.(*tr1d1umpb.DeviceResponse), nil
+}
@@ -0,0 +1,156 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/rpc/tr1d1umpb"
+	"github.com/xmidt-org/tr1d1um/translation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// handlerFunc is a kitgrpc.Handler calling a function.
+type handlerFunc func(ctx context.Context, request interface{}) (interface{}, error)
+
+func (f handlerFunc) ServeGRPC(ctx context.Context, request interface{}) (context.Context, interface{}, error) {
+	response, err := f(ctx, request)
+	return ctx, response, err
+}
+
+// testAuthChain accepts the "Basic good" credentials, which may only get statistics.
+func testAuthChain() *alice.Chain {
+	chain := alice.New(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Basic good" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			auth := bascule.Authentication{
+				Token:   bascule.NewToken("basic", "client0", bascule.NewAttributes(map[string]interface{}{})),
+				Request: bascule.Request{URL: r.URL, Method: r.Method},
+			}
+			next.ServeHTTP(w, r.WithContext(bascule.WithAuthentication(r.Context(), auth)))
+		})
+	})
+	return &chain
+}
+
+func TestHTTPRoute(t *testing.T) {
+	testCases := []struct {
+		Request        interface{}
+		ExpectedMethod string
+		ExpectedPath   string
+	}{
+		{&tr1d1umpb.GetRequest{DeviceId: "mac:112233445566", Service: "config"}, http.MethodGet, "/api/v2/device/mac:112233445566/config"},
+		{&tr1d1umpb.SetRequest{DeviceId: "mac:112233445566", Service: "config"}, http.MethodPatch, "/api/v2/device/mac:112233445566/config"},
+		{&tr1d1umpb.TestAndSetRequest{DeviceId: "mac:112233445566", Service: "config"}, http.MethodPatch, "/api/v2/device/mac:112233445566/config"},
+		{&tr1d1umpb.AddRowRequest{DeviceId: "mac:112233445566", Service: "config", Table: "t"}, http.MethodPost, "/api/v2/device/mac:112233445566/config/t"},
+		{&tr1d1umpb.ReplaceRowsRequest{DeviceId: "mac:112233445566", Service: "config", Table: "t"}, http.MethodPut, "/api/v2/device/mac:112233445566/config/t"},
+		{&tr1d1umpb.DeleteRowRequest{DeviceId: "mac:112233445566", Service: "config", Row: "r"}, http.MethodDelete, "/api/v2/device/mac:112233445566/config/r"},
+		{&tr1d1umpb.StatRequest{DeviceId: "mac:112233445566"}, http.MethodGet, "/api/v2/device/mac:112233445566/stat"},
+	}
+
+	for _, tc := range testCases {
+		method, path, err := httpRoute(tc.Request)
+		assert.Nil(t, err)
+		assert.Equal(t, tc.ExpectedMethod, method)
+		assert.Equal(t, tc.ExpectedPath, path)
+	}
+
+	_, _, err := httpRoute("unknown")
+	assert.Equal(t, errUnknownRequest, err)
+}
+
+func TestServer(t *testing.T) {
+	deviceResponse := handlerFunc(func(ctx context.Context, request interface{}) (interface{}, error) {
+		return &tr1d1umpb.DeviceResponse{StatusCode: http.StatusOK}, nil
+	})
+
+	drainer := common.NewDrainer(nil)
+	s, err := NewServer(Config{}, Options{
+		Translation: &translation.GRPCHandlers{
+			Get: handlerFunc(func(ctx context.Context, request interface{}) (interface{}, error) {
+				return nil, common.NewInvalidDeviceIDError(errors.New("invalid device ID"))
+			}),
+			Set:         deviceResponse,
+			TestAndSet:  deviceResponse,
+			AddRow:      deviceResponse,
+			ReplaceRows: deviceResponse,
+			DeleteRow:   deviceResponse,
+		},
+		Stat: handlerFunc(func(ctx context.Context, request interface{}) (interface{}, error) {
+			if _, ok := bascule.FromContext(ctx); !ok {
+				return nil, errors.New("missing authentication")
+			}
+			return &tr1d1umpb.StatResponse{}, nil
+		}),
+		Authenticate: testAuthChain(),
+		Drainer:      drainer,
+	})
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 20)
+	go s.Serve(listener)
+	defer s.Stop()
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := tr1d1umpb.NewDeviceClient(conn)
+	authorized := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic good")
+
+	t.Run("Authorized", func(t *testing.T) {
+		_, err := client.Stat(authorized, &tr1d1umpb.StatRequest{DeviceId: "mac:112233445566"})
+		assert.Nil(t, err)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, err := client.Stat(context.Background(), &tr1d1umpb.StatRequest{DeviceId: "mac:112233445566"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		_, err := client.DeleteRow(authorized, &tr1d1umpb.DeleteRowRequest{DeviceId: "mac:112233445566", Service: "config", Row: "r"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := client.Get(authorized, &tr1d1umpb.GetRequest{DeviceId: "nope", Service: "config"})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Draining", func(t *testing.T) {
+		assert.Zero(t, drainer.Drain(0))
+		_, err := client.Stat(authorized, &tr1d1umpb.StatRequest{DeviceId: "mac:112233445566"})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
+
+func TestNewServerTLS(t *testing.T) {
+	_, err := NewServer(Config{CertificateFile: "cert.pem"}, Options{})
+	assert.Equal(t, errIncompleteTLS, err)
+
+	_, err = NewServer(Config{CertificateFile: "missing.pem", KeyFile: "missing.key"}, Options{})
+	assert.NotNil(t, err)
+}
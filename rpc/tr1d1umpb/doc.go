@@ -0,0 +1,4 @@
+// Package tr1d1umpb holds the protocol buffer messages and gRPC stubs of the tr1d1um gRPC API.
+package tr1d1umpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative tr1d1um.proto
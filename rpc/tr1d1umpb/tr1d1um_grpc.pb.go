@@ -0,0 +1,333 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package tr1d1umpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// DeviceClient is the client API for Device service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DeviceClient interface {
+	// Get mirrors GET /api/v2/device/{deviceID}/{service}.
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*DeviceResponse, error)
+	// Set mirrors PATCH /api/v2/device/{deviceID}/{service} without the sync headers. The
+	// command is SET_ATTRIBUTES when the parameters only carry attributes and SET otherwise.
+	Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*DeviceResponse, error)
+	// TestAndSet mirrors PATCH /api/v2/device/{deviceID}/{service} with the sync headers.
+	TestAndSet(ctx context.Context, in *TestAndSetRequest, opts ...grpc.CallOption) (*DeviceResponse, error)
+	// AddRow mirrors POST /api/v2/device/{deviceID}/{service}/{table}.
+	AddRow(ctx context.Context, in *AddRowRequest, opts ...grpc.CallOption) (*DeviceResponse, error)
+	// ReplaceRows mirrors PUT /api/v2/device/{deviceID}/{service}/{table}.
+	ReplaceRows(ctx context.Context, in *ReplaceRowsRequest, opts ...grpc.CallOption) (*DeviceResponse, error)
+	// DeleteRow mirrors DELETE /api/v2/device/{deviceID}/{service}/{row}.
+	DeleteRow(ctx context.Context, in *DeleteRowRequest, opts ...grpc.CallOption) (*DeviceResponse, error)
+	// Stat mirrors GET /api/v2/device/{deviceID}/stat.
+	Stat(ctx context.Context, in *StatRequest, opts ...grpc.CallOption) (*StatResponse, error)
+}
+
+type deviceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDeviceClient(cc grpc.ClientConnInterface) DeviceClient {
+	return &deviceClient{cc}
+}
+
+func (c *deviceClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*DeviceResponse, error) {
+	out := new(DeviceResponse)
+	err := c.cc.Invoke(ctx, "/xmidt.tr1d1um.v1.Device/Get", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceClient) Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*DeviceResponse, error) {
+	out := new(DeviceResponse)
+	err := c.cc.Invoke(ctx, "/xmidt.tr1d1um.v1.Device/Set", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceClient) TestAndSet(ctx context.Context, in *TestAndSetRequest, opts ...grpc.CallOption) (*DeviceResponse, error) {
+	out := new(DeviceResponse)
+	err := c.cc.Invoke(ctx, "/xmidt.tr1d1um.v1.Device/TestAndSet", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceClient) AddRow(ctx context.Context, in *AddRowRequest, opts ...grpc.CallOption) (*DeviceResponse, error) {
+	out := new(DeviceResponse)
+	err := c.cc.Invoke(ctx, "/xmidt.tr1d1um.v1.Device/AddRow", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceClient) ReplaceRows(ctx context.Context, in *ReplaceRowsRequest, opts ...grpc.CallOption) (*DeviceResponse, error) {
+	out := new(DeviceResponse)
+	err := c.cc.Invoke(ctx, "/xmidt.tr1d1um.v1.Device/ReplaceRows", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceClient) DeleteRow(ctx context.Context, in *DeleteRowRequest, opts ...grpc.CallOption) (*DeviceResponse, error) {
+	out := new(DeviceResponse)
+	err := c.cc.Invoke(ctx, "/xmidt.tr1d1um.v1.Device/DeleteRow", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *deviceClient) Stat(ctx context.Context, in *StatRequest, opts ...grpc.CallOption) (*StatResponse, error) {
+	out := new(StatResponse)
+	err := c.cc.Invoke(ctx, "/xmidt.tr1d1um.v1.Device/Stat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeviceServer is the server API for Device service.
+// All implementations must embed UnimplementedDeviceServer
+// for forward compatibility
+type DeviceServer interface {
+	// Get mirrors GET /api/v2/device/{deviceID}/{service}.
+	Get(context.Context, *GetRequest) (*DeviceResponse, error)
+	// Set mirrors PATCH /api/v2/device/{deviceID}/{service} without the sync headers. The
+	// command is SET_ATTRIBUTES when the parameters only carry attributes and SET otherwise.
+	Set(context.Context, *SetRequest) (*DeviceResponse, error)
+	// TestAndSet mirrors PATCH /api/v2/device/{deviceID}/{service} with the sync headers.
+	TestAndSet(context.Context, *TestAndSetRequest) (*DeviceResponse, error)
+	// AddRow mirrors POST /api/v2/device/{deviceID}/{service}/{table}.
+	AddRow(context.Context, *AddRowRequest) (*DeviceResponse, error)
+	// ReplaceRows mirrors PUT /api/v2/device/{deviceID}/{service}/{table}.
+	ReplaceRows(context.Context, *ReplaceRowsRequest) (*DeviceResponse, error)
+	// DeleteRow mirrors DELETE /api/v2/device/{deviceID}/{service}/{row}.
+	DeleteRow(context.Context, *DeleteRowRequest) (*DeviceResponse, error)
+	// Stat mirrors GET /api/v2/device/{deviceID}/stat.
+	Stat(context.Context, *StatRequest) (*StatResponse, error)
+	mustEmbedUnimplementedDeviceServer()
+}
+
+// UnimplementedDeviceServer must be embedded to have forward compatible implementations.
+type UnimplementedDeviceServer struct {
+}
+
+func (UnimplementedDeviceServer) Get(context.Context, *GetRequest) (*DeviceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedDeviceServer) Set(context.Context, *SetRequest) (*DeviceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Set not implemented")
+}
+func (UnimplementedDeviceServer) TestAndSet(context.Context, *TestAndSetRequest) (*DeviceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TestAndSet not implemented")
+}
+func (UnimplementedDeviceServer) AddRow(context.Context, *AddRowRequest) (*DeviceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddRow not implemented")
+}
+func (UnimplementedDeviceServer) ReplaceRows(context.Context, *ReplaceRowsRequest) (*DeviceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplaceRows not implemented")
+}
+func (UnimplementedDeviceServer) DeleteRow(context.Context, *DeleteRowRequest) (*DeviceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteRow not implemented")
+}
+func (UnimplementedDeviceServer) Stat(context.Context, *StatRequest) (*StatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stat not implemented")
+}
+func (UnimplementedDeviceServer) mustEmbedUnimplementedDeviceServer() {}
+
+// UnsafeDeviceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DeviceServer will
+// result in compilation errors.
+type UnsafeDeviceServer interface {
+	mustEmbedUnimplementedDeviceServer()
+}
+
+func RegisterDeviceServer(s grpc.ServiceRegistrar, srv DeviceServer) {
+	s.RegisterService(&Device_ServiceDesc, srv)
+}
+
+func _Device_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/xmidt.tr1d1um.v1.Device/Get",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Device_Set_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceServer).Set(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/xmidt.tr1d1um.v1.Device/Set",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceServer).Set(ctx, req.(*SetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Device_TestAndSet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TestAndSetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceServer).TestAndSet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/xmidt.tr1d1um.v1.Device/TestAndSet",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceServer).TestAndSet(ctx, req.(*TestAndSetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Device_AddRow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddRowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceServer).AddRow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/xmidt.tr1d1um.v1.Device/AddRow",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceServer).AddRow(ctx, req.(*AddRowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Device_ReplaceRows_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplaceRowsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceServer).ReplaceRows(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/xmidt.tr1d1um.v1.Device/ReplaceRows",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceServer).ReplaceRows(ctx, req.(*ReplaceRowsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Device_DeleteRow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceServer).DeleteRow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/xmidt.tr1d1um.v1.Device/DeleteRow",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceServer).DeleteRow(ctx, req.(*DeleteRowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Device_Stat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DeviceServer).Stat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/xmidt.tr1d1um.v1.Device/Stat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DeviceServer).Stat(ctx, req.(*StatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Device_ServiceDesc is the grpc.ServiceDesc for Device service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Device_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "xmidt.tr1d1um.v1.Device",
+	HandlerType: (*DeviceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Get",
+			Handler:    _Device_Get_Handler,
+		},
+		{
+			MethodName: "Set",
+			Handler:    _Device_Set_Handler,
+		},
+		{
+			MethodName: "TestAndSet",
+			Handler:    _Device_TestAndSet_Handler,
+		},
+		{
+			MethodName: "AddRow",
+			Handler:    _Device_AddRow_Handler,
+		},
+		{
+			MethodName: "ReplaceRows",
+			Handler:    _Device_ReplaceRows_Handler,
+		},
+		{
+			MethodName: "DeleteRow",
+			Handler:    _Device_DeleteRow_Handler,
+		},
+		{
+			MethodName: "Stat",
+			Handler:    _Device_Stat_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "tr1d1um.proto",
+}
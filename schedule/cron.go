@@ -0,0 +1,160 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronHorizon bounds the search for the next time matching a cron expression.
+const cronHorizon = 5 * 366 * 24 * time.Hour
+
+// cronMacros are the shorthands of common cron expressions.
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var errCronFields = errors.New("expected 5 fields: minute hour day-of-month month day-of-week")
+
+// cronSpec is a parsed cron expression. Each field is a bitset of the values it matches.
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+
+	// domAny and dowAny are set when the day fields start with '*'. As with cron, days match
+	// either day field when both are restricted.
+	domAny, dowAny bool
+}
+
+// parseCron parses 5-field cron expressions (minute, hour, day of month, month and day of
+// week) made of '*', values, ranges, steps and lists, i.e. "*/15 8-18 * * 1-5", as well as
+// the @yearly, @monthly, @weekly, @daily and @hourly macros. Sundays are either 0 or 7.
+func parseCron(expr string) (*cronSpec, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, errCronFields
+	}
+
+	var (
+		c   cronSpec
+		err error
+	)
+
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+
+	c.domAny = strings.HasPrefix(fields[2], "*")
+	c.dowAny = strings.HasPrefix(fields[4], "*")
+	return &c, nil
+}
+
+// parseCronField returns the bitset of the values a comma separated list of values, ranges
+// and steps matches.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rangePart = part[:i]
+		}
+
+		var (
+			from, to int
+			err      error
+		)
+		switch {
+		case rangePart == "*":
+			from, to = min, max
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			if from, err = strconv.Atoi(bounds[0]); err == nil {
+				to, err = strconv.Atoi(bounds[1])
+			}
+		default:
+			if from, err = strconv.Atoi(rangePart); err == nil {
+				to = from
+				if step > 1 {
+					// as with cron, "5/10" stands for "5-max/10"
+					to = max
+				}
+			}
+		}
+
+		if err != nil || from < min || to > max || from > to {
+			return 0, fmt.Errorf("invalid value or range in %q, values go from %d to %d", part, min, max)
+		}
+
+		for v := from; v <= to; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// next returns the first time after t matching the spec, in the location of t. It returns the
+// zero time when nothing matches within the next five years.
+func (c *cronSpec) next(t time.Time) time.Time {
+	loc := t.Location()
+	limit := t.Add(cronHorizon)
+
+	// minutes and hours move forward on the absolute clock so that repeated wall clock times,
+	// when daylight saving time ends, don't send the search backwards
+	t = t.Truncate(time.Second).Add(time.Duration(60-t.Second()) * time.Second)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c *cronSpec) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,83 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@every 5m",
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := parseCron(expr)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	utc := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2021, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	testCases := []struct {
+		Name     string
+		Expr     string
+		From     time.Time
+		Expected time.Time
+	}{
+		{Name: "Every minute", Expr: "* * * * *", From: utc(3, 1, 10, 0).Add(30 * time.Second), Expected: utc(3, 1, 10, 1)},
+		{Name: "Strictly after", Expr: "0 10 * * *", From: utc(3, 1, 10, 0), Expected: utc(3, 2, 10, 0)},
+		{Name: "Steps", Expr: "*/15 * * * *", From: utc(3, 1, 10, 1), Expected: utc(3, 1, 10, 15)},
+		{Name: "Lists and ranges", Expr: "30 8-9,17 * * *", From: utc(3, 1, 9, 45), Expected: utc(3, 1, 17, 30)},
+		{Name: "Start with step", Expr: "5/20 * * * *", From: utc(3, 1, 10, 26), Expected: utc(3, 1, 10, 45)},
+		{Name: "Weekdays", Expr: "0 2 * * 1-5", From: utc(3, 5, 3, 0), Expected: utc(3, 8, 2, 0)},
+		{Name: "Sunday as 7", Expr: "0 0 * * 7", From: utc(3, 1, 0, 0), Expected: utc(3, 7, 0, 0)},
+		{Name: "Either day field", Expr: "0 0 15 * 1", From: utc(3, 9, 0, 0), Expected: utc(3, 15, 0, 0)},
+		{Name: "Day of month with any weekday", Expr: "0 0 31 * *", From: utc(4, 1, 0, 0), Expected: utc(5, 31, 0, 0)},
+		{Name: "Macro", Expr: "@monthly", From: utc(3, 15, 0, 0), Expected: utc(4, 1, 0, 0)},
+		{Name: "Never", Expr: "0 0 30 2 *", From: utc(3, 1, 0, 0)},
+		{
+			// 02:30 doesn't exist on the day daylight saving time starts
+			Name:     "Skipped wall clock time",
+			Expr:     "30 2 * * *",
+			From:     time.Date(2021, 3, 13, 3, 0, 0, 0, newYork),
+			Expected: time.Date(2021, 3, 15, 2, 30, 0, 0, newYork),
+		},
+		{
+			// 01:30 happens twice on the day daylight saving time ends
+			Name:     "Repeated wall clock time",
+			Expr:     "30 1 * * *",
+			From:     time.Date(2021, 11, 7, 1, 30, 0, 0, newYork),
+			Expected: time.Date(2021, 11, 7, 1, 30, 0, 0, newYork).Add(time.Hour),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			spec, err := parseCron(tc.Expr)
+			require.NoError(t, err)
+
+			next := spec.next(tc.From)
+			assert.True(t, tc.Expected.Equal(next), "expected %v, got %v", tc.Expected, next)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package schedule
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+func makeCreateEndpoint(s *Scheduler) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return s.Create(ctx, *request.(*Schedule))
+	}
+}
+
+func makeListEndpoint(s *Scheduler) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return s.store.List(ctx, request.(*listRequest).filter)
+	}
+}
+
+func makeGetEndpoint(s *Scheduler) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return ownedSchedule(ctx, s.store, request.(*scheduleRequest))
+	}
+}
+
+func makeCancelEndpoint(s *Scheduler) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		sched, err := ownedSchedule(ctx, s.store, request.(*scheduleRequest))
+		if err != nil {
+			return nil, err
+		}
+		return s.Cancel(ctx, sched.ID)
+	}
+}
+
+func makeRunsEndpoint(s *Scheduler) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		sched, err := ownedSchedule(ctx, s.store, request.(*scheduleRequest))
+		if err != nil {
+			return nil, err
+		}
+		return s.store.Runs(ctx, sched.ID)
+	}
+}
+
+// ownedSchedule returns the schedule of the request provided the caller created it or is an admin.
+func ownedSchedule(ctx context.Context, store Store, r *scheduleRequest) (Schedule, error) {
+	sched, err := store.Get(ctx, r.id)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	if !r.admin && sched.Owner != r.principal {
+		return Schedule{}, ErrScheduleNotOwned
+	}
+	return sched, nil
+}
@@ -0,0 +1,43 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/xmidt-org/tr1d1um/common"
+)
+
+// Error values definitions for the scheduler
+var (
+	ErrScheduleNotFound  = common.NewCodedError(errors.New("schedule not found"), http.StatusNotFound)
+	ErrScheduleNotOwned  = common.NewCodedError(errors.New("schedule was created by a different principal"), http.StatusForbidden)
+	ErrScheduleNotActive = common.NewCodedError(errors.New("schedule is already completed or canceled"), http.StatusConflict)
+	ErrInvalidSchedule   = common.NewBadRequestError(errors.New("invalid schedule JSON"))
+	ErrInvalidDevices    = common.NewBadRequestError(errors.New("deviceIDs must list at least one device and at most the configured maximum"))
+	ErrInvalidTiming     = common.NewBadRequestError(errors.New("exactly one of runAt and cron is required"))
+	ErrRunAtInPast       = common.NewBadRequestError(errors.New("runAt must be in the future"))
+	ErrInvalidTimeZone   = common.NewBadRequestError(errors.New("timeZone must be an IANA time zone name and only applies to cron schedules"))
+	ErrInvalidStatus     = common.NewBadRequestError(errors.New("status must be one of active, completed and canceled"))
+)
+
+// ErrorCodeForbiddenDevice is the error code of operations on devices the caller may not access.
+const ErrorCodeForbiddenDevice = "forbidden-device"
+
+var (
+	errDeviceNotAllowed = errors.New("device is not allowed by the token of the schedule owner")
+	errUnknownStoreType = errors.New("unknown schedule store type")
+	errMissingPath      = errors.New("schedule store path is required")
+	errCronNeverMatches = errors.New("expression never matches")
+	errRunCanceled      = common.NewCodedError(errors.New("schedule was canceled before the operation was sent"), http.StatusConflict)
+)
+
+// newForbiddenDeviceError is the error of operations on devices the caller may not access.
+func newForbiddenDeviceError(err error) error {
+	return common.NewProblemError(err, http.StatusForbidden, ErrorCodeForbiddenDevice, "Forbidden device", "deviceIDs")
+}
+
+// newInvalidCronError is the error of cron expressions which don't parse or never match.
+func newInvalidCronError(err error) error {
+	return common.NewBadRequestError(fmt.Errorf("invalid cron expression: %w", err))
+}
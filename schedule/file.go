@@ -0,0 +1,195 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Defaults of the file store.
+const (
+	DefaultMaxRuns   = 100
+	DefaultRetention = 30 * 24 * time.Hour
+)
+
+// FileConfig configures the JSON file store.
+type FileConfig struct {
+	// Path is the file schedules and runs are persisted to.
+	Path string
+
+	// MaxRuns is the number of runs kept per schedule. Older ones are dropped.
+	// (Optional) Defaults to 100.
+	MaxRuns int
+
+	// Retention is how long completed and canceled schedules are kept, along with their runs.
+	// (Optional) Defaults to 720h (30 days).
+	Retention time.Duration
+}
+
+// FileStore keeps schedules and runs in memory and persists them to a JSON file on every change.
+type FileStore struct {
+	path      string
+	maxRuns   int
+	retention time.Duration
+	now       func() time.Time
+
+	lock sync.Mutex
+	data fileData
+}
+
+// fileData is the content of the store file.
+type fileData struct {
+	Schedules map[string]Schedule `json:"schedules"`
+	Runs      map[string][]Run    `json:"runs"`
+}
+
+// NewFileStore loads the store file, if it exists.
+func NewFileStore(c FileConfig) (*FileStore, error) {
+	if c.Path == "" {
+		return nil, errMissingPath
+	}
+
+	if c.MaxRuns <= 0 {
+		c.MaxRuns = DefaultMaxRuns
+	}
+
+	if c.Retention <= 0 {
+		c.Retention = DefaultRetention
+	}
+
+	f := &FileStore{
+		path:      c.Path,
+		maxRuns:   c.MaxRuns,
+		retention: c.Retention,
+		now:       time.Now,
+	}
+
+	data, err := ioutil.ReadFile(c.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &f.data); err != nil {
+			return nil, fmt.Errorf("failed to decode schedule store file %s: %v", c.Path, err)
+		}
+	}
+
+	if f.data.Schedules == nil {
+		f.data.Schedules = make(map[string]Schedule)
+	}
+	if f.data.Runs == nil {
+		f.data.Runs = make(map[string][]Run)
+	}
+	return f, nil
+}
+
+func (f *FileStore) Save(_ context.Context, s Schedule) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.data.Schedules[s.ID] = s
+	return f.persist()
+}
+
+func (f *FileStore) Get(_ context.Context, id string) (Schedule, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	s, ok := f.data.Schedules[id]
+	if !ok {
+		return Schedule{}, ErrScheduleNotFound
+	}
+	return s, nil
+}
+
+func (f *FileStore) List(_ context.Context, filter Filter) ([]Schedule, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	schedules := []Schedule{}
+	for _, s := range f.data.Schedules {
+		if filter.matches(s) {
+			schedules = append(schedules, s)
+		}
+	}
+
+	sortByCreation(schedules)
+	return schedules, nil
+}
+
+func (f *FileStore) AddRun(_ context.Context, r Run) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.data.Schedules[r.ScheduleID]; !ok {
+		return ErrScheduleNotFound
+	}
+
+	runs := append(f.data.Runs[r.ScheduleID], r)
+	if len(runs) > f.maxRuns {
+		runs = runs[len(runs)-f.maxRuns:]
+	}
+	f.data.Runs[r.ScheduleID] = runs
+	return f.persist()
+}
+
+func (f *FileStore) Runs(_ context.Context, scheduleID string) ([]Run, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.data.Schedules[scheduleID]; !ok {
+		return nil, ErrScheduleNotFound
+	}
+
+	stored := f.data.Runs[scheduleID]
+	runs := make([]Run, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		runs = append(runs, stored[i])
+	}
+	return runs, nil
+}
+
+// expire drops the schedules which ended longer than the retention ago. The lock must be held.
+func (f *FileStore) expire() {
+	cutoff := f.now().Add(-f.retention)
+	for id, s := range f.data.Schedules {
+		if s.EndedAt != nil && s.EndedAt.Before(cutoff) {
+			delete(f.data.Schedules, id)
+			delete(f.data.Runs, id)
+		}
+	}
+}
+
+// persist writes the store file. The lock must be held.
+func (f *FileStore) persist() error {
+	f.expire()
+
+	data, err := json.MarshalIndent(f.data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so a crash never leaves a truncated store behind
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
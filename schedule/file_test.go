@@ -0,0 +1,122 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFileStore(t *testing.T, c FileConfig) *FileStore {
+	dir, err := ioutil.TempDir("", "schedules")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	c.Path = filepath.Join(dir, "schedules.json")
+	f, err := NewFileStore(c)
+	require.NoError(t, err)
+	return f
+}
+
+func TestNewFileStore(t *testing.T) {
+	_, err := NewFileStore(FileConfig{})
+	assert.Equal(t, errMissingPath, err)
+
+	_, err = NewStore(Config{Type: "bolt"})
+	assert.True(t, errors.Is(err, errUnknownStoreType))
+
+	dir, err := ioutil.TempDir("", "schedules")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "schedules.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte("{"), 0600))
+	_, err = NewFileStore(FileConfig{Path: path})
+	assert.Error(t, err)
+}
+
+func TestFileStore(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	f := newTestFileStore(t, FileConfig{MaxRuns: 2})
+
+	created := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	first := Schedule{ID: "a", Owner: "client0", DeviceIDs: []string{"mac:112233445566"}, Status: StatusActive, CreatedAt: created}
+	second := Schedule{ID: "b", Owner: "client1", DeviceIDs: []string{"mac:665544332211"}, Status: StatusCompleted, CreatedAt: created.Add(time.Minute)}
+	require.NoError(t, f.Save(ctx, second))
+	require.NoError(t, f.Save(ctx, first))
+
+	s, err := f.Get(ctx, "a")
+	assert.NoError(err)
+	assert.Equal(first, s)
+
+	_, err = f.Get(ctx, "c")
+	assert.Equal(ErrScheduleNotFound, err)
+
+	all, err := f.List(ctx, Filter{})
+	assert.NoError(err)
+	assert.Equal([]Schedule{first, second}, all)
+
+	for _, filter := range []Filter{{Owner: "client0"}, {DeviceID: "mac:112233445566"}, {Status: StatusActive}} {
+		matching, err := f.List(ctx, filter)
+		assert.NoError(err)
+		assert.Equal([]Schedule{first}, matching)
+	}
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, f.AddRun(ctx, Run{ScheduleID: "a", ScheduledAt: created.Add(time.Duration(i) * time.Hour)}))
+	}
+	assert.Equal(ErrScheduleNotFound, f.AddRun(ctx, Run{ScheduleID: "c"}))
+
+	runs, err := f.Runs(ctx, "a")
+	assert.NoError(err)
+	if assert.Len(runs, 2) {
+		assert.Equal(created.Add(2*time.Hour), runs[0].ScheduledAt)
+		assert.Equal(created.Add(time.Hour), runs[1].ScheduledAt)
+	}
+
+	_, err = f.Runs(ctx, "c")
+	assert.Equal(ErrScheduleNotFound, err)
+
+	// everything survives a restart
+	reloaded, err := NewFileStore(FileConfig{Path: f.path, MaxRuns: 2})
+	require.NoError(t, err)
+
+	all, err = reloaded.List(ctx, Filter{})
+	assert.NoError(err)
+	assert.Len(all, 2)
+
+	reloadedRuns, err := reloaded.Runs(ctx, "a")
+	assert.NoError(err)
+	assert.Len(reloadedRuns, 2)
+}
+
+func TestFileStoreRetention(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	f := newTestFileStore(t, FileConfig{Retention: time.Hour})
+
+	now := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return now }
+
+	ended := now
+	require.NoError(t, f.Save(ctx, Schedule{ID: "old", Status: StatusCanceled, EndedAt: &ended}))
+	require.NoError(t, f.AddRun(ctx, Run{ScheduleID: "old"}))
+
+	// expired schedules are dropped along with their runs the next time the store changes
+	now = now.Add(2 * time.Hour)
+	require.NoError(t, f.Save(ctx, Schedule{ID: "new", Status: StatusActive}))
+
+	_, err := f.Get(ctx, "old")
+	assert.Equal(ErrScheduleNotFound, err)
+	assert.NotContains(f.data.Runs, "old")
+
+	_, err = f.Get(ctx, "new")
+	assert.NoError(err)
+}
@@ -0,0 +1,200 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/translation"
+)
+
+// Statuses of schedules.
+const (
+	// StatusActive schedules have runs to come.
+	StatusActive = "active"
+
+	// StatusCompleted schedules won't run again. One-off schedules complete as their run starts.
+	StatusCompleted = "completed"
+
+	// StatusCanceled schedules were canceled by their owner or an admin.
+	StatusCanceled = "canceled"
+)
+
+// Reasons runs are skipped for.
+const (
+	// SkippedMisfire runs were due longer than the misfire grace period ago, i.e. while
+	// tr1d1um was down.
+	SkippedMisfire = "misfire"
+
+	// SkippedOverlap runs were due while the previous run of their schedule was in flight.
+	SkippedOverlap = "overlap"
+)
+
+// Schedule is a WDMP operation to run on a list of devices, either once at a given time or
+// repeatedly on a cron schedule.
+type Schedule struct {
+	ID         string                `json:"id"`
+	Owner      string                `json:"owner"`
+	PartnerIDs []string              `json:"partnerIDs,omitempty"`
+	DeviceIDs  []string              `json:"deviceIDs"`
+	Operation  translation.Operation `json:"operation"`
+
+	// AllowedDevices is the enforced allowed devices claim of the token of the owner, if any.
+	// Operations are only sent to the devices it allows.
+	AllowedDevices []string `json:"allowedDevices,omitempty"`
+
+	// RunAt is the time of one-off schedules. Cron is the 5-field cron expression of
+	// recurring ones, evaluated in TimeZone (UTC by default).
+	RunAt    *time.Time `json:"runAt,omitempty"`
+	Cron     string     `json:"cron,omitempty"`
+	TimeZone string     `json:"timeZone,omitempty"`
+
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"createdAt"`
+	NextRun   *time.Time `json:"nextRun,omitempty"`
+
+	// EndedAt is when the schedule was completed or canceled.
+	EndedAt *time.Time `json:"endedAt,omitempty"`
+}
+
+// Run is an execution of a schedule.
+type Run struct {
+	ScheduleID  string    `json:"scheduleID"`
+	ScheduledAt time.Time `json:"scheduledAt"`
+	StartedAt   time.Time `json:"startedAt"`
+	FinishedAt  time.Time `json:"finishedAt"`
+
+	// Skipped is the reason the run was skipped for, if it was.
+	Skipped string `json:"skipped,omitempty"`
+
+	Results []Result `json:"results,omitempty"`
+}
+
+// Result is the outcome of the operation of a run for one device. Response is the WDMP
+// response of the device, whose status code is StatusCode. Operations which didn't get a
+// device response carry the problem details HTTP API consumers would get instead.
+type Result struct {
+	DeviceID   string          `json:"deviceID"`
+	TID        string          `json:"tid"`
+	StatusCode int             `json:"statusCode"`
+	Response   json.RawMessage `json:"response,omitempty"`
+	Error      *common.Problem `json:"error,omitempty"`
+}
+
+// Store persists schedules and the history of their runs.
+type Store interface {
+	// Save creates or replaces a schedule.
+	Save(ctx context.Context, s Schedule) error
+
+	// Get returns ErrScheduleNotFound for unknown schedules.
+	Get(ctx context.Context, id string) (Schedule, error)
+
+	// List returns the schedules matching the filter by creation time.
+	List(ctx context.Context, f Filter) ([]Schedule, error)
+
+	AddRun(ctx context.Context, r Run) error
+
+	// Runs returns the runs of a schedule, the most recent first.
+	Runs(ctx context.Context, scheduleID string) ([]Run, error)
+}
+
+// Filter selects schedules. Zero fields select all schedules.
+type Filter struct {
+	Owner    string
+	DeviceID string
+	Status   string
+}
+
+func (f Filter) matches(s Schedule) bool {
+	switch {
+	case f.Owner != "" && f.Owner != s.Owner:
+		return false
+	case f.Status != "" && f.Status != s.Status:
+		return false
+	case f.DeviceID != "" && !contains(s.DeviceIDs, f.DeviceID):
+		return false
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sortByCreation sorts schedules by creation time, then ID.
+func sortByCreation(schedules []Schedule) {
+	sort.Slice(schedules, func(i, j int) bool {
+		if schedules[i].CreatedAt.Equal(schedules[j].CreatedAt) {
+			return schedules[i].ID < schedules[j].ID
+		}
+		return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+	})
+}
+
+// Supported store types.
+const (
+	FileStoreType = "file"
+)
+
+// Default values of the scheduler. They apply when the corresponding Config field is left unset.
+const (
+	DefaultMaxDevices         = 100
+	DefaultMaxConcurrency     = 10
+	DefaultMisfireGracePeriod = 10 * time.Minute
+)
+
+// Config configures the scheduler and its store.
+type Config struct {
+	// Type is the type of store. Only "file" is supported.
+	// (Optional) Defaults to "file".
+	Type string
+
+	File FileConfig
+
+	// MaxDevices bounds the devices of a schedule.
+	// (Optional) Defaults to 100.
+	MaxDevices int
+
+	// MaxConcurrency bounds the operations sent to devices at once, across runs.
+	// (Optional) Defaults to 10.
+	MaxConcurrency int
+
+	// MisfireGracePeriod is how late runs may start, i.e. after tr1d1um was down. Later runs
+	// are skipped and recorded as such.
+	// (Optional) Defaults to 10m.
+	MisfireGracePeriod time.Duration
+
+	// AdminCapability allows listing, inspecting and canceling the schedules of all owners.
+	// (Optional) By default, callers only get to their own schedules.
+	AdminCapability string
+}
+
+func (c Config) withDefaults() Config {
+	if c.MaxDevices <= 0 {
+		c.MaxDevices = DefaultMaxDevices
+	}
+	if c.MaxConcurrency <= 0 {
+		c.MaxConcurrency = DefaultMaxConcurrency
+	}
+	if c.MisfireGracePeriod <= 0 {
+		c.MisfireGracePeriod = DefaultMisfireGracePeriod
+	}
+	return c
+}
+
+// NewStore creates the store described by the config.
+func NewStore(c Config) (Store, error) {
+	switch c.Type {
+	case "", FileStoreType:
+		return NewFileStore(c.File)
+	}
+	return nil, fmt.Errorf("%w: %s", errUnknownStoreType, c.Type)
+}
@@ -0,0 +1,532 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/provider"
+	"github.com/gorilla/mux"
+	"github.com/justinas/alice"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/translation"
+	"github.com/xmidt-org/webpa-common/device"
+	"github.com/xmidt-org/webpa-common/logging"
+	"github.com/xmidt-org/webpa-common/xmetrics"
+)
+
+// Metric names of the scheduler.
+const (
+	RunsCounter       = "scheduled_runs"
+	OperationsCounter = "scheduled_operations"
+)
+
+// OutcomeLabel is the label of the outcome of runs: executed or the reason they were skipped for.
+const OutcomeLabel = "outcome"
+
+// outcomeExecuted is the outcome label value of runs which were not skipped.
+const outcomeExecuted = "executed"
+
+// retryInterval is how long the scheduler waits before listing schedules again when the
+// store fails.
+const retryInterval = time.Minute
+
+// Metrics returns the metrics relevant to this package.
+func Metrics() []xmetrics.Metric {
+	return []xmetrics.Metric{
+		{
+			Name:       RunsCounter,
+			Type:       xmetrics.CounterType,
+			Help:       "Count of the runs of schedules by outcome.",
+			LabelNames: []string{OutcomeLabel},
+		},
+		{
+			Name:       OperationsCounter,
+			Type:       xmetrics.CounterType,
+			Help:       "Count of scheduled operations sent to devices by WDMP command and response status code.",
+			LabelNames: []string{common.CommandLabel, common.CodeLabel},
+		},
+	}
+}
+
+// Options wraps the properties needed to run schedules and serve the scheduler routes.
+type Options struct {
+	// S sends the operations. Operations are sent without the token of the caller who scheduled
+	// them, so S is expected to acquire its own.
+	S     translation.Service
+	Store Store
+
+	//APIRouter is assumed to be a subrouter with the API prefix path (i.e. 'api/v2')
+	APIRouter                   *mux.Router
+	Authenticate                *alice.Chain
+	Log                         kitlog.Logger
+	ReducedLoggingResponseCodes []int
+	MetricsProvider             provider.Provider
+
+	// ValidServices, PartnerPolicy and ParameterPolicy apply to operations as they are
+	// scheduled, as they do to the requests of the translation routes.
+	ValidServices   []string
+	PartnerPolicy   *common.PartnerPolicy
+	ParameterPolicy *translation.ParameterPolicy
+
+	// DeviceAccess, when set, restricts the devices callers may schedule operations on. The
+	// device access checks of the auth chain only see the device IDs of request paths.
+	// (Optional)
+	DeviceAccess DeviceAccess
+
+	// SensitiveParameters, when set, masks the values of sensitive parameters in the responses
+	// of the scheduled GET operations recorded in run histories.
+	// (Optional)
+	SensitiveParameters *translation.SensitiveParameters
+
+	// Drainer, when set, tracks the operations in flight such that they get to complete on
+	// shutdown.
+	// (Optional)
+	Drainer *common.Drainer
+
+	//Runtime, when set, overrides ValidServices and ReducedLoggingResponseCodes with the ones of its current snapshot.
+	//(Optional)
+	Runtime *common.Runtime
+}
+
+// DeviceAccess restricts the devices of schedules to the ones the tokens of their owners
+// may access.
+type DeviceAccess interface {
+	// Authorize returns an error if the token of ctx may not access deviceID.
+	Authorize(ctx context.Context, deviceID device.ID) error
+
+	// AllowedDevices returns the allowed devices claim of the token of ctx, if it's enforced.
+	// Runs only send operations to the devices it allows, as they are sent with the token
+	// of tr1d1um.
+	AllowedDevices(ctx context.Context) ([]string, bool)
+}
+
+// Scheduler runs the operations of the schedules of a store as they become due.
+type Scheduler struct {
+	config    Config
+	store     Store
+	service   translation.Service
+	sensitive *translation.SensitiveParameters
+	drainer   *common.Drainer
+	logger    kitlog.Logger
+	now       func() time.Time
+
+	wake  chan struct{}
+	slots chan struct{}
+
+	// lock serializes the changes to schedules and guards running, which holds the
+	// cancel functions of the runs in flight by schedule ID.
+	lock    sync.Mutex
+	running map[string]context.CancelFunc
+
+	runs       metrics.Counter
+	operations metrics.Counter
+}
+
+// NewScheduler creates a scheduler for the schedules of o.Store. Schedules only run once the
+// scheduler is started.
+func NewScheduler(c Config, o *Options) *Scheduler {
+	c = c.withDefaults()
+
+	p := o.MetricsProvider
+	if p == nil {
+		p = provider.NewDiscardProvider()
+	}
+
+	logger := o.Log
+	if logger == nil {
+		logger = kitlog.NewNopLogger()
+	}
+
+	return &Scheduler{
+		config:     c,
+		store:      o.Store,
+		service:    o.S,
+		sensitive:  o.SensitiveParameters,
+		drainer:    o.Drainer,
+		logger:     logger,
+		now:        time.Now,
+		wake:       make(chan struct{}, 1),
+		slots:      make(chan struct{}, c.MaxConcurrency),
+		running:    make(map[string]context.CancelFunc),
+		runs:       p.NewCounter(RunsCounter),
+		operations: p.NewCounter(OperationsCounter),
+	}
+}
+
+// Start runs due schedules in the background. The returned function cancels the runs in
+// flight and waits for them to be recorded.
+func (s *Scheduler) Start() func() {
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		runs        sync.WaitGroup
+		done        = make(chan struct{})
+	)
+
+	go func() {
+		defer close(done)
+		s.loop(ctx, &runs)
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+			runs.Wait()
+		})
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, runs *sync.WaitGroup) {
+	for {
+		var wait <-chan time.Time
+		next, err := s.runDue(ctx, runs)
+		if err != nil {
+			logging.Error(s.logger).Log(logging.MessageKey(), "Failed to list due schedules", logging.ErrorKey(), err)
+			next = s.now().Add(retryInterval)
+		}
+
+		var timer *time.Timer
+		if !next.IsZero() {
+			timer = time.NewTimer(next.Sub(s.now()))
+			wait = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-s.wake:
+		case <-wait:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// notify wakes the loop up such that it takes changed schedules into account.
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// runDue starts the runs of the schedules due by now and returns the time of the earliest run
+// to come, if any. Schedules are moved to their next run before their run starts so that a
+// crash never repeats an operation. Nothing runs once draining started: the operations would be
+// rejected, so due schedules are left for the next instance to pick up.
+func (s *Scheduler) runDue(ctx context.Context, runs *sync.WaitGroup) (time.Time, error) {
+	if !s.drainer.Ready() {
+		return time.Time{}, nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	schedules, err := s.store.List(ctx, Filter{Status: StatusActive})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var next time.Time
+	now := s.now()
+	for _, sched := range schedules {
+		if sched.NextRun == nil {
+			continue
+		}
+
+		if sched.NextRun.After(now) {
+			next = earliest(next, *sched.NextRun)
+			continue
+		}
+
+		scheduledAt := *sched.NextRun
+		if err := s.advance(ctx, &sched, now); err != nil {
+			logging.Error(s.logger).Log(logging.MessageKey(), "Failed to save schedule", "scheduleID", sched.ID, logging.ErrorKey(), err)
+			continue
+		}
+
+		if sched.NextRun != nil {
+			next = earliest(next, *sched.NextRun)
+		}
+
+		run := Run{ScheduleID: sched.ID, ScheduledAt: scheduledAt, StartedAt: now, FinishedAt: now}
+		if now.Sub(scheduledAt) > s.config.MisfireGracePeriod {
+			run.Skipped = SkippedMisfire
+		} else if _, ok := s.running[sched.ID]; ok {
+			run.Skipped = SkippedOverlap
+		}
+
+		if run.Skipped != "" {
+			s.record(ctx, run)
+			continue
+		}
+
+		runCtx, cancel := context.WithCancel(ctx)
+		s.running[sched.ID] = cancel
+		runs.Add(1)
+		go func(sched Schedule) {
+			defer runs.Done()
+			s.run(ctx, runCtx, sched, run)
+		}(sched)
+	}
+	return next, nil
+}
+
+// advance moves an active schedule to its next run, completing it when there is none. The
+// lock must be held.
+func (s *Scheduler) advance(ctx context.Context, sched *Schedule, now time.Time) error {
+	var next time.Time
+	if sched.Cron != "" {
+		var err error
+		if next, err = nextCronRun(sched.Cron, sched.TimeZone, now); err != nil {
+			return err
+		}
+	}
+
+	if next.IsZero() {
+		sched.Status, sched.NextRun, sched.EndedAt = StatusCompleted, nil, &now
+	} else {
+		sched.NextRun = &next
+	}
+	return s.store.Save(ctx, *sched)
+}
+
+// run sends the operation of the schedule to its devices and records the run. Canceling runCtx
+// skips the devices the operation wasn't sent to yet while canceling ctx, on shutdown, aborts
+// the operations in flight too.
+func (s *Scheduler) run(ctx, runCtx context.Context, sched Schedule, run Run) {
+	defer func() {
+		s.lock.Lock()
+		s.running[sched.ID]()
+		delete(s.running, sched.ID)
+		s.lock.Unlock()
+	}()
+
+	var (
+		results = make([]Result, len(sched.DeviceIDs))
+		wg      sync.WaitGroup
+		allowed = s.allowedDevices(sched)
+	)
+
+	for i, deviceID := range sched.DeviceIDs {
+		select {
+		case s.slots <- struct{}{}:
+		case <-runCtx.Done():
+		}
+
+		if runCtx.Err() != nil {
+			results[i] = skipped(ctx, deviceID)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, deviceID string) {
+			defer func() {
+				<-s.slots
+				wg.Done()
+			}()
+			results[i] = s.execute(ctx, sched, allowed, deviceID)
+		}(i, deviceID)
+	}
+
+	wg.Wait()
+	run.FinishedAt = s.now()
+	run.Results = results
+	s.record(context.Background(), run)
+}
+
+// skipped is the result of a device the operation wasn't sent to as the run was canceled,
+// either along with its schedule or on shutdown.
+func skipped(ctx context.Context, deviceID string) Result {
+	var err error = errRunCanceled
+	if ctx.Err() != nil {
+		err = common.ErrShuttingDown
+	}
+
+	problem := common.NewProblem(err, "")
+	return Result{DeviceID: deviceID, StatusCode: problem.Status, Error: &problem}
+}
+
+// execute sends the operation of the schedule to a device. Operations of runs started before
+// draining which are not sent yet are rejected.
+func (s *Scheduler) execute(ctx context.Context, sched Schedule, allowed *common.AllowedDevices, deviceID string) Result {
+	var (
+		tid     = common.NewTID()
+		start   = time.Now()
+		result  = Result{DeviceID: deviceID, TID: tid}
+		sendErr error
+	)
+
+	ctx = context.WithValue(ctx, common.ContextKeyRequestTID, tid)
+	err := s.drainer.Run(func() {
+		result.StatusCode, result.Response, sendErr = s.send(ctx, sched, allowed, deviceID, tid)
+	})
+	if err == nil {
+		err = sendErr
+	}
+
+	if err != nil {
+		problem := common.NewProblem(err, tid)
+		result.StatusCode, result.Response, result.Error = problem.Status, nil, &problem
+		logging.Error(s.logger).Log(logging.MessageKey(), "scheduled operation failed", "scheduleID", sched.ID, "tid", tid, logging.ErrorKey(), err)
+	}
+
+	s.operations.With(common.CommandLabel, sched.Operation.Command, common.CodeLabel, strconv.Itoa(result.StatusCode)).Add(1)
+	logging.Info(s.logger).Log(logging.MessageKey(), "record", "scheduleID", sched.ID, "tid", tid, "deviceID", deviceID,
+		"service", sched.Operation.Service, "command", sched.Operation.Command, "code", result.StatusCode, "duration", time.Since(start))
+	return result
+}
+
+// allowedDevices compiles the allowed devices of the schedule, if any, once for all the
+// devices of a run.
+func (s *Scheduler) allowedDevices(sched Schedule) *common.AllowedDevices {
+	if sched.AllowedDevices == nil {
+		return nil
+	}
+
+	allowed := common.NewAllowedDevices(sched.AllowedDevices)
+	if len(allowed.Invalid) > 0 {
+		logging.Warn(s.logger).Log(logging.MessageKey(), "ignoring invalid allowed devices entries", "scheduleID", sched.ID, "invalid", allowed.Invalid)
+	}
+	return &allowed
+}
+
+func (s *Scheduler) send(ctx context.Context, sched Schedule, allowed *common.AllowedDevices, deviceID, tid string) (int, []byte, error) {
+	if allowed != nil && !allowed.Allows(device.ID(deviceID)) {
+		return 0, nil, newForbiddenDeviceError(fmt.Errorf("%w: %s", errDeviceNotAllowed, deviceID))
+	}
+
+	wrpMsg, err := sched.Operation.Message(deviceID, tid, sched.PartnerIDs)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resp, err := s.service.SendWRP(ctx, wrpMsg, "")
+	if err != nil {
+		return 0, nil, err
+	}
+	return sched.Operation.DeviceResponse(ctx, resp, s.sensitive)
+}
+
+// record adds a run to the history of its schedule.
+func (s *Scheduler) record(ctx context.Context, run Run) {
+	outcome := run.Skipped
+	if outcome == "" {
+		outcome = outcomeExecuted
+	}
+	s.runs.With(OutcomeLabel, outcome).Add(1)
+
+	if err := s.store.AddRun(ctx, run); err != nil {
+		logging.Error(s.logger).Log(logging.MessageKey(), "Failed to record run", "scheduleID", run.ScheduleID, logging.ErrorKey(), err)
+	}
+}
+
+// Create schedules an operation. The ID, status, creation time and next run of the schedule
+// are set here.
+func (s *Scheduler) Create(ctx context.Context, sched Schedule) (Schedule, error) {
+	now := s.now()
+	sched.ID = common.NewTID()
+	sched.Status = StatusActive
+	sched.CreatedAt = now
+	sched.EndedAt = nil
+
+	switch {
+	case sched.RunAt != nil && sched.Cron == "":
+		if !sched.RunAt.After(now) {
+			return Schedule{}, ErrRunAtInPast
+		}
+		if sched.TimeZone != "" {
+			return Schedule{}, ErrInvalidTimeZone
+		}
+		next := *sched.RunAt
+		sched.NextRun = &next
+	case sched.RunAt == nil && sched.Cron != "":
+		next, err := nextCronRun(sched.Cron, sched.TimeZone, now)
+		if err != nil {
+			return Schedule{}, err
+		}
+		if next.IsZero() {
+			return Schedule{}, newInvalidCronError(errCronNeverMatches)
+		}
+		sched.NextRun = &next
+	default:
+		return Schedule{}, ErrInvalidTiming
+	}
+
+	s.lock.Lock()
+	err := s.store.Save(ctx, sched)
+	s.lock.Unlock()
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	s.notify()
+	return sched, nil
+}
+
+// Cancel cancels an active schedule. Operations of its run in flight, if any, which were not
+// sent yet are canceled too.
+func (s *Scheduler) Cancel(ctx context.Context, id string) (Schedule, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	sched, err := s.store.Get(ctx, id)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	if sched.Status != StatusActive {
+		return Schedule{}, ErrScheduleNotActive
+	}
+
+	now := s.now()
+	sched.Status, sched.NextRun, sched.EndedAt = StatusCanceled, nil, &now
+	if err := s.store.Save(ctx, sched); err != nil {
+		return Schedule{}, err
+	}
+
+	if cancel, ok := s.running[id]; ok {
+		cancel()
+	}
+
+	s.notify()
+	return sched, nil
+}
+
+// nextCronRun returns the first time after now matching a cron expression in a time zone. The
+// zero time is returned if nothing matches.
+func nextCronRun(expr, timeZone string, now time.Time) (time.Time, error) {
+	spec, err := parseCron(expr)
+	if err != nil {
+		return time.Time{}, newInvalidCronError(err)
+	}
+
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return time.Time{}, ErrInvalidTimeZone
+	}
+
+	next := spec.next(now.In(loc))
+	if next.IsZero() {
+		return next, nil
+	}
+	return next.UTC(), nil
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || b.Before(a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,381 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/translation"
+	"github.com/xmidt-org/webpa-common/xmetrics/xmetricstest"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+// serviceFunc is a translation.Service calling a function.
+type serviceFunc func(context.Context, *wrp.Message, string) (*common.XmidtResponse, error)
+
+func (f serviceFunc) SendWRP(ctx context.Context, msg *wrp.Message, authHeaderValue string) (*common.XmidtResponse, error) {
+	return f(ctx, msg, authHeaderValue)
+}
+
+// deviceResponse returns the XMiDT response carrying the given WDMP response of a device.
+func deviceResponse(payload string) *common.XmidtResponse {
+	return &common.XmidtResponse{
+		Code: http.StatusOK,
+		Body: bytes.NewBuffer(wrp.MustEncode(&wrp.Message{
+			Type:    wrp.SimpleRequestResponseMessageType,
+			Payload: []byte(payload),
+		}, wrp.Msgpack)).Bytes(),
+	}
+}
+
+var testOperation = translation.Operation{
+	Command:    translation.CommandSet,
+	Service:    "config",
+	Parameters: []byte(`[{"name":"Device.Enable","value":"true","dataType":3}]`),
+}
+
+// newTestScheduler returns a scheduler with a file store whose clocks are at now.
+func newTestScheduler(t *testing.T, c Config, s translation.Service, now *time.Time) (*Scheduler, xmetricstest.Provider) {
+	p := xmetricstest.NewProvider(nil, Metrics)
+	store := newTestFileStore(t, FileConfig{})
+	sched := NewScheduler(c, &Options{
+		S:               s,
+		Store:           store,
+		MetricsProvider: p,
+	})
+	store.now = func() time.Time { return *now }
+	sched.now = store.now
+	return sched, p
+}
+
+func TestSchedulerCreate(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Hour)
+
+	testCases := []struct {
+		Name            string
+		Schedule        Schedule
+		ExpectedNextRun time.Time
+		ExpectedErr     error
+		ExpectedStatus  int
+	}{
+		{Name: "Run at", Schedule: Schedule{RunAt: &later}, ExpectedNextRun: later},
+		{Name: "Cron", Schedule: Schedule{Cron: "0 2 * * *"}, ExpectedNextRun: time.Date(2021, 3, 2, 2, 0, 0, 0, time.UTC)},
+		{
+			Name:            "Cron in time zone",
+			Schedule:        Schedule{Cron: "0 2 * * *", TimeZone: "America/New_York"},
+			ExpectedNextRun: time.Date(2021, 3, 2, 7, 0, 0, 0, time.UTC),
+		},
+		{Name: "Run at in the past", Schedule: Schedule{RunAt: &earlier}, ExpectedErr: ErrRunAtInPast},
+		{Name: "Run at and cron", Schedule: Schedule{RunAt: &later, Cron: "@daily"}, ExpectedErr: ErrInvalidTiming},
+		{Name: "No timing", ExpectedErr: ErrInvalidTiming},
+		{Name: "Run at with time zone", Schedule: Schedule{RunAt: &later, TimeZone: "UTC"}, ExpectedErr: ErrInvalidTimeZone},
+		{Name: "Unknown time zone", Schedule: Schedule{Cron: "@daily", TimeZone: "Mars/Olympus"}, ExpectedErr: ErrInvalidTimeZone},
+		{Name: "Invalid cron", Schedule: Schedule{Cron: "every day"}, ExpectedStatus: http.StatusBadRequest},
+		{Name: "Cron never matching", Schedule: Schedule{Cron: "0 0 30 2 *"}, ExpectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			s, _ := newTestScheduler(t, Config{}, nil, &now)
+
+			created, err := s.Create(context.Background(), tc.Schedule)
+			if tc.ExpectedErr != nil || tc.ExpectedStatus != 0 {
+				if tc.ExpectedErr != nil {
+					assert.Equal(tc.ExpectedErr, err)
+				} else {
+					assert.Equal(tc.ExpectedStatus, common.NewProblem(err, "").Status)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotEmpty(created.ID)
+			assert.Equal(StatusActive, created.Status)
+			assert.Equal(now, created.CreatedAt)
+			if assert.NotNil(created.NextRun) {
+				assert.True(tc.ExpectedNextRun.Equal(*created.NextRun), "expected %v, got %v", tc.ExpectedNextRun, *created.NextRun)
+			}
+
+			stored, err := s.store.Get(context.Background(), created.ID)
+			assert.NoError(err)
+			assert.Equal(created, stored)
+		})
+	}
+}
+
+func TestSchedulerRunDue(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	var lock sync.Mutex
+	sent := map[string]string{}
+	service := serviceFunc(func(_ context.Context, msg *wrp.Message, authHeaderValue string) (*common.XmidtResponse, error) {
+		lock.Lock()
+		sent[msg.Destination] = authHeaderValue
+		lock.Unlock()
+
+		if msg.Destination == "mac:665544332211/config" {
+			return &common.XmidtResponse{Code: http.StatusNotFound, Body: []byte("device not found")}, nil
+		}
+		return deviceResponse(`{"statusCode":200,"message":"Success"}`), nil
+	})
+
+	s, p := newTestScheduler(t, Config{}, service, &now)
+
+	runAt := now.Add(time.Minute)
+	oneOff, err := s.Create(ctx, Schedule{
+		Owner:      "client0",
+		PartnerIDs: []string{"comcast"},
+		DeviceIDs:  []string{"mac:112233445566", "mac:665544332211"},
+		Operation:  testOperation,
+		RunAt:      &runAt,
+	})
+	require.NoError(t, err)
+
+	recurring, err := s.Create(ctx, Schedule{DeviceIDs: []string{"mac:112233445566"}, Operation: testOperation, Cron: "*/5 * * * *"})
+	require.NoError(t, err)
+
+	// nothing is due yet
+	var runs sync.WaitGroup
+	next, err := s.runDue(ctx, &runs)
+	assert.NoError(err)
+	assert.Equal(runAt, next)
+
+	now = now.Add(time.Minute)
+	next, err = s.runDue(ctx, &runs)
+	runs.Wait()
+	assert.NoError(err)
+	assert.Equal(time.Date(2021, 3, 1, 12, 5, 0, 0, time.UTC), next)
+
+	completed, err := s.store.Get(ctx, oneOff.ID)
+	assert.NoError(err)
+	assert.Equal(StatusCompleted, completed.Status)
+	assert.Nil(completed.NextRun)
+	assert.Equal(&now, completed.EndedAt)
+
+	history, err := s.store.Runs(ctx, oneOff.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(runAt, history[0].ScheduledAt)
+	assert.Empty(history[0].Skipped)
+	if assert.Len(history[0].Results, 2) {
+		ok, failed := history[0].Results[0], history[0].Results[1]
+		assert.Equal("mac:112233445566", ok.DeviceID)
+		assert.NotEmpty(ok.TID)
+		assert.Equal(http.StatusOK, ok.StatusCode)
+		assert.JSONEq(`{"statusCode":200,"message":"Success"}`, string(ok.Response))
+		assert.Nil(ok.Error)
+
+		assert.Equal("mac:665544332211", failed.DeviceID)
+		assert.Equal(http.StatusNotFound, failed.StatusCode)
+		if assert.NotNil(failed.Error) {
+			assert.Equal(failed.TID, failed.Error.TID)
+		}
+	}
+
+	// operations are sent with the token of the service rather than of the caller
+	assert.Equal(map[string]string{"mac:112233445566/config": "", "mac:665544332211/config": ""}, sent)
+
+	// runs late by more than the misfire grace period are skipped and the schedule moves on
+	now = now.Add(time.Hour)
+	next, err = s.runDue(ctx, &runs)
+	runs.Wait()
+	assert.NoError(err)
+	assert.Equal(time.Date(2021, 3, 1, 13, 5, 0, 0, time.UTC), next)
+
+	history, err = s.store.Runs(ctx, recurring.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(SkippedMisfire, history[0].Skipped)
+	assert.Empty(history[0].Results)
+
+	p.Assert(t, RunsCounter, OutcomeLabel, outcomeExecuted)(xmetricstest.Value(1))
+	p.Assert(t, RunsCounter, OutcomeLabel, SkippedMisfire)(xmetricstest.Value(1))
+	p.Assert(t, OperationsCounter, common.CommandLabel, translation.CommandSet, common.CodeLabel, "200")(xmetricstest.Value(1))
+	p.Assert(t, OperationsCounter, common.CommandLabel, translation.CommandSet, common.CodeLabel, "404")(xmetricstest.Value(1))
+}
+
+func TestSchedulerRunAllowedDevices(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	var (
+		lock sync.Mutex
+		sent []string
+	)
+	service := serviceFunc(func(_ context.Context, msg *wrp.Message, _ string) (*common.XmidtResponse, error) {
+		lock.Lock()
+		sent = append(sent, msg.Destination)
+		lock.Unlock()
+		return deviceResponse(`{"statusCode":200}`), nil
+	})
+
+	s, _ := newTestScheduler(t, Config{}, service, &now)
+
+	runAt := now.Add(time.Minute)
+	sched, err := s.Create(ctx, Schedule{
+		DeviceIDs:      []string{"mac:112233445566", "mac:aabbccddeeff"},
+		AllowedDevices: []string{"mac:112233445566"},
+		Operation:      testOperation,
+		RunAt:          &runAt,
+	})
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	var runs sync.WaitGroup
+	_, err = s.runDue(ctx, &runs)
+	runs.Wait()
+	assert.NoError(err)
+
+	// operations are only sent to the devices the token of the owner allows
+	assert.Equal([]string{"mac:112233445566/config"}, sent)
+
+	history, err := s.store.Runs(ctx, sched.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	if assert.Len(history[0].Results, 2) {
+		forbidden := history[0].Results[1]
+		assert.Equal(http.StatusForbidden, forbidden.StatusCode)
+		if assert.NotNil(forbidden.Error) {
+			assert.Equal(ErrorCodeForbiddenDevice, forbidden.Error.Code)
+		}
+	}
+}
+
+func TestSchedulerRunDueWhileDraining(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	service := serviceFunc(func(context.Context, *wrp.Message, string) (*common.XmidtResponse, error) {
+		assert.Fail("operation sent while draining")
+		return deviceResponse(`{"statusCode":200}`), nil
+	})
+
+	s, _ := newTestScheduler(t, Config{}, service, &now)
+	s.drainer = common.NewDrainer(nil)
+
+	runAt := now.Add(time.Minute)
+	sched, err := s.Create(ctx, Schedule{DeviceIDs: []string{"mac:112233445566"}, Operation: testOperation, RunAt: &runAt})
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	s.drainer.Drain(0)
+
+	var runs sync.WaitGroup
+	next, err := s.runDue(ctx, &runs)
+	runs.Wait()
+	assert.NoError(err)
+	assert.True(next.IsZero())
+
+	// the schedule is still due for the next instance
+	due, err := s.store.Get(ctx, sched.ID)
+	require.NoError(t, err)
+	assert.Equal(StatusActive, due.Status)
+	assert.Equal(&runAt, due.NextRun)
+
+	history, err := s.store.Runs(ctx, sched.ID)
+	require.NoError(t, err)
+	assert.Empty(history)
+}
+
+func TestSchedulerOverlapAndCancel(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	service := serviceFunc(func(context.Context, *wrp.Message, string) (*common.XmidtResponse, error) {
+		started <- struct{}{}
+		<-release
+		return deviceResponse(`{"statusCode":200}`), nil
+	})
+
+	s, p := newTestScheduler(t, Config{MaxConcurrency: 1}, service, &now)
+	sched, err := s.Create(ctx, Schedule{
+		DeviceIDs: []string{"mac:112233445566", "mac:665544332211"},
+		Operation: testOperation,
+		Cron:      "* * * * *",
+	})
+	require.NoError(t, err)
+
+	var runs sync.WaitGroup
+	now = now.Add(time.Minute)
+	_, err = s.runDue(ctx, &runs)
+	assert.NoError(err)
+	<-started
+
+	// the next run is due while the first one is still in flight
+	now = now.Add(time.Minute)
+	_, err = s.runDue(ctx, &runs)
+	assert.NoError(err)
+
+	canceled, err := s.Cancel(ctx, sched.ID)
+	assert.NoError(err)
+	assert.Equal(StatusCanceled, canceled.Status)
+	assert.Nil(canceled.NextRun)
+
+	_, err = s.Cancel(ctx, sched.ID)
+	assert.Equal(ErrScheduleNotActive, err)
+
+	close(release)
+	runs.Wait()
+
+	history, err := s.store.Runs(ctx, sched.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	// the most recent run first, which is the first one as it's recorded once done
+	assert.Equal(SkippedOverlap, history[1].Skipped)
+	assert.Empty(history[0].Skipped)
+	if assert.Len(history[0].Results, 2) {
+		assert.Equal(http.StatusOK, history[0].Results[0].StatusCode)
+		assert.Equal(http.StatusConflict, history[0].Results[1].StatusCode)
+	}
+
+	p.Assert(t, RunsCounter, OutcomeLabel, SkippedOverlap)(xmetricstest.Value(1))
+}
+
+func TestSchedulerStart(t *testing.T) {
+	assert := assert.New(t)
+	sent := make(chan *wrp.Message, 1)
+	service := serviceFunc(func(_ context.Context, msg *wrp.Message, _ string) (*common.XmidtResponse, error) {
+		sent <- msg
+		return deviceResponse(`{"statusCode":200}`), nil
+	})
+
+	s := NewScheduler(Config{}, &Options{S: service, Store: newTestFileStore(t, FileConfig{})})
+	stop := s.Start()
+	defer stop()
+
+	// the scheduler wakes up for schedules created while it waits
+	runAt := time.Now().Add(50 * time.Millisecond)
+	sched, err := s.Create(context.Background(), Schedule{DeviceIDs: []string{"mac:112233445566"}, Operation: testOperation, RunAt: &runAt})
+	require.NoError(t, err)
+
+	select {
+	case msg := <-sent:
+		assert.Equal("mac:112233445566/config", msg.Destination)
+	case <-time.After(5 * time.Second):
+		assert.Fail("scheduled operation was not sent")
+	}
+
+	stop()
+	history, err := s.store.Runs(context.Background(), sched.ID)
+	assert.NoError(err)
+	assert.Len(history, 1)
+}
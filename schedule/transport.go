@@ -0,0 +1,244 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/translation"
+	"github.com/xmidt-org/webpa-common/device"
+)
+
+// Query parameters supported by the schedule list route.
+const (
+	ownerQueryKey    = "owner"
+	deviceIDQueryKey = "deviceID"
+	statusQueryKey   = "status"
+)
+
+// createRequest is the body of schedule creation requests.
+type createRequest struct {
+	DeviceIDs []string              `json:"deviceIDs"`
+	Operation translation.Operation `json:"operation"`
+	RunAt     *time.Time            `json:"runAt,omitempty"`
+	Cron      string                `json:"cron,omitempty"`
+	TimeZone  string                `json:"timeZone,omitempty"`
+}
+
+// caller is the principal of a request along with whether it may access all schedules.
+type caller struct {
+	principal string
+	admin     bool
+}
+
+func callerFromContext(ctx context.Context, adminCapability string) caller {
+	var c caller
+	if auth, ok := bascule.FromContext(ctx); ok {
+		c.principal = auth.Token.Principal()
+	}
+
+	c.admin = adminCapability != "" && common.HasCapability(ctx, adminCapability)
+	return c
+}
+
+type listRequest struct {
+	caller
+	filter Filter
+}
+
+type scheduleRequest struct {
+	caller
+	id string
+}
+
+// ConfigHandler sets up the routes to schedule operations, to list, inspect and cancel
+// schedules and to get their runs.
+func ConfigHandler(s *Scheduler, c *Options) {
+	transactionLogging := common.TransactionLogging(c.ReducedLoggingResponseCodes, c.Log)
+	validServices := func() []string { return c.ValidServices }
+	if c.Runtime != nil {
+		transactionLogging = common.RuntimeTransactionLogging(c.Runtime, c.Log)
+		validServices = func() []string { return c.Runtime.Load().ValidServices }
+	}
+
+	opts := []kithttp.ServerOption{
+		kithttp.ServerBefore(common.Capture(c.Log)),
+		kithttp.ServerErrorEncoder(common.ErrorLogEncoder(c.Log, encodeError)),
+		kithttp.ServerFinalizer(transactionLogging),
+	}
+
+	adminCapability := s.config.AdminCapability
+
+	createHandler := kithttp.NewServer(
+		makeCreateEndpoint(s),
+		decodeCreateRequest(s.config.MaxDevices, adminCapability, validServices, c.PartnerPolicy, c.ParameterPolicy, c.DeviceAccess),
+		encodeResponse(http.StatusCreated),
+		opts...,
+	)
+
+	listHandler := kithttp.NewServer(
+		makeListEndpoint(s),
+		decodeListRequest(adminCapability),
+		encodeResponse(http.StatusOK),
+		opts...,
+	)
+
+	getHandler := kithttp.NewServer(
+		makeGetEndpoint(s),
+		decodeScheduleRequest(adminCapability),
+		encodeResponse(http.StatusOK),
+		opts...,
+	)
+
+	cancelHandler := kithttp.NewServer(
+		makeCancelEndpoint(s),
+		decodeScheduleRequest(adminCapability),
+		encodeResponse(http.StatusOK),
+		opts...,
+	)
+
+	runsHandler := kithttp.NewServer(
+		makeRunsEndpoint(s),
+		decodeScheduleRequest(adminCapability),
+		encodeResponse(http.StatusOK),
+		opts...,
+	)
+
+	c.APIRouter.Handle("/schedules", c.Authenticate.Then(common.Welcome(createHandler))).Methods(http.MethodPost)
+	c.APIRouter.Handle("/schedules", c.Authenticate.Then(common.Welcome(listHandler))).Methods(http.MethodGet)
+	c.APIRouter.Handle("/schedules/{id}", c.Authenticate.Then(common.Welcome(getHandler))).Methods(http.MethodGet)
+	c.APIRouter.Handle("/schedules/{id}", c.Authenticate.Then(common.Welcome(cancelHandler))).Methods(http.MethodDelete)
+	c.APIRouter.Handle("/schedules/{id}/runs", c.Authenticate.Then(common.Welcome(runsHandler))).Methods(http.MethodGet)
+}
+
+// decodeCreateRequest decodes schedules with the same service, partner and parameter checks as
+// the requests of the translation routes. Operations are checked against the policies as they
+// are scheduled, with the partners and capabilities of the caller, rather than as they run.
+func decodeCreateRequest(maxDevices int, adminCapability string, validServices func() []string, partnerPolicy *common.PartnerPolicy, policy *translation.ParameterPolicy, deviceAccess DeviceAccess) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		var body createRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, ErrInvalidSchedule
+		}
+
+		if len(body.DeviceIDs) == 0 || len(body.DeviceIDs) > maxDevices {
+			return nil, ErrInvalidDevices
+		}
+
+		deviceIDs := make([]string, 0, len(body.DeviceIDs))
+		for _, deviceID := range body.DeviceIDs {
+			id, err := device.ParseID(deviceID)
+			if err != nil {
+				return nil, common.NewInvalidDeviceIDError(err)
+			}
+
+			if deviceAccess != nil {
+				if err := deviceAccess.Authorize(ctx, id); err != nil {
+					return nil, newForbiddenDeviceError(err)
+				}
+			}
+
+			if !contains(deviceIDs, string(id)) {
+				deviceIDs = append(deviceIDs, string(id))
+			}
+		}
+
+		if !contains(validServices(), body.Operation.Service) {
+			return nil, translation.ErrInvalidService
+		}
+
+		partnerIDs, err := partnerPolicy.PartnerIDs(ctx, r.Header)
+		if err != nil {
+			return nil, err
+		}
+
+		// the payload, hence the names it reads or writes, is the same for all devices
+		tid, _ := ctx.Value(common.ContextKeyRequestTID).(string)
+		wrpMsg, err := body.Operation.Message(deviceIDs[0], tid, partnerIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := policy.Authorize(ctx, wrpMsg); err != nil {
+			return nil, err
+		}
+
+		sched := &Schedule{
+			Owner:      callerFromContext(ctx, adminCapability).principal,
+			PartnerIDs: partnerIDs,
+			DeviceIDs:  deviceIDs,
+			Operation:  body.Operation,
+			Cron:       body.Cron,
+			TimeZone:   body.TimeZone,
+		}
+
+		if deviceAccess != nil {
+			if allowed, ok := deviceAccess.AllowedDevices(ctx); ok {
+				sched.AllowedDevices = allowed
+			}
+		}
+
+		if body.RunAt != nil {
+			runAt := body.RunAt.UTC()
+			sched.RunAt = &runAt
+		}
+		return sched, nil
+	}
+}
+
+// decodeListRequest restricts the list to the schedules of the caller unless they are an admin,
+// who may filter by owner.
+func decodeListRequest(adminCapability string) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		query := r.URL.Query()
+		req := &listRequest{caller: callerFromContext(ctx, adminCapability)}
+
+		req.filter.Owner = req.principal
+		if req.admin {
+			req.filter.Owner = query.Get(ownerQueryKey)
+		}
+
+		if deviceID := query.Get(deviceIDQueryKey); deviceID != "" {
+			id, err := device.ParseID(deviceID)
+			if err != nil {
+				return nil, common.NewInvalidDeviceIDError(err)
+			}
+			req.filter.DeviceID = string(id)
+		}
+
+		switch status := query.Get(statusQueryKey); status {
+		case "", StatusActive, StatusCompleted, StatusCanceled:
+			req.filter.Status = status
+		default:
+			return nil, ErrInvalidStatus
+		}
+		return req, nil
+	}
+}
+
+func decodeScheduleRequest(adminCapability string) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		return &scheduleRequest{
+			caller: callerFromContext(ctx, adminCapability),
+			id:     mux.Vars(r)["id"],
+		}, nil
+	}
+}
+
+func encodeResponse(code int) kithttp.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set(common.HeaderWPATID, ctx.Value(common.ContextKeyRequestTID).(string))
+		w.WriteHeader(code)
+		return json.NewEncoder(w).Encode(response)
+	}
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	common.WriteError(ctx, err, w)
+}
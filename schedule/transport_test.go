@@ -0,0 +1,234 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/translation"
+	"github.com/xmidt-org/webpa-common/basculechecks"
+	"github.com/xmidt-org/webpa-common/device"
+	"github.com/xmidt-org/webpa-common/logging"
+	"github.com/xmidt-org/wrp-go/v3/wrphttp"
+)
+
+// testPrincipalHeader carries the principal the requests of the tests are authenticated as.
+// Requests of the "admin" principal carry the admin capability.
+const testPrincipalHeader = "Test-Principal"
+
+// testDeviceAccess restricts every caller to the allowed devices.
+type testDeviceAccess []string
+
+func (a testDeviceAccess) Authorize(_ context.Context, deviceID device.ID) error {
+	if !common.NewAllowedDevices(a).Allows(deviceID) {
+		return errors.New("device not allowed")
+	}
+	return nil
+}
+
+func (a testDeviceAccess) AllowedDevices(context.Context) ([]string, bool) {
+	return a, true
+}
+
+func newTestRouter(t *testing.T, options ...func(*Options)) (*mux.Router, *Scheduler) {
+	authenticate := alice.New(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := r.Header.Get(testPrincipalHeader)
+			var capabilities []string
+			if principal == "admin" {
+				capabilities = []string{"schedules:admin"}
+			}
+
+			auth := bascule.Authentication{
+				Token: bascule.NewToken("basic", principal, bascule.NewAttributes(map[string]interface{}{
+					basculechecks.CapabilityKey: capabilities,
+				})),
+			}
+			next.ServeHTTP(w, r.WithContext(bascule.WithAuthentication(r.Context(), auth)))
+		})
+	})
+
+	router := mux.NewRouter()
+	store := newTestFileStore(t, FileConfig{})
+	store.now = func() time.Time { return time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC) }
+	o := &Options{
+		Store:         store,
+		APIRouter:     router,
+		Authenticate:  &authenticate,
+		Log:           logging.NewTestLogger(nil, t),
+		ValidServices: []string{"config"},
+		ParameterPolicy: &translation.ParameterPolicy{
+			Rules: []translation.ParameterRule{{Deny: []string{"Device.X_Secret"}}},
+		},
+	}
+	for _, option := range options {
+		option(o)
+	}
+
+	s := NewScheduler(Config{MaxDevices: 2, AdminCapability: "schedules:admin"}, o)
+	s.now = store.now
+	ConfigHandler(s, o)
+	return router, s
+}
+
+func serve(router *mux.Router, method, url, principal, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, url, strings.NewReader(body))
+	r.Header.Set(testPrincipalHeader, principal)
+	r.Header.Set(wrphttp.PartnerIdHeader, "comcast")
+	r.Header.Set("Accept", common.ProblemContentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestCreateSchedule(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Body           string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Run at",
+			Body:           `{"deviceIDs":["mac:11-22-33-44-55-66","mac:112233445566"],"runAt":"2021-03-01T13:00:00Z","operation":{"command":"SET","service":"config","parameters":[{"name":"Device.Enable","value":"true","dataType":3}]}}`,
+			ExpectedStatus: http.StatusCreated,
+		},
+		{
+			Name:           "Cron",
+			Body:           `{"deviceIDs":["mac:112233445566"],"cron":"0 2 * * 1-5","timeZone":"America/New_York","operation":{"command":"GET","service":"config","names":["Device.Enable"]}}`,
+			ExpectedStatus: http.StatusCreated,
+		},
+		{Name: "Invalid JSON", Body: `{`, ExpectedStatus: http.StatusBadRequest},
+		{Name: "No devices", Body: `{"deviceIDs":[],"cron":"@daily","operation":{"command":"GET","service":"config","names":["a"]}}`, ExpectedStatus: http.StatusBadRequest},
+		{
+			Name:           "Too many devices",
+			Body:           `{"deviceIDs":["mac:112233445566","mac:112233445567","mac:112233445568"],"cron":"@daily","operation":{"command":"GET","service":"config","names":["a"]}}`,
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{Name: "Invalid device", Body: `{"deviceIDs":["nope"],"cron":"@daily","operation":{"command":"GET","service":"config","names":["a"]}}`, ExpectedStatus: http.StatusBadRequest},
+		{Name: "Invalid service", Body: `{"deviceIDs":["mac:112233445566"],"cron":"@daily","operation":{"command":"GET","service":"other","names":["a"]}}`, ExpectedStatus: http.StatusBadRequest},
+		{Name: "Invalid operation", Body: `{"deviceIDs":["mac:112233445566"],"cron":"@daily","operation":{"command":"SET","service":"config"}}`, ExpectedStatus: http.StatusBadRequest},
+		{
+			Name:           "Forbidden parameters",
+			Body:           `{"deviceIDs":["mac:112233445566"],"cron":"@daily","operation":{"command":"GET","service":"config","names":["Device.X_Secret"]}}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{Name: "Invalid cron", Body: `{"deviceIDs":["mac:112233445566"],"cron":"daily","operation":{"command":"GET","service":"config","names":["a"]}}`, ExpectedStatus: http.StatusBadRequest},
+		{Name: "No timing", Body: `{"deviceIDs":["mac:112233445566"],"operation":{"command":"GET","service":"config","names":["a"]}}`, ExpectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			router, _ := newTestRouter(t)
+
+			w := serve(router, http.MethodPost, "/schedules", "client0", tc.Body)
+			assert.Equal(tc.ExpectedStatus, w.Code)
+			assert.NotEmpty(w.Header().Get(common.HeaderWPATID))
+			if tc.ExpectedStatus != http.StatusCreated {
+				assert.Equal(common.ProblemContentType, w.Header().Get("Content-Type"))
+				return
+			}
+
+			var created Schedule
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+			assert.NotEmpty(created.ID)
+			assert.Equal("client0", created.Owner)
+			assert.Equal([]string{"comcast"}, created.PartnerIDs)
+			assert.Equal(StatusActive, created.Status)
+			assert.NotNil(created.NextRun)
+		})
+	}
+}
+
+func TestCreateScheduleDeviceAccess(t *testing.T) {
+	assert := assert.New(t)
+	router, _ := newTestRouter(t, func(o *Options) {
+		o.DeviceAccess = testDeviceAccess{"mac:112233445566"}
+	})
+
+	w := serve(router, http.MethodPost, "/schedules", "client0", `{"deviceIDs":["mac:112233445566","mac:aabbccddeeff"],"cron":"@daily","operation":{"command":"GET","service":"config","names":["a"]}}`)
+	assert.Equal(http.StatusForbidden, w.Code)
+
+	var problem common.Problem
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+	assert.Equal(ErrorCodeForbiddenDevice, problem.Code)
+
+	w = serve(router, http.MethodPost, "/schedules", "client0", `{"deviceIDs":["mac:11-22-33-44-55-66"],"cron":"@daily","operation":{"command":"GET","service":"config","names":["a"]}}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created Schedule
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+	assert.Equal([]string{"mac:112233445566"}, created.AllowedDevices)
+}
+
+func TestScheduleAccess(t *testing.T) {
+	assert := assert.New(t)
+	router, _ := newTestRouter(t)
+
+	create := func(principal string) Schedule {
+		w := serve(router, http.MethodPost, "/schedules", principal, `{"deviceIDs":["mac:112233445566"],"cron":"@daily","operation":{"command":"GET","service":"config","names":["a"]}}`)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var created Schedule
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&created))
+		return created
+	}
+
+	list := func(principal, query string) []Schedule {
+		w := serve(router, http.MethodGet, "/schedules"+query, principal, "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var schedules []Schedule
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&schedules))
+		return schedules
+	}
+
+	own, other := create("client0"), create("client1")
+
+	// callers only list their own schedules while admins list everyone's
+	if schedules := list("client0", ""); assert.Len(schedules, 1) {
+		assert.Equal(own.ID, schedules[0].ID)
+	}
+	// the owner filter only applies to admins
+	assert.Len(list("client0", "?owner=client1"), 1)
+	assert.Len(list("admin", ""), 2)
+	if schedules := list("admin", "?owner=client1"); assert.Len(schedules, 1) {
+		assert.Equal(other.ID, schedules[0].ID)
+	}
+	assert.Len(list("client0", "?deviceID=mac:11-22-33-44-55-66&status=active"), 1)
+	assert.Empty(list("client0", "?status=canceled"))
+	assert.Equal(http.StatusBadRequest, serve(router, http.MethodGet, "/schedules?status=paused", "client0", "").Code)
+	assert.Equal(http.StatusBadRequest, serve(router, http.MethodGet, "/schedules?deviceID=nope", "client0", "").Code)
+
+	assert.Equal(http.StatusOK, serve(router, http.MethodGet, "/schedules/"+own.ID, "client0", "").Code)
+	assert.Equal(http.StatusForbidden, serve(router, http.MethodGet, "/schedules/"+other.ID, "client0", "").Code)
+	assert.Equal(http.StatusOK, serve(router, http.MethodGet, "/schedules/"+other.ID, "admin", "").Code)
+	assert.Equal(http.StatusNotFound, serve(router, http.MethodGet, "/schedules/unknown", "client0", "").Code)
+
+	w := serve(router, http.MethodGet, "/schedules/"+own.ID+"/runs", "client0", "")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.JSONEq(`[]`, w.Body.String())
+	assert.Equal(http.StatusForbidden, serve(router, http.MethodGet, "/schedules/"+other.ID+"/runs", "client0", "").Code)
+
+	assert.Equal(http.StatusForbidden, serve(router, http.MethodDelete, "/schedules/"+other.ID, "client0", "").Code)
+
+	w = serve(router, http.MethodDelete, "/schedules/"+own.ID, "client0", "")
+	assert.Equal(http.StatusOK, w.Code)
+
+	var canceled Schedule
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&canceled))
+	assert.Equal(StatusCanceled, canceled.Status)
+	assert.Equal(http.StatusConflict, serve(router, http.MethodDelete, "/schedules/"+own.ID, "client0", "").Code)
+	assert.Equal(http.StatusOK, serve(router, http.MethodDelete, "/schedules/"+other.ID, "admin", "").Code)
+}
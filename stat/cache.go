@@ -0,0 +1,190 @@
+package stat
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/metrics"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/webpa-common/xmetrics"
+)
+
+// Headers set on stat responses served from the last known snapshot of a device.
+const (
+	HeaderAge   = "Age"
+	HeaderStale = "X-Tr1d1um-Stale"
+)
+
+const allowStaleQueryKey = "allowStale"
+
+// DefaultCacheMaxDevices is the number of device snapshots kept when CacheOptions.MaxDevices is unset.
+const DefaultCacheMaxDevices = 10000
+
+// Metric names and labels for the stat package.
+const (
+	StaleResponsesCounter = "stat_stale_responses"
+
+	ReasonLabel = "reason"
+)
+
+// Stale response reasons.
+const (
+	notFoundReason      = "not_found"
+	upstreamErrorReason = "upstream_error"
+)
+
+// Metrics returns the metrics relevant to this package.
+func Metrics() []xmetrics.Metric {
+	return []xmetrics.Metric{
+		{
+			Name:       StaleResponsesCounter,
+			Type:       xmetrics.CounterType,
+			Help:       "Count of device stat responses served from the last known snapshot.",
+			LabelNames: []string{ReasonLabel},
+		},
+	}
+}
+
+// CacheOptions configures the store of the last successful stat response of each device.
+type CacheOptions struct {
+	// MaxDevices bounds the number of device snapshots kept. The least recently
+	// updated snapshots are evicted first.
+	// (Optional) Defaults to 10000.
+	MaxDevices int
+
+	// MaxAge is the oldest a snapshot can be and still be served.
+	// (Optional) By default, snapshots are served regardless of their age.
+	MaxAge time.Duration
+}
+
+type snapshot struct {
+	deviceID string
+	body     []byte
+	storedAt time.Time
+}
+
+// snapshotCache is a bounded LRU store of the last successful stat body per device.
+type snapshotCache struct {
+	maxDevices int
+	maxAge     time.Duration
+	now        func() time.Time
+
+	lock     sync.Mutex
+	order    *list.List
+	elements map[string]*list.Element
+}
+
+func newSnapshotCache(o CacheOptions) *snapshotCache {
+	if o.MaxDevices <= 0 {
+		o.MaxDevices = DefaultCacheMaxDevices
+	}
+
+	return &snapshotCache{
+		maxDevices: o.MaxDevices,
+		maxAge:     o.MaxAge,
+		now:        time.Now,
+		order:      list.New(),
+		elements:   make(map[string]*list.Element),
+	}
+}
+
+func (c *snapshotCache) store(deviceID string, body []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	s := &snapshot{deviceID: deviceID, body: body, storedAt: c.now()}
+	if e, ok := c.elements[deviceID]; ok {
+		e.Value = s
+		c.order.MoveToFront(e)
+		return
+	}
+
+	c.elements[deviceID] = c.order.PushFront(s)
+	for c.order.Len() > c.maxDevices {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.elements, oldest.Value.(*snapshot).deviceID)
+	}
+}
+
+func (c *snapshotCache) load(deviceID string) (*snapshot, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	e, ok := c.elements[deviceID]
+	if !ok {
+		return nil, false
+	}
+
+	s := e.Value.(*snapshot)
+	if c.maxAge > 0 && c.now().Sub(s.storedAt) > c.maxAge {
+		return nil, false
+	}
+	return s, true
+}
+
+// recording decorates s such that every successful stat response is kept as the device's last known snapshot.
+func (c *snapshotCache) recording(s Service) Service {
+	return &recordingService{Service: s, cache: c}
+}
+
+type recordingService struct {
+	Service
+	cache *snapshotCache
+}
+
+func (r *recordingService) RequestStat(ctx context.Context, authHeaderValue, deviceID string) (*common.XmidtResponse, error) {
+	resp, err := r.Service.RequestStat(ctx, authHeaderValue, deviceID)
+	if err == nil && resp.Code == http.StatusOK {
+		r.cache.store(deviceID, resp.Body)
+	}
+	return resp, err
+}
+
+// staleFallback is an endpoint middleware that, for requests which opted in, replaces a
+// device not found or an upstream failure response with the last known snapshot of the device.
+func staleFallback(c *snapshotCache, staleResponses metrics.Counter) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			response, err := next(ctx, request)
+
+			statReq := request.(*statRequest)
+			if !statReq.AllowStale {
+				return response, err
+			}
+
+			var reason string
+			if err != nil {
+				reason = upstreamErrorReason
+			} else if resp := response.(*common.XmidtResponse); resp.Code == http.StatusNotFound {
+				reason = notFoundReason
+			} else if resp.Code >= http.StatusInternalServerError {
+				reason = upstreamErrorReason
+			} else {
+				return response, err
+			}
+
+			s, ok := c.load(statReq.DeviceID)
+			if !ok {
+				return response, err
+			}
+
+			staleResponses.With(ReasonLabel, reason).Add(1)
+
+			headers := make(http.Header)
+			headers.Set(HeaderAge, strconv.FormatInt(int64(c.now().Sub(s.storedAt).Seconds()), 10))
+			headers.Set(HeaderStale, "true")
+
+			return &common.XmidtResponse{
+				Code:             http.StatusOK,
+				ForwardedHeaders: headers,
+				Body:             s.body,
+			}, nil
+		}
+	}
+}
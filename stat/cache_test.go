@@ -0,0 +1,175 @@
+package stat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/xmidt-org/tr1d1um/common"
+)
+
+func TestSnapshotCacheEviction(t *testing.T) {
+	assert := assert.New(t)
+	c := newSnapshotCache(CacheOptions{MaxDevices: 2})
+
+	c.store("mac:000000000001", []byte("1"))
+	c.store("mac:000000000002", []byte("2"))
+	c.store("mac:000000000001", []byte("1b"))
+	c.store("mac:000000000003", []byte("3"))
+
+	s, ok := c.load("mac:000000000001")
+	assert.True(ok)
+	assert.Equal([]byte("1b"), s.body)
+
+	_, ok = c.load("mac:000000000002")
+	assert.False(ok)
+
+	_, ok = c.load("mac:000000000003")
+	assert.True(ok)
+}
+
+func TestSnapshotCacheMaxAge(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	c := newSnapshotCache(CacheOptions{MaxAge: time.Minute})
+	c.now = func() time.Time { return now }
+
+	c.store("mac:112233445566", []byte("{}"))
+	_, ok := c.load("mac:112233445566")
+	assert.True(ok)
+
+	now = now.Add(2 * time.Minute)
+	_, ok = c.load("mac:112233445566")
+	assert.False(ok)
+}
+
+func TestRecordingService(t *testing.T) {
+	assert := assert.New(t)
+	c := newSnapshotCache(CacheOptions{})
+	m := new(MockService)
+	m.On("RequestStat", context.TODO(), "a0", "mac:000000000001").Return(&common.XmidtResponse{Code: http.StatusOK, Body: []byte("ok")}, nil)
+	m.On("RequestStat", context.TODO(), "a0", "mac:000000000002").Return(&common.XmidtResponse{Code: http.StatusNotFound}, nil)
+
+	s := c.recording(m)
+	s.RequestStat(context.TODO(), "a0", "mac:000000000001")
+	s.RequestStat(context.TODO(), "a0", "mac:000000000002")
+
+	_, ok := c.load("mac:000000000001")
+	assert.True(ok)
+	_, ok = c.load("mac:000000000002")
+	assert.False(ok)
+}
+
+func TestStaleFallback(t *testing.T) {
+	var (
+		deviceID = "mac:112233445566"
+		upstream = errors.New("upstream")
+	)
+
+	testCases := []struct {
+		Name           string
+		AllowStale     bool
+		Snapshot       bool
+		Response       *common.XmidtResponse
+		Err            error
+		ExpectStale    bool
+		ExpectedReason string
+	}{
+		{
+			Name:     "Not opted in",
+			Snapshot: true,
+			Response: &common.XmidtResponse{Code: http.StatusNotFound},
+		},
+		{
+			Name:       "Success is not replaced",
+			AllowStale: true,
+			Snapshot:   true,
+			Response:   &common.XmidtResponse{Code: http.StatusOK, Body: []byte("fresh")},
+		},
+		{
+			Name:       "No snapshot",
+			AllowStale: true,
+			Response:   &common.XmidtResponse{Code: http.StatusNotFound},
+		},
+		{
+			Name:           "Not found",
+			AllowStale:     true,
+			Snapshot:       true,
+			Response:       &common.XmidtResponse{Code: http.StatusNotFound},
+			ExpectStale:    true,
+			ExpectedReason: notFoundReason,
+		},
+		{
+			Name:           "Upstream error",
+			AllowStale:     true,
+			Snapshot:       true,
+			Err:            upstream,
+			ExpectStale:    true,
+			ExpectedReason: upstreamErrorReason,
+		},
+		{
+			Name:           "Upstream 5xx",
+			AllowStale:     true,
+			Snapshot:       true,
+			Response:       &common.XmidtResponse{Code: http.StatusBadGateway},
+			ExpectStale:    true,
+			ExpectedReason: upstreamErrorReason,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			now := time.Now()
+			c := newSnapshotCache(CacheOptions{})
+			c.now = func() time.Time { return now }
+			if testCase.Snapshot {
+				c.store(deviceID, []byte("cached"))
+				now = now.Add(90 * time.Second)
+			}
+
+			s := new(MockService)
+			s.On("RequestStat", mock.Anything, "a0", deviceID).Return(testCase.Response, testCase.Err)
+
+			counter := new(testCounter)
+			e := staleFallback(c, counter)(makeStatEndpoint(s))
+
+			resp, err := e(context.TODO(), &statRequest{DeviceID: deviceID, AuthHeaderValue: "a0", AllowStale: testCase.AllowStale})
+
+			if !testCase.ExpectStale {
+				assert.Equal(testCase.Err, err)
+				assert.Equal(testCase.Response, resp)
+				assert.Zero(counter.value)
+				return
+			}
+
+			assert.Nil(err)
+			xmidtResp := resp.(*common.XmidtResponse)
+			assert.Equal(http.StatusOK, xmidtResp.Code)
+			assert.Equal([]byte("cached"), xmidtResp.Body)
+			assert.Equal("90", xmidtResp.ForwardedHeaders.Get(HeaderAge))
+			assert.Equal("true", xmidtResp.ForwardedHeaders.Get(HeaderStale))
+			assert.Equal([]string{ReasonLabel, testCase.ExpectedReason}, counter.labelValues)
+			assert.EqualValues(1, counter.value)
+		})
+	}
+}
+
+type testCounter struct {
+	labelValues []string
+	value       float64
+}
+
+func (c *testCounter) With(labelValues ...string) metrics.Counter {
+	c.labelValues = append(c.labelValues, labelValues...)
+	return c
+}
+
+func (c *testCounter) Add(delta float64) {
+	c.value += delta
+}
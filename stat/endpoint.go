@@ -9,6 +9,7 @@ import (
 type statRequest struct {
 	DeviceID        string
 	AuthHeaderValue string
+	AllowStale      bool
 }
 
 func makeStatEndpoint(s Service) endpoint.Endpoint {
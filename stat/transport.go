@@ -2,14 +2,15 @@ package stat
 
 import (
 	"context"
-	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/xmidt-org/tr1d1um/common"
 
 	"github.com/xmidt-org/webpa-common/device"
 
 	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics/provider"
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
 	"github.com/justinas/alice"
@@ -24,56 +25,93 @@ type Options struct {
 	Authenticate                *alice.Chain
 	Log                         kitlog.Logger
 	ReducedLoggingResponseCodes []int
+
+	//Watch enables the device presence watch endpoint when set.
+	//(Optional)
+	Watch *WatchOptions
+
+	//Cache enables keeping the last successful stat response of each device so it
+	//can be served when the device is not found or the XMiDT cluster fails.
+	//(Optional)
+	Cache *CacheOptions
+
+	//MetricsProvider helps initialize the metrics of this package.
+	//(Optional) Defaults to a discard provider.
+	MetricsProvider provider.Provider
+
+	//Runtime, when set, overrides ReducedLoggingResponseCodes with the ones of its current snapshot.
+	//(Optional)
+	Runtime *common.Runtime
+
+	//Drainer tracks the requests that must complete before shutting down and rejects the
+	//ones arriving while draining.
+	//(Optional)
+	Drainer *common.Drainer
 }
 
 // ConfigHandler sets up the server that powers the stat service
 // That is, it configures the mux paths to access the service
 func ConfigHandler(c *Options) {
+	transactionLogging := common.TransactionLogging(c.ReducedLoggingResponseCodes, c.Log)
+	if c.Runtime != nil {
+		transactionLogging = common.RuntimeTransactionLogging(c.Runtime, c.Log)
+	}
+
+	if c.MetricsProvider == nil {
+		c.MetricsProvider = provider.NewDiscardProvider()
+	}
+
+	routeMetrics := common.NewRouteMetrics(c.MetricsProvider)
 	opts := []kithttp.ServerOption{
-		kithttp.ServerBefore(common.Capture(c.Log)),
+		kithttp.ServerBefore(common.Capture(c.Log), routeMetrics.Before),
 		kithttp.ServerErrorEncoder(common.ErrorLogEncoder(c.Log, encodeError)),
-		kithttp.ServerFinalizer(common.TransactionLogging(c.ReducedLoggingResponseCodes, c.Log)),
+		kithttp.ServerFinalizer(transactionLogging, routeMetrics.Finalizer),
+	}
+
+	s, statEndpoint := c.S, makeStatEndpoint(c.S)
+	if c.Cache != nil {
+		cache := newSnapshotCache(*c.Cache)
+		s = cache.recording(c.S)
+		statEndpoint = staleFallback(cache, c.MetricsProvider.NewCounter(StaleResponsesCounter))(makeStatEndpoint(s))
 	}
 
 	statHandler := kithttp.NewServer(
-		makeStatEndpoint(c.S),
+		statEndpoint,
 		decodeRequest,
 		encodeResponse,
 		opts...,
 	)
 
-	c.APIRouter.Handle("/device/{deviceid}/stat", c.Authenticate.Then(common.Welcome(statHandler))).
+	if c.Watch != nil {
+		c.APIRouter.Handle("/device/{deviceid}/stat/watch", c.Authenticate.Then(common.Welcome(newPresenceHub(s, *c.Watch, c.Log)))).
+			Methods(http.MethodGet)
+	}
+
+	c.APIRouter.Handle("/device/{deviceid}/stat", c.Drainer.Track(c.Authenticate.Then(common.Welcome(statHandler)))).
 		Methods(http.MethodGet)
 }
 
-func decodeRequest(_ context.Context, r *http.Request) (req interface{}, err error) {
+func decodeRequest(ctx context.Context, r *http.Request) (req interface{}, err error) {
+	_, span := common.StartSpan(ctx, common.DecodeRequestSpan, common.DeviceIDAttribute.String(mux.Vars(r)["deviceid"]))
+	defer func() { common.EndSpan(span, err) }()
+
 	var deviceID device.ID
 	if deviceID, err = device.ParseID(mux.Vars(r)["deviceid"]); err == nil {
+		allowStale, _ := strconv.ParseBool(r.URL.Query().Get(allowStaleQueryKey))
 		req = &statRequest{
 			AuthHeaderValue: r.Header.Get("Authorization"),
 			DeviceID:        string(deviceID),
+			AllowStale:      allowStale,
 		}
 	} else {
-		err = common.NewBadRequestError(err)
+		err = common.NewInvalidDeviceIDError(err)
 	}
 
 	return
 }
 
 func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.Header().Set(common.HeaderWPATID, ctx.Value(common.ContextKeyRequestTID).(string))
-
-	if ce, ok := err.(common.CodedError); ok {
-		w.WriteHeader(ce.StatusCode())
-	} else {
-		w.WriteHeader(http.StatusInternalServerError)
-		err = common.ErrTr1d1umInternal
-	}
-
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": err.Error(),
-	})
+	common.WriteError(ctx, err, w)
 }
 
 // encodeResponse simply forwards the response Tr1d1um got from the XMiDT API
@@ -0,0 +1,64 @@
+package stat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	kitlog "github.com/go-kit/kit/log"
+	kitgrpc "github.com/go-kit/kit/transport/grpc"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/rpc/tr1d1umpb"
+	"github.com/xmidt-org/webpa-common/device"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// GRPCOptions wraps the properties needed to serve the Stat RPC.
+type GRPCOptions struct {
+	S   Service
+	Log kitlog.Logger
+}
+
+// NewGRPCHandler sets up the handler of the Stat RPC of the gRPC API.
+func NewGRPCHandler(o *GRPCOptions) kitgrpc.Handler {
+	return kitgrpc.NewServer(
+		makeStatEndpoint(o.S),
+		decodeGRPCRequest,
+		encodeGRPCResponse,
+		kitgrpc.ServerBefore(common.CaptureGRPC(o.Log)),
+		kitgrpc.ServerErrorHandler(common.GRPCErrorLogger(o.Log)),
+		kitgrpc.ServerFinalizer(common.GRPCTransactionLogging),
+	)
+}
+
+func decodeGRPCRequest(ctx context.Context, request interface{}) (interface{}, error) {
+	deviceID, err := device.ParseID(request.(*tr1d1umpb.StatRequest).DeviceId)
+	if err != nil {
+		return nil, common.NewInvalidDeviceIDError(err)
+	}
+
+	return &statRequest{
+		AuthHeaderValue: common.RequestHeader(ctx).Get("Authorization"),
+		DeviceID:        string(deviceID),
+	}, nil
+}
+
+// encodeGRPCResponse returns the statistics of the device. Non-200 responses of the XMiDT
+// cluster are errors.
+func encodeGRPCResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(*common.XmidtResponse)
+	if resp.Code != http.StatusOK {
+		return nil, common.NewXmidtResponseError(resp)
+	}
+
+	var model map[string]interface{}
+	if err := json.Unmarshal(resp.Body, &model); err != nil {
+		return nil, err
+	}
+
+	s, err := structpb.NewStruct(model)
+	if err != nil {
+		return nil, err
+	}
+	return &tr1d1umpb.StatResponse{Stat: s}, nil
+}
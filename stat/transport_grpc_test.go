@@ -0,0 +1,67 @@
+package stat
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/rpc/tr1d1umpb"
+	"github.com/xmidt-org/webpa-common/logging"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGRPCHandler(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		DeviceID     string
+		Response     *common.XmidtResponse
+		ExpectedStat map[string]interface{}
+		ExpectedCode int
+	}{
+		{
+			Name:         "OK",
+			DeviceID:     "mac:112233445566",
+			Response:     &common.XmidtResponse{Code: http.StatusOK, Body: []byte(`{"id":"mac:112233445566","pending":0}`)},
+			ExpectedStat: map[string]interface{}{"id": "mac:112233445566", "pending": float64(0)},
+		},
+		{
+			Name:         "Not Found",
+			DeviceID:     "mac:112233445566",
+			Response:     &common.XmidtResponse{Code: http.StatusNotFound},
+			ExpectedCode: http.StatusNotFound,
+		},
+		{
+			Name:         "Invalid Device",
+			DeviceID:     "mac:1122@#8!!",
+			ExpectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			s := new(MockService)
+			if tc.Response != nil {
+				s.On("RequestStat", mock.Anything, "Basic dGVzdDp0ZXN0", tc.DeviceID).Return(tc.Response, nil)
+			}
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Basic dGVzdDp0ZXN0"))
+			_, response, err := NewGRPCHandler(&GRPCOptions{S: s, Log: logging.NewTestLogger(nil, t)}).
+				ServeGRPC(ctx, &tr1d1umpb.StatRequest{DeviceId: tc.DeviceID})
+
+			if tc.ExpectedCode != 0 {
+				require.NotNil(t, err)
+				assert.Equal(tc.ExpectedCode, err.(common.CodedError).StatusCode())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(tc.ExpectedStat, response.(*tr1d1umpb.StatResponse).Stat.AsMap())
+			s.AssertExpectations(t)
+		})
+	}
+}
@@ -51,6 +51,19 @@ func TestDecodeRequest(t *testing.T) {
 			DeviceID:        "mac:112233445566",
 		}, resp.(*statRequest))
 	})
+
+	t.Run("AllowStale", func(t *testing.T) {
+		var assert = assert.New(t)
+
+		var r = httptest.NewRequest(http.MethodGet, "http://localhost:8090/api/stat?allowStale=true", nil)
+
+		r = mux.SetURLVars(r, map[string]string{"deviceid": "mac:112233445566"})
+
+		resp, err := decodeRequest(ctxTID, r)
+
+		assert.Nil(err)
+		assert.True(resp.(*statRequest).AllowStale)
+	})
 }
 
 func TestEncodeError(t *testing.T) {
@@ -0,0 +1,426 @@
+package stat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/gorilla/mux"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/webpa-common/device"
+	"github.com/xmidt-org/webpa-common/logging"
+)
+
+// Device connection states reported by the presence watch.
+const (
+	StateOnline  = "online"
+	StateOffline = "offline"
+	StateUnknown = "unknown"
+)
+
+const (
+	presenceEventName = "presence"
+	intervalQueryKey  = "interval"
+)
+
+// ErrInvalidWatchInterval is returned when a watcher requests a poll interval that is not a positive duration.
+var ErrInvalidWatchInterval = common.NewProblemError(errors.New("interval must be a positive duration such as '30s'"), http.StatusBadRequest, "invalid-watch-interval", "Invalid watch interval", "interval")
+
+var errStreamingUnsupported = errors.New("response writer does not support streaming")
+
+// Default values for the presence watch. They apply when the corresponding
+// WatchOptions field is left unset.
+const (
+	DefaultPollInterval      = 10 * time.Second
+	DefaultMinPollInterval   = 2 * time.Second
+	DefaultMaxPollInterval   = 5 * time.Minute
+	DefaultHeartbeatInterval = 15 * time.Second
+)
+
+// WatchOptions configures the device presence watch (Server-Sent Events) endpoint.
+type WatchOptions struct {
+	// PollInterval is how often the XMiDT stat endpoint is polled for a watched device
+	// when watchers don't request a specific interval.
+	// (Optional) Defaults to 10s.
+	PollInterval time.Duration
+
+	// MinPollInterval and MaxPollInterval bound the interval watchers can request
+	// through the 'interval' query parameter.
+	// (Optional) Default to 2s and 5m respectively.
+	MinPollInterval time.Duration
+	MaxPollInterval time.Duration
+
+	// HeartbeatInterval is how often an SSE comment is written to idle watchers
+	// so intermediaries don't close the connection.
+	// (Optional) Defaults to 15s.
+	HeartbeatInterval time.Duration
+}
+
+func (o WatchOptions) withDefaults() WatchOptions {
+	if o.PollInterval <= 0 {
+		o.PollInterval = DefaultPollInterval
+	}
+	if o.MinPollInterval <= 0 {
+		o.MinPollInterval = DefaultMinPollInterval
+	}
+	if o.MaxPollInterval <= 0 {
+		o.MaxPollInterval = DefaultMaxPollInterval
+	}
+	if o.MaxPollInterval < o.MinPollInterval {
+		o.MaxPollInterval = o.MinPollInterval
+	}
+	if o.HeartbeatInterval <= 0 {
+		o.HeartbeatInterval = DefaultHeartbeatInterval
+	}
+	return o
+}
+
+// Presence is the payload of every presence event sent to watchers.
+type Presence struct {
+	DeviceID    string    `json:"deviceID"`
+	State       string    `json:"state"`
+	Code        int       `json:"code,omitempty"`
+	ConnectedAt string    `json:"connectedAt,omitempty"`
+	ObservedAt  time.Time `json:"observedAt"`
+}
+
+// changed reports whether p represents a different connection state or session than o.
+func (p Presence) changed(o Presence) bool {
+	return p.State != o.State || p.ConnectedAt != o.ConnectedAt
+}
+
+type statBody struct {
+	Statistics struct {
+		ConnectedAt string `json:"connectedAt"`
+	} `json:"statistics"`
+}
+
+// presenceFromResult derives the device presence out of a stat transaction result.
+// A device's session is identified by its connection timestamp.
+func presenceFromResult(deviceID string, code int, body []byte, err error) Presence {
+	p := Presence{DeviceID: deviceID, State: StateUnknown, Code: code, ObservedAt: time.Now()}
+	if err != nil {
+		return p
+	}
+
+	switch code {
+	case http.StatusOK:
+		p.State = StateOnline
+		var b statBody
+		if json.Unmarshal(body, &b) == nil {
+			p.ConnectedAt = b.Statistics.ConnectedAt
+		}
+	case http.StatusNotFound:
+		p.State = StateOffline
+	}
+	return p
+}
+
+// watcher is a single client subscribed to presence changes of a device.
+type watcher struct {
+	interval        time.Duration
+	authHeaderValue string
+	events          chan Presence
+}
+
+// pollerKey identifies the poller of a device for a single principal so that
+// the credentials of one caller are never used to poll on behalf of another.
+type pollerKey struct {
+	deviceID  string
+	principal string
+}
+
+// watchPrincipal returns the principal of the watch request. Requests without
+// a bascule authentication are told apart by their credentials.
+func watchPrincipal(r *http.Request) string {
+	if auth, ok := bascule.FromContext(r.Context()); ok && auth.Token != nil {
+		return "principal:" + auth.Token.Principal()
+	}
+	return "authorization:" + r.Header.Get("Authorization")
+}
+
+// poller periodically requests the stats of a single device on behalf of all
+// the watchers of a principal.
+type poller struct {
+	deviceID string
+	watchers map[*watcher]bool
+	last     *Presence
+	reset    chan struct{}
+	stop     chan struct{}
+}
+
+// interval returns the smallest interval requested among the current watchers.
+func (p *poller) interval() time.Duration {
+	var min time.Duration
+	for w := range p.watchers {
+		if min == 0 || w.interval < min {
+			min = w.interval
+		}
+	}
+	return min
+}
+
+// authHeaderValue picks the credentials of any current watcher for the upstream request.
+// Watchers of a poller all belong to the same principal.
+func (p *poller) authHeaderValue() string {
+	for w := range p.watchers {
+		return w.authHeaderValue
+	}
+	return ""
+}
+
+// presenceHub multiplexes watchers over a single poller per device and principal
+// so that many watchers on one device cause one upstream poll per principal.
+type presenceHub struct {
+	s       Service
+	logger  kitlog.Logger
+	options WatchOptions
+
+	lock    sync.Mutex
+	pollers map[pollerKey]*poller
+}
+
+func newPresenceHub(s Service, o WatchOptions, logger kitlog.Logger) *presenceHub {
+	return &presenceHub{
+		s:       s,
+		logger:  logger,
+		options: o.withDefaults(),
+		pollers: make(map[pollerKey]*poller),
+	}
+}
+
+// subscribe registers w for presence changes of the given device, starting a poller if needed.
+func (h *presenceHub) subscribe(key pollerKey, w *watcher) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	p, ok := h.pollers[key]
+	if !ok {
+		p = &poller{
+			deviceID: key.deviceID,
+			watchers: make(map[*watcher]bool),
+			reset:    make(chan struct{}, 1),
+			stop:     make(chan struct{}),
+		}
+		h.pollers[key] = p
+		p.watchers[w] = true
+		go h.poll(p)
+		return
+	}
+
+	previous := p.interval()
+	p.watchers[w] = true
+	if p.last != nil {
+		w.events <- *p.last
+	}
+	if w.interval < previous {
+		h.signalReset(p)
+	}
+}
+
+// unsubscribe removes w, stopping the device poller once it has no more watchers.
+func (h *presenceHub) unsubscribe(key pollerKey, w *watcher) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	p, ok := h.pollers[key]
+	if !ok {
+		return
+	}
+
+	previous := p.interval()
+	delete(p.watchers, w)
+	if len(p.watchers) == 0 {
+		delete(h.pollers, key)
+		close(p.stop)
+		return
+	}
+	if p.interval() != previous {
+		h.signalReset(p)
+	}
+}
+
+func (h *presenceHub) signalReset(p *poller) {
+	select {
+	case p.reset <- struct{}{}:
+	default:
+	}
+}
+
+func (h *presenceHub) poll(p *poller) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		<-p.stop
+		cancel()
+	}()
+
+	h.lock.Lock()
+	interval := p.interval()
+	h.lock.Unlock()
+
+	var lastPoll time.Time
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-p.reset:
+			h.lock.Lock()
+			interval = p.interval()
+			h.lock.Unlock()
+
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			// the next poll is due an interval after the last one, so that watchers
+			// coming and going don't keep postponing it
+			timer.Reset(time.Until(lastPoll.Add(interval)))
+			continue
+		case <-timer.C:
+		}
+
+		lastPoll = time.Now()
+		h.lock.Lock()
+		authHeaderValue := p.authHeaderValue()
+		h.lock.Unlock()
+
+		var (
+			code int
+			body []byte
+		)
+		resp, err := h.s.RequestStat(ctx, authHeaderValue, p.deviceID)
+		if err == nil {
+			code, body = resp.Code, resp.Body
+		} else if ctx.Err() == nil {
+			logging.Debug(h.logger).Log(logging.MessageKey(), "presence poll failed", "deviceID", p.deviceID, logging.ErrorKey(), err)
+		}
+
+		h.publish(p, presenceFromResult(p.deviceID, code, body, err))
+		timer.Reset(interval)
+	}
+}
+
+// publish notifies watchers of the given presence only if the device connection
+// state or session changed since the last poll.
+func (h *presenceHub) publish(p *poller, presence Presence) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if p.last != nil && !presence.changed(*p.last) {
+		return
+	}
+	p.last = &presence
+
+	for w := range p.watchers {
+		select {
+		case w.events <- presence:
+		default:
+			// slow watchers only miss intermediate states; the latest one is always kept
+			select {
+			case <-w.events:
+			default:
+			}
+			w.events <- presence
+		}
+	}
+}
+
+// requestedInterval reads the poll interval requested by a watcher, bounded by the configured limits.
+func (h *presenceHub) requestedInterval(r *http.Request) (time.Duration, error) {
+	raw := r.URL.Query().Get(intervalQueryKey)
+	if raw == "" {
+		return h.options.PollInterval, nil
+	}
+
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval <= 0 {
+		return 0, ErrInvalidWatchInterval
+	}
+
+	if interval < h.options.MinPollInterval {
+		interval = h.options.MinPollInterval
+	} else if interval > h.options.MaxPollInterval {
+		interval = h.options.MaxPollInterval
+	}
+	return interval, nil
+}
+
+// ServeHTTP streams presence events of a device to the client until it disconnects.
+func (h *presenceHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := common.Capture(h.logger)(r.Context(), r)
+
+	deviceID, err := device.ParseID(mux.Vars(r)["deviceid"])
+	if err != nil {
+		encodeError(ctx, common.NewInvalidDeviceIDError(err), w)
+		return
+	}
+
+	interval, err := h.requestedInterval(r)
+	if err != nil {
+		encodeError(ctx, err, w)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		encodeError(ctx, errStreamingUnsupported, w)
+		return
+	}
+
+	wt := &watcher{
+		interval:        interval,
+		authHeaderValue: r.Header.Get("Authorization"),
+		events:          make(chan Presence, 1),
+	}
+
+	w.Header().Set(common.HeaderWPATID, ctx.Value(common.ContextKeyRequestTID).(string))
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	key := pollerKey{deviceID: string(deviceID), principal: watchPrincipal(r)}
+	h.subscribe(key, wt)
+	defer h.unsubscribe(key, wt)
+
+	logging.Info(h.logger).Log(logging.MessageKey(), "presence watch started", "deviceID", deviceID, "interval", interval)
+	defer logging.Info(h.logger).Log(logging.MessageKey(), "presence watch ended", "deviceID", deviceID)
+
+	heartbeat := time.NewTicker(h.options.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for id := 1; ; {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case presence := <-wt.events:
+			data, err := json.Marshal(presence)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, presenceEventName, data); err != nil {
+				return
+			}
+			id++
+		}
+		flusher.Flush()
+	}
+}
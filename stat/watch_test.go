@@ -0,0 +1,218 @@
+package stat
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/webpa-common/logging"
+)
+
+func TestPresenceFromResult(t *testing.T) {
+	testCases := []struct {
+		Name                string
+		Code                int
+		Body                string
+		Err                 error
+		ExpectedState       string
+		ExpectedConnectedAt string
+	}{
+		{
+			Name:                "Online",
+			Code:                http.StatusOK,
+			Body:                `{"id": "mac:112233445566", "pending": 0, "statistics": {"connectedAt": "2021-05-01T10:00:00Z"}}`,
+			ExpectedState:       StateOnline,
+			ExpectedConnectedAt: "2021-05-01T10:00:00Z",
+		},
+		{
+			Name:          "Offline",
+			Code:          http.StatusNotFound,
+			ExpectedState: StateOffline,
+		},
+		{
+			Name:          "Upstream error",
+			Err:           errors.New("network error"),
+			ExpectedState: StateUnknown,
+		},
+		{
+			Name:          "Unexpected code",
+			Code:          http.StatusInternalServerError,
+			ExpectedState: StateUnknown,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			p := presenceFromResult("mac:112233445566", testCase.Code, []byte(testCase.Body), testCase.Err)
+			assert.Equal(testCase.ExpectedState, p.State)
+			assert.Equal(testCase.ExpectedConnectedAt, p.ConnectedAt)
+			assert.Equal("mac:112233445566", p.DeviceID)
+		})
+	}
+}
+
+func TestRequestedInterval(t *testing.T) {
+	h := newPresenceHub(nil, WatchOptions{PollInterval: 10 * time.Second, MinPollInterval: 5 * time.Second, MaxPollInterval: time.Minute}, logging.DefaultLogger())
+
+	testCases := []struct {
+		Query       string
+		Expected    time.Duration
+		ExpectedErr error
+	}{
+		{Query: "", Expected: 10 * time.Second},
+		{Query: "interval=20s", Expected: 20 * time.Second},
+		{Query: "interval=1s", Expected: 5 * time.Second},
+		{Query: "interval=1h", Expected: time.Minute},
+		{Query: "interval=nope", ExpectedErr: ErrInvalidWatchInterval},
+		{Query: "interval=-3s", ExpectedErr: ErrInvalidWatchInterval},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Query, func(t *testing.T) {
+			assert := assert.New(t)
+			r := httptest.NewRequest(http.MethodGet, "http://localhost/stat/watch?"+testCase.Query, nil)
+			interval, err := h.requestedInterval(r)
+			assert.Equal(testCase.ExpectedErr, err)
+			assert.Equal(testCase.Expected, interval)
+		})
+	}
+}
+
+func TestPresenceHubPublishesChangesOnly(t *testing.T) {
+	assert := assert.New(t)
+	h := newPresenceHub(nil, WatchOptions{}, logging.DefaultLogger())
+	p := &poller{watchers: make(map[*watcher]bool)}
+	w := &watcher{events: make(chan Presence, 1)}
+	p.watchers[w] = true
+
+	h.publish(p, Presence{State: StateOnline, ConnectedAt: "a"})
+	assert.Equal(StateOnline, (<-w.events).State)
+
+	h.publish(p, Presence{State: StateOnline, ConnectedAt: "a"})
+	assert.Len(w.events, 0)
+
+	h.publish(p, Presence{State: StateOnline, ConnectedAt: "b"})
+	assert.Equal("b", (<-w.events).ConnectedAt)
+
+	h.publish(p, Presence{State: StateOffline})
+	assert.Equal(StateOffline, (<-w.events).State)
+}
+
+func TestPresenceWatch(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+
+	var polls int32
+	s := new(MockService)
+	s.On("RequestStat", mock.Anything, "a0", "mac:112233445566").Return(
+		func(context.Context, string, string) *common.XmidtResponse {
+			atomic.AddInt32(&polls, 1)
+			return &common.XmidtResponse{Code: http.StatusNotFound}
+		}, nil)
+
+	router := mux.NewRouter()
+	router.Handle("/device/{deviceid}/stat/watch", newPresenceHub(s, WatchOptions{PollInterval: time.Hour}, logging.DefaultLogger()))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	readEvent := func() (*http.Response, string) {
+		r, err := http.NewRequest(http.MethodGet, server.URL+"/device/mac:112233445566/stat/watch", nil)
+		require.NoError(err)
+		r.Header.Set("Authorization", "a0")
+		resp, err := http.DefaultClient.Do(r)
+		require.NoError(err)
+		require.Equal(http.StatusOK, resp.StatusCode)
+		assert.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+		var event strings.Builder
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() && scanner.Text() != "" {
+			event.WriteString(scanner.Text() + "\n")
+		}
+		return resp, event.String()
+	}
+
+	first, event := readEvent()
+	defer first.Body.Close()
+	assert.Contains(event, "event: presence\n")
+	assert.Contains(event, `"state":"offline"`)
+
+	// a second watcher on the same device gets the last known presence
+	// without causing another upstream poll
+	second, event := readEvent()
+	defer second.Body.Close()
+	assert.Contains(event, `"state":"offline"`)
+	assert.EqualValues(1, atomic.LoadInt32(&polls))
+}
+
+func TestPresenceWatchInvalidDevice(t *testing.T) {
+	assert := assert.New(t)
+	router := mux.NewRouter()
+	router.Handle("/device/{deviceid}/stat/watch", newPresenceHub(nil, WatchOptions{}, logging.DefaultLogger()))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://localhost/device/mac:1122@#8!!/stat/watch", nil))
+	assert.Equal(http.StatusBadRequest, w.Code)
+}
+
+func TestPresenceHubPollsPerPrincipal(t *testing.T) {
+	assert := assert.New(t)
+	s := new(MockService)
+	s.On("RequestStat", mock.Anything, "a0", "mac:112233445566").Return(&common.XmidtResponse{Code: http.StatusOK}, nil).Once()
+	s.On("RequestStat", mock.Anything, "b0", "mac:112233445566").Return(&common.XmidtResponse{Code: http.StatusNotFound}, nil).Once()
+
+	h := newPresenceHub(s, WatchOptions{}, logging.DefaultLogger())
+	first := &watcher{interval: time.Hour, authHeaderValue: "a0", events: make(chan Presence, 1)}
+	second := &watcher{interval: time.Hour, authHeaderValue: "b0", events: make(chan Presence, 1)}
+
+	firstKey := pollerKey{deviceID: "mac:112233445566", principal: "a"}
+	secondKey := pollerKey{deviceID: "mac:112233445566", principal: "b"}
+	h.subscribe(firstKey, first)
+	defer h.unsubscribe(firstKey, first)
+	h.subscribe(secondKey, second)
+	defer h.unsubscribe(secondKey, second)
+
+	// each principal is polled with its own credentials
+	assert.Equal(StateOnline, (<-first.events).State)
+	assert.Equal(StateOffline, (<-second.events).State)
+	s.AssertExpectations(t)
+}
+
+func TestPresenceHubPollsWhileWatchersChange(t *testing.T) {
+	var polls int32
+	s := new(MockService)
+	s.On("RequestStat", mock.Anything, "a0", "mac:112233445566").Return(
+		func(context.Context, string, string) *common.XmidtResponse {
+			atomic.AddInt32(&polls, 1)
+			return &common.XmidtResponse{Code: http.StatusNotFound}
+		}, nil)
+
+	h := newPresenceHub(s, WatchOptions{}, logging.DefaultLogger())
+	key := pollerKey{deviceID: "mac:112233445566", principal: "a"}
+	w := &watcher{interval: 50 * time.Millisecond, authHeaderValue: "a0", events: make(chan Presence, 1)}
+	h.subscribe(key, w)
+	defer h.unsubscribe(key, w)
+
+	// watchers coming and going faster than the interval don't postpone polls
+	deadline := time.Now().Add(300 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		other := &watcher{interval: 50 * time.Millisecond, authHeaderValue: "a0", events: make(chan Presence, 1)}
+		h.subscribe(key, other)
+		time.Sleep(10 * time.Millisecond)
+		h.unsubscribe(key, other)
+	}
+
+	assert.True(t, atomic.LoadInt32(&polls) >= 3, "expected at least 3 polls, got %d", atomic.LoadInt32(&polls))
+}
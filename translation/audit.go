@@ -0,0 +1,103 @@
+package translation
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/xmidt-org/tr1d1um/audit"
+	"github.com/xmidt-org/tr1d1um/common"
+)
+
+// describeOperation returns the audit record of the mutating WDMP commands. Requests whose
+// WDMP cannot be built are left out as they never reach the device.
+func describeOperation(m *masker, partnerPolicy *common.PartnerPolicy) audit.DescribeFunc {
+	return func(r *http.Request, _ []byte) (audit.Record, bool) {
+		if r.Method == http.MethodGet {
+			return audit.Record{}, false
+		}
+
+		payload, err := requestPayload(r)
+		if err != nil {
+			return audit.Record{}, false
+		}
+
+		command, parameters, err := auditParameters(payload, m)
+		if err != nil {
+			return audit.Record{}, false
+		}
+
+		partnerIDs, _ := partnerPolicy.PartnerIDs(r.Context(), r.Header)
+		return audit.Record{
+			PartnerIDs: partnerIDs,
+			DeviceID:   mux.Vars(r)["deviceid"],
+			Operation:  command,
+			Parameters: parameters,
+		}, true
+	}
+}
+
+// auditParameters returns the command of a mutating WDMP payload along with the names and
+// values it sets. Values of sensitive parameters (or table columns) are masked.
+func auditParameters(payload []byte, m *masker) (string, []audit.Parameter, error) {
+	var command struct {
+		Command string `json:"command"`
+	}
+
+	if err := json.Unmarshal(payload, &command); err != nil {
+		return "", nil, err
+	}
+
+	switch command.Command {
+	case CommandAddRow:
+		var wdmp addRowWDMP
+		err := json.Unmarshal(payload, &wdmp)
+		return wdmp.Command, []audit.Parameter{{Name: wdmp.Table, Value: m.maskRow(wdmp.Table, wdmp.Row)}}, err
+	case CommandReplaceRows:
+		var wdmp replaceRowsWDMP
+		if err := json.Unmarshal(payload, &wdmp); err != nil {
+			return "", nil, err
+		}
+
+		rows := make(map[string]map[string]string, len(wdmp.Rows))
+		for index, row := range wdmp.Rows {
+			rows[index] = m.maskRow(wdmp.Table, row)
+		}
+		return wdmp.Command, []audit.Parameter{{Name: wdmp.Table, Value: rows}}, nil
+	case CommandDeleteRow:
+		var wdmp deleteRowDMP
+		err := json.Unmarshal(payload, &wdmp)
+		return wdmp.Command, []audit.Parameter{{Name: wdmp.Row}}, err
+	}
+
+	var wdmp setWDMP
+	if err := json.Unmarshal(payload, &wdmp); err != nil {
+		return "", nil, err
+	}
+
+	parameters := make([]audit.Parameter, 0, len(wdmp.Parameters))
+	for _, p := range wdmp.Parameters {
+		value := p.Value
+		if value == nil && len(p.Attributes) > 0 {
+			value = p.Attributes
+		}
+
+		if m.sensitive(*p.Name) {
+			value = maskedValue
+		}
+		parameters = append(parameters, audit.Parameter{Name: *p.Name, Value: value})
+	}
+	return wdmp.Command, parameters, nil
+}
+
+// maskRow returns a copy of a table row with the values of sensitive columns masked.
+func (m *masker) maskRow(table string, row map[string]string) map[string]string {
+	masked := make(map[string]string, len(row))
+	for column, value := range row {
+		if m.sensitive(table + column) {
+			value = maskedValue
+		}
+		masked[column] = value
+	}
+	return masked
+}
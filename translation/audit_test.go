@@ -0,0 +1,92 @@
+package translation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/tr1d1um/audit"
+	"github.com/xmidt-org/wrp-go/v3/wrphttp"
+)
+
+func TestAuditParameters(t *testing.T) {
+	m := newMasker(testSensitiveParameters)
+
+	testCases := []struct {
+		Name               string
+		Payload            string
+		ExpectedCommand    string
+		ExpectedParameters []audit.Parameter
+	}{
+		{
+			Name:            "SET",
+			Payload:         `{"command":"SET","parameters":[{"name":"Device.Time.Enable","value":"true","dataType":3},{"name":"Device.Users.User.1.Password","value":"secret","dataType":0}]}`,
+			ExpectedCommand: CommandSet,
+			ExpectedParameters: []audit.Parameter{
+				{Name: "Device.Time.Enable", Value: "true"},
+				{Name: "Device.Users.User.1.Password", Value: maskedValue},
+			},
+		},
+		{
+			Name:               "SET_ATTRIBUTES",
+			Payload:            `{"command":"SET_ATTRIBUTES","parameters":[{"name":"Device.Time.Enable","attributes":{"notify":1}}]}`,
+			ExpectedCommand:    CommandSetAttrs,
+			ExpectedParameters: []audit.Parameter{{Name: "Device.Time.Enable", Value: map[string]interface{}{"notify": float64(1)}}},
+		},
+		{
+			Name:               "ADD_ROW",
+			Payload:            `{"command":"ADD_ROW","table":"Device.Users.User.","row":{"Username":"admin","Password":"secret"}}`,
+			ExpectedCommand:    CommandAddRow,
+			ExpectedParameters: []audit.Parameter{{Name: "Device.Users.User.", Value: map[string]string{"Username": maskedValue, "Password": maskedValue}}},
+		},
+		{
+			Name:               "REPLACE_ROWS",
+			Payload:            `{"command":"REPLACE_ROWS","table":"Device.NAT.PortMapping.","rows":{"0":{"Enable":"true"}}}`,
+			ExpectedCommand:    CommandReplaceRows,
+			ExpectedParameters: []audit.Parameter{{Name: "Device.NAT.PortMapping.", Value: map[string]map[string]string{"0": {"Enable": "true"}}}},
+		},
+		{
+			Name:               "DELETE_ROW",
+			Payload:            `{"command":"DELETE_ROW","row":"Device.NAT.PortMapping.1."}`,
+			ExpectedCommand:    CommandDeleteRow,
+			ExpectedParameters: []audit.Parameter{{Name: "Device.NAT.PortMapping.1."}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			command, parameters, err := auditParameters([]byte(tc.Payload), m)
+			assert.Nil(err)
+			assert.Equal(tc.ExpectedCommand, command)
+			assert.Equal(tc.ExpectedParameters, parameters)
+		})
+	}
+}
+
+func TestDescribeOperation(t *testing.T) {
+	assert := assert.New(t)
+	describe := describeOperation(nil, nil)
+
+	r := httptest.NewRequest(http.MethodPatch, "/device/mac:112233445566/config", strings.NewReader(`{"parameters":[{"name":"Device.Time.Enable","value":"true","dataType":3}]}`))
+	r.Header.Set(wrphttp.PartnerIdHeader, "partner0")
+	r = mux.SetURLVars(r, map[string]string{"deviceid": "mac:112233445566", "service": "config"})
+
+	record, ok := describe(r, nil)
+	assert.True(ok)
+	assert.Equal(audit.Record{
+		PartnerIDs: []string{"partner0"},
+		DeviceID:   "mac:112233445566",
+		Operation:  CommandSet,
+		Parameters: []audit.Parameter{{Name: "Device.Time.Enable", Value: "true"}},
+	}, record)
+
+	_, ok = describe(httptest.NewRequest(http.MethodGet, "/device/mac:112233445566/config?names=Device.Time.Enable", nil), nil)
+	assert.False(ok)
+
+	_, ok = describe(httptest.NewRequest(http.MethodPatch, "/device/mac:112233445566/config", strings.NewReader(`{`)), nil)
+	assert.False(ok)
+}
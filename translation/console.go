@@ -0,0 +1,352 @@
+package translation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/provider"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/webpa-common/device"
+	"github.com/xmidt-org/webpa-common/logging"
+	"github.com/xmidt-org/webpa-common/xmetrics"
+)
+
+// Metric names of the device console.
+const (
+	ConsoleSessionsGauge   = "console_sessions"
+	ConsoleCommandsCounter = "console_commands"
+)
+
+// Metrics returns the metrics relevant to this package.
+func Metrics() []xmetrics.Metric {
+	return []xmetrics.Metric{
+		{
+			Name: ConsoleSessionsGauge,
+			Type: xmetrics.GaugeType,
+			Help: "Number of open device console sessions.",
+		},
+		{
+			Name:       ConsoleCommandsCounter,
+			Type:       xmetrics.CounterType,
+			Help:       "Count of device console commands by WDMP command and response status code.",
+			LabelNames: []string{common.CommandLabel, common.CodeLabel},
+		},
+	}
+}
+
+// Default values for the device console. They apply when the corresponding ConsoleOptions
+// field is left unset.
+const (
+	DefaultConsoleMaxInFlight  = 4
+	DefaultConsoleReadLimit    = 64 * 1024
+	DefaultConsolePingInterval = 30 * time.Second
+	DefaultConsoleWriteTimeout = 10 * time.Second
+)
+
+// ConsoleOptions configures the device console (WebSocket) endpoint.
+type ConsoleOptions struct {
+	// MaxInFlight bounds the commands of a session sent to the device at once. Frames
+	// beyond it are not read until a command completes.
+	// (Optional) Defaults to 4.
+	MaxInFlight int
+
+	// ReadLimit is the maximum size in bytes of a command frame. Sessions sending larger
+	// frames are closed.
+	// (Optional) Defaults to 64KiB.
+	ReadLimit int64
+
+	// PingInterval is how often sessions are pinged. Sessions not answering within twice
+	// the interval are closed.
+	// (Optional) Defaults to 30s.
+	PingInterval time.Duration
+
+	// WriteTimeout bounds the time taken to write a frame to a session.
+	// (Optional) Defaults to 10s.
+	WriteTimeout time.Duration
+
+	// AllowedOrigins lists the values of the Origin header of the browsers allowed to open
+	// sessions from other origins.
+	// (Optional) By default, only requests without Origin or from the same host are allowed.
+	AllowedOrigins []string
+
+	// WriteCapability is the capability the token of a session must carry for its commands
+	// other than GET and GET_ATTRIBUTES. Sessions are opened with GET requests so capability
+	// checks alone would let callers with read access change parameters.
+	// (Optional) By default, sessions are read-only.
+	WriteCapability string
+}
+
+func (o ConsoleOptions) withDefaults() ConsoleOptions {
+	if o.MaxInFlight <= 0 {
+		o.MaxInFlight = DefaultConsoleMaxInFlight
+	}
+	if o.ReadLimit <= 0 {
+		o.ReadLimit = DefaultConsoleReadLimit
+	}
+	if o.PingInterval <= 0 {
+		o.PingInterval = DefaultConsolePingInterval
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = DefaultConsoleWriteTimeout
+	}
+	return o
+}
+
+// consoleCommand is a command frame. Besides the operation, it carries a correlation ID which
+// is echoed back in the response frame.
+type consoleCommand struct {
+	ID string `json:"id"`
+	Operation
+}
+
+// consoleResponse is a response frame. Response is the WDMP response of the device, whose
+// status code is StatusCode. Requests which didn't get a device response carry the problem
+// details HTTP API consumers would get instead.
+type consoleResponse struct {
+	ID         string          `json:"id"`
+	TID        string          `json:"tid,omitempty"`
+	StatusCode int             `json:"statusCode"`
+	Response   json.RawMessage `json:"response,omitempty"`
+	Error      *common.Problem `json:"error,omitempty"`
+}
+
+// consoleHandler upgrades requests to '/device/{deviceid}/console' into console sessions.
+// Sessions are authenticated once, by the upgrade request, and their commands go through the
+// same service, partner and parameter checks as the requests of the HTTP API.
+type consoleHandler struct {
+	o        ConsoleOptions
+	upgrader websocket.Upgrader
+	endpoint endpoint.Endpoint
+
+	validServices func() []string
+	partnerPolicy *common.PartnerPolicy
+	policy        *ParameterPolicy
+	masker        *masker
+	drainer       *common.Drainer
+
+	logger   kitlog.Logger
+	sessions metrics.Gauge
+	commands metrics.Counter
+}
+
+func newConsoleHandler(o ConsoleOptions, e endpoint.Endpoint, validServices func() []string, c *Options, m *masker) *consoleHandler {
+	p := c.MetricsProvider
+	if p == nil {
+		p = provider.NewDiscardProvider()
+	}
+
+	h := &consoleHandler{
+		o:             o.withDefaults(),
+		endpoint:      e,
+		validServices: validServices,
+		partnerPolicy: c.PartnerPolicy,
+		policy:        c.ParameterPolicy,
+		masker:        m,
+		drainer:       c.Drainer,
+		logger:        c.Log,
+		sessions:      p.NewGauge(ConsoleSessionsGauge),
+		commands:      p.NewCounter(ConsoleCommandsCounter),
+	}
+
+	if len(h.o.AllowedOrigins) > 0 {
+		h.upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return origin == "" || contains(origin, h.o.AllowedOrigins)
+		}
+	}
+	return h
+}
+
+func (h *consoleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := context.WithValue(r.Context(), common.ContextKeyRequestTID, common.NewTID())
+	ctx = context.WithValue(ctx, common.ContextKeyRequestAccept, r.Header.Get("Accept"))
+
+	deviceID, err := device.ParseID(mux.Vars(r)["deviceid"])
+	if err != nil {
+		common.WriteError(ctx, common.NewInvalidDeviceIDError(err), w)
+		return
+	}
+
+	partnerIDs, err := h.partnerPolicy.PartnerIDs(ctx, r.Header)
+	if err != nil {
+		common.WriteError(ctx, err, w)
+		return
+	}
+
+	// the upgrader responds to failed upgrades
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	s := &consoleSession{
+		h:               h,
+		conn:            conn,
+		deviceID:        string(deviceID),
+		partnerIDs:      partnerIDs,
+		authHeaderValue: r.Header.Get(authHeaderKey),
+	}
+	s.run(r.Context())
+}
+
+// consoleSession serves the commands of a console connection.
+type consoleSession struct {
+	h    *consoleHandler
+	conn *websocket.Conn
+
+	deviceID        string
+	partnerIDs      []string
+	authHeaderValue string
+
+	writeLock sync.Mutex
+}
+
+// run reads command frames until the connection fails or is closed. Commands in flight
+// are canceled then.
+func (s *consoleSession) run(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	var (
+		inFlight = make(chan struct{}, s.h.o.MaxInFlight)
+		pongWait = 2 * s.h.o.PingInterval
+		commands sync.WaitGroup
+	)
+
+	s.h.sessions.Add(1)
+	defer func() {
+		cancel()
+		commands.Wait()
+		s.conn.Close()
+		s.h.sessions.Add(-1)
+	}()
+
+	s.conn.SetReadLimit(s.h.o.ReadLimit)
+	s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	go s.ping(ctx)
+
+	for {
+		_, frame, err := s.conn.ReadMessage()
+		if err != nil {
+			logging.Debug(s.h.logger).Log(logging.MessageKey(), "console session closed", "deviceID", s.deviceID, logging.ErrorKey(), err)
+			return
+		}
+
+		var c consoleCommand
+		if err := json.Unmarshal(frame, &c); err != nil {
+			problem := common.NewProblem(newInvalidFrameError(err), "")
+			s.write(consoleResponse{StatusCode: problem.Status, Error: &problem})
+			continue
+		}
+
+		select {
+		case inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		// waiting for a free slot doesn't count against the session
+		s.conn.SetReadDeadline(time.Now().Add(pongWait))
+
+		commands.Add(1)
+		go func() {
+			defer func() {
+				<-inFlight
+				commands.Done()
+			}()
+			s.write(s.execute(ctx, c))
+		}()
+	}
+}
+
+func (s *consoleSession) ping(ctx context.Context) {
+	ticker := time.NewTicker(s.h.o.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(s.h.o.WriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *consoleSession) write(r consoleResponse) {
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+
+	s.conn.SetWriteDeadline(time.Now().Add(s.h.o.WriteTimeout))
+	if err := s.conn.WriteJSON(r); err != nil {
+		logging.Debug(s.h.logger).Log(logging.MessageKey(), "failed to write console response", "id", r.ID, "tid", r.TID, logging.ErrorKey(), err)
+	}
+}
+
+// execute sends the command to the device. Commands arriving while draining are rejected.
+func (s *consoleSession) execute(ctx context.Context, c consoleCommand) consoleResponse {
+	var (
+		tid      = common.NewTID()
+		start    = time.Now()
+		response = consoleResponse{ID: c.ID, TID: tid}
+		sendErr  error
+	)
+
+	ctx = context.WithValue(ctx, common.ContextKeyRequestTID, tid)
+	err := s.h.drainer.Run(func() {
+		response.StatusCode, response.Response, sendErr = s.send(ctx, tid, c)
+	})
+	if err == nil {
+		err = sendErr
+	}
+
+	if err != nil {
+		problem := common.NewProblem(err, tid)
+		response.StatusCode, response.Response, response.Error = problem.Status, nil, &problem
+		logging.Error(s.h.logger).Log(logging.MessageKey(), "console command failed", "tid", tid, logging.ErrorKey(), err)
+	}
+
+	s.h.commands.With(common.CommandLabel, c.commandLabel(), common.CodeLabel, strconv.Itoa(response.StatusCode)).Add(1)
+	logging.Info(s.h.logger).Log(logging.MessageKey(), "record", "tid", tid, "deviceID", s.deviceID, "service", c.Service,
+		"command", c.commandLabel(), "code", response.StatusCode, "duration", time.Since(start))
+	return response
+}
+
+// send returns the status code and WDMP response of the device.
+func (s *consoleSession) send(ctx context.Context, tid string, c consoleCommand) (int, json.RawMessage, error) {
+	if !contains(c.Service, s.h.validServices()) {
+		return 0, nil, ErrInvalidService
+	}
+
+	// writes fail closed: no capability configured means no session may write
+	if !c.ReadOnly() && !common.HasCapability(ctx, s.h.o.WriteCapability) {
+		return 0, nil, ErrForbiddenCommand
+	}
+
+	wrpMsg, err := c.Message(s.deviceID, tid, s.partnerIDs)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := s.h.policy.Authorize(ctx, wrpMsg); err != nil {
+		return 0, nil, err
+	}
+
+	response, err := s.h.endpoint(ctx, &wrpRequest{WRPMessage: wrpMsg, AuthHeaderValue: s.authHeaderValue})
+	if err != nil {
+		return 0, nil, err
+	}
+	return c.response(ctx, s.h.masker, response.(*common.XmidtResponse))
+}
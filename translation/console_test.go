@@ -0,0 +1,270 @@
+package translation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/webpa-common/basculechecks"
+	"github.com/xmidt-org/webpa-common/logging"
+	"github.com/xmidt-org/webpa-common/xmetrics/xmetricstest"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+// serviceFunc is a Service calling a function.
+type serviceFunc func(context.Context, *wrp.Message, string) (*common.XmidtResponse, error)
+
+func (f serviceFunc) SendWRP(ctx context.Context, msg *wrp.Message, authHeaderValue string) (*common.XmidtResponse, error) {
+	return f(ctx, msg, authHeaderValue)
+}
+
+// deviceResponse returns the XMiDT response carrying the given WDMP response of a device.
+func deviceResponse(payload string) *common.XmidtResponse {
+	return &common.XmidtResponse{
+		Code: http.StatusOK,
+		Body: bytes.NewBuffer(wrp.MustEncode(&wrp.Message{
+			Type:    wrp.SimpleRequestResponseMessageType,
+			Payload: []byte(payload),
+		}, wrp.Msgpack)).Bytes(),
+	}
+}
+
+// newTestConsole serves the console of the options and returns the URL of the console of the
+// mac:112233445566 device. Sessions are authenticated as client0 with the given capabilities.
+func newTestConsole(t *testing.T, o *Options, capabilities ...string) (*httptest.Server, string) {
+	authenticate := alice.New(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth := bascule.Authentication{
+				Token: bascule.NewToken("basic", "client0", bascule.NewAttributes(map[string]interface{}{
+					basculechecks.CapabilityKey: capabilities,
+				})),
+			}
+			next.ServeHTTP(w, r.WithContext(bascule.WithAuthentication(r.Context(), auth)))
+		})
+	})
+
+	router := mux.NewRouter()
+	o.APIRouter = router.PathPrefix(apiBase).Subrouter()
+	o.Authenticate = &authenticate
+	o.Log = logging.NewTestLogger(nil, t)
+	o.ValidServices = []string{"config"}
+	ConfigHandler(o)
+
+	server := httptest.NewServer(router)
+	return server, "ws" + strings.TrimPrefix(server.URL, "http") + apiBase + "/device/mac:112233445566/console"
+}
+
+func dialConsole(t *testing.T, url string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": []string{"Basic dGVzdDp0ZXN0"}})
+	require.NoError(t, err)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func TestConsole(t *testing.T) {
+	s := serviceFunc(func(_ context.Context, msg *wrp.Message, authHeaderValue string) (*common.XmidtResponse, error) {
+		if authHeaderValue != "Basic dGVzdDp0ZXN0" || msg.Destination != "mac:112233445566/config" {
+			return nil, errors.New("unexpected message")
+		}
+
+		switch {
+		case strings.Contains(string(msg.Payload), "Device.Unreachable"):
+			return &common.XmidtResponse{Code: http.StatusNotFound, Body: []byte("device not found")}, nil
+		case strings.Contains(string(msg.Payload), `"GET"`):
+			return deviceResponse(`{"statusCode":200,"parameters":[{"name":"Device.Users.User.1.Password","value":"secret","dataType":0}]}`), nil
+		default:
+			return deviceResponse(`{"statusCode":520,"message":"Error setting parameter"}`), nil
+		}
+	})
+
+	testCases := []struct {
+		Name               string
+		Frame              string
+		Capabilities       []string
+		ExpectedID         string
+		ExpectedStatusCode int
+		ExpectedErrorCode  string
+		ExpectedResponse   string
+	}{
+		{
+			Name:               "GET",
+			Frame:              `{"id":"1","command":"GET","service":"config","names":["Device.Users.User.1.Password"]}`,
+			ExpectedID:         "1",
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedResponse:   `{"statusCode":200,"parameters":[{"name":"Device.Users.User.1.Password","value":"****","dataType":0}]}`,
+		},
+		{
+			Name:               "Device Failure",
+			Frame:              `{"id":"2","command":"SET","service":"config","parameters":[{"name":"a","value":"b","dataType":0}]}`,
+			Capabilities:       []string{"console:write"},
+			ExpectedID:         "2",
+			ExpectedStatusCode: 520,
+			ExpectedResponse:   `{"statusCode":520,"message":"Error setting parameter"}`,
+		},
+		{
+			Name:               "Read-only",
+			Frame:              `{"id":"3","command":"SET","service":"config","parameters":[{"name":"a","value":"b","dataType":0}]}`,
+			ExpectedID:         "3",
+			ExpectedStatusCode: http.StatusForbidden,
+			ExpectedErrorCode:  ErrorCodeForbiddenCommand,
+		},
+		{
+			Name:               "Unsupported Service",
+			Frame:              `{"id":"4","command":"GET","service":"iot","names":["a"]}`,
+			ExpectedID:         "4",
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedErrorCode:  ErrorCodeUnsupportedService,
+		},
+		{
+			Name:               "XMiDT Failure",
+			Frame:              `{"id":"5","command":"GET","service":"config","names":["Device.Unreachable"]}`,
+			ExpectedID:         "5",
+			ExpectedStatusCode: http.StatusNotFound,
+			ExpectedErrorCode:  "not-found",
+		},
+		{
+			Name:               "Invalid Frame",
+			Frame:              `{"id":`,
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedErrorCode:  ErrorCodeInvalidFrame,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			p := xmetricstest.NewProvider(nil, Metrics)
+			server, url := newTestConsole(t, &Options{
+				S:                   s,
+				SensitiveParameters: testSensitiveParameters,
+				MetricsProvider:     p,
+				Console:             &ConsoleOptions{WriteCapability: "console:write"},
+			}, tc.Capabilities...)
+			defer server.Close()
+
+			conn := dialConsole(t, url)
+			defer conn.Close()
+			require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(tc.Frame)))
+
+			var response consoleResponse
+			require.NoError(t, conn.ReadJSON(&response))
+			assert.Equal(tc.ExpectedID, response.ID)
+			assert.Equal(tc.ExpectedStatusCode, response.StatusCode)
+
+			if tc.ExpectedErrorCode != "" {
+				require.NotNil(t, response.Error)
+				assert.Equal(tc.ExpectedErrorCode, response.Error.Code)
+				assert.Empty(response.Response)
+				return
+			}
+
+			assert.NotEmpty(response.TID)
+			assert.Nil(response.Error)
+			assert.JSONEq(tc.ExpectedResponse, string(response.Response))
+			p.Assert(t, ConsoleSessionsGauge)(xmetricstest.Value(1))
+		})
+	}
+}
+
+func TestConsoleWritesFailClosed(t *testing.T) {
+	assert := assert.New(t)
+	s := serviceFunc(func(context.Context, *wrp.Message, string) (*common.XmidtResponse, error) {
+		return deviceResponse(`{"statusCode":200}`), nil
+	})
+
+	// no write capability configured: even tokens carrying capabilities may not write
+	server, url := newTestConsole(t, &Options{S: s, Console: &ConsoleOptions{}}, "console:write")
+	defer server.Close()
+
+	conn := dialConsole(t, url)
+	defer conn.Close()
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"1","command":"SET","service":"config","parameters":[{"name":"a","value":"b","dataType":0}]}`)))
+
+	var response consoleResponse
+	require.NoError(t, conn.ReadJSON(&response))
+	assert.Equal(http.StatusForbidden, response.StatusCode)
+	require.NotNil(t, response.Error)
+	assert.Equal(ErrorCodeForbiddenCommand, response.Error.Code)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"2","command":"GET","service":"config","names":["a"]}`)))
+	require.NoError(t, conn.ReadJSON(&response))
+	assert.Equal(http.StatusOK, response.StatusCode)
+}
+
+func TestConsoleInvalidDevice(t *testing.T) {
+	server, url := newTestConsole(t, &Options{Console: &ConsoleOptions{}})
+	defer server.Close()
+
+	_, response, err := websocket.DefaultDialer.Dial(strings.Replace(url, "mac:112233445566", "nope", 1), nil)
+	assert.NotNil(t, err)
+	require.NotNil(t, response)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestConsoleMaxInFlight(t *testing.T) {
+	assert := assert.New(t)
+	started, release := make(chan string, 2), make(chan struct{})
+	s := serviceFunc(func(_ context.Context, msg *wrp.Message, _ string) (*common.XmidtResponse, error) {
+		started <- msg.TransactionUUID
+		<-release
+		return deviceResponse(`{"statusCode":200}`), nil
+	})
+
+	p := xmetricstest.NewProvider(nil, Metrics)
+	server, url := newTestConsole(t, &Options{S: s, MetricsProvider: p, Console: &ConsoleOptions{MaxInFlight: 1}})
+	defer server.Close()
+
+	conn := dialConsole(t, url)
+	defer conn.Close()
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"1","command":"GET","service":"config","names":["a"]}`)))
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"2","command":"GET","service":"config","names":["a"]}`)))
+
+	<-started
+	select {
+	case <-started:
+		assert.Fail("commands beyond the limit must wait")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	for _, id := range []string{"1", "2"} {
+		var response consoleResponse
+		require.NoError(t, conn.ReadJSON(&response))
+		assert.Equal(id, response.ID)
+		assert.Equal(http.StatusOK, response.StatusCode)
+	}
+	p.Assert(t, ConsoleCommandsCounter, common.CommandLabel, CommandGet, common.CodeLabel, "200")(xmetricstest.Value(2))
+}
+
+func TestConsoleDraining(t *testing.T) {
+	drainer := common.NewDrainer(nil)
+	s := serviceFunc(func(context.Context, *wrp.Message, string) (*common.XmidtResponse, error) {
+		return deviceResponse(`{"statusCode":200}`), nil
+	})
+
+	server, url := newTestConsole(t, &Options{S: s, Drainer: drainer, Console: &ConsoleOptions{}})
+	defer server.Close()
+
+	conn := dialConsole(t, url)
+	defer conn.Close()
+
+	assert.Zero(t, drainer.Drain(0))
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"id":"1","command":"GET","service":"config","names":["a"]}`)))
+
+	var response consoleResponse
+	require.NoError(t, conn.ReadJSON(&response))
+	require.NotNil(t, response.Error)
+	assert.Equal(t, common.ErrorCodeShuttingDown, response.Error.Code)
+}
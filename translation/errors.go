@@ -2,26 +2,60 @@ package translation
 
 import (
 	"errors"
+	"net/http"
 
 	"github.com/xmidt-org/tr1d1um/common"
 )
 
+// Error codes of the translation service. Unlike error messages, they never change.
+const (
+	ErrorCodeMissingNames        = "missing-names"
+	ErrorCodeUnsupportedService  = "unsupported-service"
+	ErrorCodeUnsupportedMethod   = "unsupported-method"
+	ErrorCodeInvalidWDMP         = "invalid-wdmp"
+	ErrorCodeInvalidSet          = "invalid-set"
+	ErrorCodeMissingNewCID       = "missing-new-cid"
+	ErrorCodeMissingTable        = "missing-table"
+	ErrorCodeMissingRow          = "missing-row"
+	ErrorCodeInvalidRow          = "invalid-row"
+	ErrorCodeMissingRows         = "missing-rows"
+	ErrorCodeInvalidRows         = "invalid-rows"
+	ErrorCodeForbiddenParameters = "forbidden-parameters"
+	ErrorCodeUnsupportedCommand  = "unsupported-command"
+	ErrorCodeForbiddenCommand    = "forbidden-command"
+	ErrorCodeInvalidFrame        = "invalid-frame"
+)
+
 // Error values definitions for the translation service
 var (
-	ErrEmptyNames        = common.NewBadRequestError(errors.New("names parameter is required"))
-	ErrInvalidService    = common.NewBadRequestError(errors.New("unsupported Service"))
-	ErrUnsupportedMethod = common.NewBadRequestError(errors.New("unsupported method. Could not decode request payload"))
+	ErrEmptyNames        = common.NewProblemError(errors.New("names parameter is required"), http.StatusBadRequest, ErrorCodeMissingNames, "Missing parameter names", "names")
+	ErrInvalidService    = common.NewProblemError(errors.New("unsupported Service"), http.StatusBadRequest, ErrorCodeUnsupportedService, "Unsupported device service", "service")
+	ErrUnsupportedMethod = common.NewProblemError(errors.New("unsupported method. Could not decode request payload"), http.StatusBadRequest, ErrorCodeUnsupportedMethod, "Unsupported method")
 
 	//Set command errors
-	ErrInvalidSetWDMP = common.NewBadRequestError(errors.New("invalid SET message"))
-	ErrNewCIDRequired = common.NewBadRequestError(errors.New("newCid is required for TEST_AND_SET"))
+	ErrInvalidSetWDMP = common.NewProblemError(errors.New("invalid SET message"), http.StatusBadRequest, ErrorCodeInvalidSet, "Invalid SET message", "parameters")
+	ErrNewCIDRequired = common.NewProblemError(errors.New("newCid is required for TEST_AND_SET"), http.StatusBadRequest, ErrorCodeMissingNewCID, "Missing new CID", HeaderWPASyncNewCID)
 
 	//Add/Delete command  errors
-	ErrMissingTable = common.NewBadRequestError(errors.New("table property is required"))
-	ErrMissingRow   = common.NewBadRequestError(errors.New("row property is required"))
-	ErrInvalidRow   = common.NewBadRequestError(errors.New("row property is invalid"))
+	ErrMissingTable = common.NewProblemError(errors.New("table property is required"), http.StatusBadRequest, ErrorCodeMissingTable, "Missing table", "table")
+	ErrMissingRow   = common.NewProblemError(errors.New("row property is required"), http.StatusBadRequest, ErrorCodeMissingRow, "Missing row", "row")
+	ErrInvalidRow   = common.NewProblemError(errors.New("row property is invalid"), http.StatusBadRequest, ErrorCodeInvalidRow, "Invalid row", "row")
 
 	//Replace command error
-	ErrMissingRows = common.NewBadRequestError(errors.New("rows property is required"))
-	ErrInvalidRows = common.NewBadRequestError(errors.New("rows property is invalid"))
+	ErrMissingRows = common.NewProblemError(errors.New("rows property is required"), http.StatusBadRequest, ErrorCodeMissingRows, "Missing rows", "rows")
+	ErrInvalidRows = common.NewProblemError(errors.New("rows property is invalid"), http.StatusBadRequest, ErrorCodeInvalidRows, "Invalid rows", "rows")
+
+	//Console errors
+	ErrUnsupportedCommand = common.NewProblemError(errors.New("unsupported command"), http.StatusBadRequest, ErrorCodeUnsupportedCommand, "Unsupported console command", "command")
+	ErrForbiddenCommand   = common.NewProblemError(errors.New("command is read-only for this caller"), http.StatusForbidden, ErrorCodeForbiddenCommand, "Forbidden console command", "command")
 )
+
+// newInvalidWDMPError is the error of requests whose WDMP body could not be decoded.
+func newInvalidWDMPError(err error) common.ProblemError {
+	return common.NewProblemError(err, http.StatusBadRequest, ErrorCodeInvalidWDMP, "Invalid WDMP structure")
+}
+
+// newInvalidFrameError is the error of console frames which could not be decoded.
+func newInvalidFrameError(err error) common.ProblemError {
+	return common.NewProblemError(err, http.StatusBadRequest, ErrorCodeInvalidFrame, "Invalid console frame")
+}
@@ -0,0 +1,200 @@
+package translation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+// maskedValue replaces sensitive parameter names in logs and sensitive values in responses.
+const maskedValue = "****"
+
+// SensitiveParameters lists the parameters whose names must not show up in transaction
+// logs and whose values may be hidden from callers.
+type SensitiveParameters struct {
+	// Patterns select the sensitive parameter names. Like the ones of parameter policies, they
+	// are globs (i.e. 'Device.WiFi.AccessPoint.*.Security.KeyPassphrase') if they include
+	// any of '*?[' and name prefixes otherwise.
+	Patterns []string
+
+	// MaskResponses replaces the values of sensitive parameters in GET responses
+	// unless the caller has the RevealCapability.
+	MaskResponses bool
+
+	// RevealCapability grants access to the values of sensitive parameters.
+	// (Optional) If empty, values are masked for every caller.
+	RevealCapability string
+}
+
+// masker hides sensitive parameters. A nil masker hides nothing.
+type masker struct {
+	patterns         []string
+	maskResponses    bool
+	revealCapability string
+}
+
+func newMasker(s *SensitiveParameters) *masker {
+	if s == nil || len(s.Patterns) == 0 {
+		return nil
+	}
+
+	return &masker{
+		patterns:         s.Patterns,
+		maskResponses:    s.MaskResponses,
+		revealCapability: s.RevealCapability,
+	}
+}
+
+func (m *masker) sensitive(name string) bool {
+	return m != nil && matchesAny(name, m.patterns)
+}
+
+// maskNames returns a copy of names where the sensitive ones are masked.
+func (m *masker) maskNames(names []string) []string {
+	if m == nil {
+		return names
+	}
+
+	masked := make([]string, len(names))
+	for i, name := range names {
+		if m.sensitive(name) {
+			name = maskedValue
+		}
+		masked[i] = name
+	}
+	return masked
+}
+
+// maskQuery returns the raw query with the sensitive entries of the 'names' query parameter masked.
+func (m *masker) maskQuery(rawQuery string) string {
+	if m == nil || rawQuery == "" {
+		return rawQuery
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		// the request is rejected later on anyways so don't log anything which might be sensitive
+		return maskedValue
+	}
+
+	names, ok := query["names"]
+	if !ok {
+		return rawQuery
+	}
+
+	changed := false
+	for i, list := range names {
+		parts := strings.Split(list, ",")
+		for j, name := range parts {
+			if m.sensitive(strings.TrimSpace(name)) {
+				parts[j], changed = maskedValue, true
+			}
+		}
+		names[i] = strings.Join(parts, ",")
+	}
+
+	if !changed {
+		return rawQuery
+	}
+	return query.Encode()
+}
+
+// capture is common.Capture which keeps sensitive parameter names out of the logged request query.
+func (m *masker) capture(capture kithttp.RequestFunc) kithttp.RequestFunc {
+	if m == nil {
+		return capture
+	}
+
+	return func(ctx context.Context, r *http.Request) context.Context {
+		masked, u := *r, *r.URL
+		u.RawQuery = m.maskQuery(u.RawQuery)
+		masked.URL = &u
+		return capture(ctx, &masked)
+	}
+}
+
+// maskPayload replaces the values of the sensitive parameters in a device response payload.
+// It returns the payload as is if it has no sensitive values.
+func (m *masker) maskPayload(payload []byte) []byte {
+	var model interface{}
+	if err := json.Unmarshal(payload, &model); err != nil {
+		return payload
+	}
+
+	if !m.maskValues(model) {
+		return payload
+	}
+
+	masked, err := json.Marshal(model)
+	if err != nil {
+		return payload
+	}
+	return masked
+}
+
+// maskValues walks a decoded JSON document and masks the value of every object with
+// a sensitive name. Wildcard GET responses nest such objects in the parameter values.
+func (m *masker) maskValues(v interface{}) (masked bool) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if name, ok := t["name"].(string); ok && m.sensitive(name) {
+			if _, ok := t["value"]; ok {
+				t["value"] = maskedValue
+				return true
+			}
+		}
+
+		for _, e := range t {
+			masked = m.maskValues(e) || masked
+		}
+	case []interface{}:
+		for _, e := range t {
+			masked = m.maskValues(e) || masked
+		}
+	}
+	return
+}
+
+// encodeMaskedResponse decorates a response encoder such that the values of sensitive parameters
+// in successful GET responses are masked for callers without the reveal capability.
+func (m *masker) encodeMaskedResponse(encoder kithttp.EncodeResponseFunc) kithttp.EncodeResponseFunc {
+	if m == nil || !m.maskResponses {
+		return encoder
+	}
+
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		resp := response.(*common.XmidtResponse)
+		method, _ := ctx.Value(kithttp.ContextKeyRequestMethod).(string)
+		if method != http.MethodGet || resp.Code != http.StatusOK || common.HasCapability(ctx, m.revealCapability) {
+			return encoder(ctx, w, response)
+		}
+
+		var message wrp.Message
+		if err := wrp.NewDecoderBytes(resp.Body, wrp.Msgpack).Decode(&message); err != nil {
+			// let the encoder deal with it
+			return encoder(ctx, w, response)
+		}
+
+		masked := m.maskPayload(message.Payload)
+		if bytes.Equal(masked, message.Payload) {
+			return encoder(ctx, w, response)
+		}
+		message.Payload = masked
+
+		var body []byte
+		if err := wrp.NewEncoderBytes(&body, wrp.Msgpack).Encode(&message); err != nil {
+			return err
+		}
+
+		maskedResp := *resp
+		maskedResp.Body = body
+		return encoder(ctx, w, &maskedResp)
+	}
+}
@@ -0,0 +1,159 @@
+package translation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+var testSensitiveParameters = &SensitiveParameters{
+	Patterns:         []string{"Device.WiFi.AccessPoint.*.Security.KeyPassphrase", "Device.Users."},
+	MaskResponses:    true,
+	RevealCapability: "secrets:reveal",
+}
+
+func TestNewMasker(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(newMasker(nil))
+	assert.Nil(newMasker(&SensitiveParameters{MaskResponses: true}))
+
+	var m *masker
+	assert.False(m.sensitive("Device.Users.User.1.Password"))
+	assert.Equal([]string{"Device.Users.User.1.Password"}, m.maskNames([]string{"Device.Users.User.1.Password"}))
+	assert.Equal("names=Device.Users.User.1.Password", m.maskQuery("names=Device.Users.User.1.Password"))
+}
+
+func TestMaskNames(t *testing.T) {
+	m := newMasker(testSensitiveParameters)
+	assert.Equal(t,
+		[]string{"Device.WiFi.SSID.1.SSID", maskedValue, maskedValue},
+		m.maskNames([]string{"Device.WiFi.SSID.1.SSID", "Device.WiFi.AccessPoint.10001.Security.KeyPassphrase", "Device.Users.User.1.Password"}))
+}
+
+func TestMaskQuery(t *testing.T) {
+	m := newMasker(testSensitiveParameters)
+
+	testCases := []struct {
+		Name     string
+		RawQuery string
+		Expected string
+	}{
+		{Name: "Empty"},
+		{Name: "No names", RawQuery: "attributes=notify", Expected: "attributes=notify"},
+		{Name: "Nothing sensitive", RawQuery: "names=Device.WiFi.SSID.1.SSID", Expected: "names=Device.WiFi.SSID.1.SSID"},
+		{
+			Name:     "Sensitive",
+			RawQuery: "names=Device.WiFi.SSID.1.SSID,Device.Users.User.1.Password&attributes=notify",
+			Expected: "attributes=notify&names=" + url.QueryEscape("Device.WiFi.SSID.1.SSID,"+maskedValue),
+		},
+		{Name: "Malformed", RawQuery: "names=%zz", Expected: maskedValue},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, m.maskQuery(tc.RawQuery))
+		})
+	}
+}
+
+func TestMaskCapture(t *testing.T) {
+	assert := assert.New(t)
+	m := newMasker(testSensitiveParameters)
+
+	r := httptest.NewRequest(http.MethodGet, "/device/mac:112233445566/config?names=Device.Users.User.1.Password", nil)
+
+	var captured string
+	m.capture(func(ctx context.Context, r *http.Request) context.Context {
+		captured = r.URL.RawQuery
+		return ctx
+	})(context.Background(), r)
+
+	assert.Equal("names="+url.QueryEscape(maskedValue), captured)
+	assert.Equal("names=Device.Users.User.1.Password", r.URL.RawQuery)
+}
+
+func TestMaskPayload(t *testing.T) {
+	m := newMasker(testSensitiveParameters)
+
+	testCases := []struct {
+		Name     string
+		Payload  string
+		Expected string
+	}{
+		{
+			Name:     "Nothing sensitive",
+			Payload:  `{"parameters":[{"name":"Device.WiFi.SSID.1.SSID","value":"home","dataType":0}],"statusCode":200}`,
+			Expected: `{"parameters":[{"name":"Device.WiFi.SSID.1.SSID","value":"home","dataType":0}],"statusCode":200}`,
+		},
+		{
+			Name:     "Sensitive",
+			Payload:  `{"parameters":[{"name":"Device.Users.User.1.Password","value":"secret","dataType":0}],"statusCode":200}`,
+			Expected: `{"parameters":[{"dataType":0,"name":"Device.Users.User.1.Password","value":"****"}],"statusCode":200}`,
+		},
+		{
+			Name:     "Wildcard",
+			Payload:  `{"parameters":[{"name":"Device.WiFi.AccessPoint.","value":[{"name":"Device.WiFi.AccessPoint.10001.Security.KeyPassphrase","value":"secret"},{"name":"Device.WiFi.AccessPoint.10001.Enable","value":"true"}]}]}`,
+			Expected: `{"parameters":[{"name":"Device.WiFi.AccessPoint.","value":[{"name":"Device.WiFi.AccessPoint.10001.Security.KeyPassphrase","value":"****"},{"name":"Device.WiFi.AccessPoint.10001.Enable","value":"true"}]}]}`,
+		},
+		{
+			Name:     "Not JSON",
+			Payload:  `{"parameters":`,
+			Expected: `{"parameters":`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, string(m.maskPayload([]byte(tc.Payload))))
+		})
+	}
+}
+
+func TestEncodeMaskedResponse(t *testing.T) {
+	const payload = `{"parameters":[{"name":"Device.Users.User.1.Password","value":"secret"}],"statusCode":200}`
+
+	testCases := []struct {
+		Name         string
+		Method       string
+		Capabilities []interface{}
+		Masked       bool
+	}{
+		{Name: "GET", Method: http.MethodGet, Masked: true},
+		{Name: "GET with other capabilities", Method: http.MethodGet, Capabilities: []interface{}{"other"}, Masked: true},
+		{Name: "GET with reveal capability", Method: http.MethodGet, Capabilities: []interface{}{"secrets:reveal"}},
+		{Name: "PATCH", Method: http.MethodPatch},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			ctx := context.WithValue(ctxTID, kithttp.ContextKeyRequestMethod, tc.Method)
+			ctx = bascule.WithAuthentication(ctx, bascule.Authentication{
+				Token: bascule.NewToken("jwt", "client0", bascule.NewAttributes(map[string]interface{}{"capabilities": tc.Capabilities})),
+			})
+
+			body := wrp.MustEncode(&wrp.Message{Type: wrp.SimpleRequestResponseMessageType, Payload: []byte(payload)}, wrp.Msgpack)
+			recorder := httptest.NewRecorder()
+			require.NoError(newMasker(testSensitiveParameters).encodeMaskedResponse(encodeResponse)(ctx, recorder, &common.XmidtResponse{Code: http.StatusOK, Body: body}))
+
+			assert.Equal(http.StatusOK, recorder.Code)
+			if tc.Masked {
+				assert.Equal(`{"parameters":[{"name":"Device.Users.User.1.Password","value":"****"}],"statusCode":200}`, recorder.Body.String())
+			} else {
+				assert.Equal(payload, recorder.Body.String())
+			}
+		})
+	}
+}
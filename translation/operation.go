@@ -0,0 +1,128 @@
+package translation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+// unsupportedCommandLabel is the command label value of unsupported operations.
+const unsupportedCommandLabel = "unsupported"
+
+// Operation is a WDMP command for a service of a device, as sent through the device console
+// or scheduled for later. Its fields follow the WDMP ones.
+type Operation struct {
+	Command string `json:"command"`
+	Service string `json:"service"`
+
+	// GET and GET_ATTRIBUTES
+	Names      []string `json:"names,omitempty"`
+	Attributes string   `json:"attributes,omitempty"`
+
+	// SET, SET_ATTRIBUTES and TEST_AND_SET
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+	NewCID     string          `json:"new-cid,omitempty"`
+	OldCID     string          `json:"old-cid,omitempty"`
+	SyncCMC    string          `json:"sync-cmc,omitempty"`
+
+	// ADD_ROW, REPLACE_ROWS and DELETE_ROW. row is an object for ADD_ROW and a name for
+	// DELETE_ROW.
+	Table string          `json:"table,omitempty"`
+	Row   json.RawMessage `json:"row,omitempty"`
+	Rows  json.RawMessage `json:"rows,omitempty"`
+}
+
+// ReadOnly reports whether the operation only reads parameters.
+func (o Operation) ReadOnly() bool {
+	return o.Command == CommandGet || o.Command == CommandGetAttrs
+}
+
+// Payload builds the WDMP payload of the operation with the same rules as the HTTP API. As with
+// PATCH requests, the actual SET command is deduced from the parameters and CIDs.
+func (o Operation) Payload() ([]byte, error) {
+	switch o.Command {
+	case CommandGet, CommandGetAttrs:
+		return requestGetPayload(strings.Join(o.Names, ","), o.Attributes)
+	case CommandSet, CommandSetAttrs, CommandTestSet:
+		body, err := json.Marshal(struct {
+			Parameters json.RawMessage `json:"parameters,omitempty"`
+		}{o.Parameters})
+		if err != nil {
+			return nil, newInvalidWDMPError(err)
+		}
+		return requestSetPayload(bytes.NewReader(body), o.NewCID, o.OldCID, o.SyncCMC)
+	case CommandAddRow:
+		return requestAddPayload(map[string]string{"parameter": o.Table}, bytes.NewReader(o.Row))
+	case CommandReplaceRows:
+		return requestReplacePayload(map[string]string{"parameter": o.Table}, bytes.NewReader(o.Rows))
+	case CommandDeleteRow:
+		var row string
+		if len(o.Row) > 0 && json.Unmarshal(o.Row, &row) != nil {
+			return nil, ErrMissingRow
+		}
+		return requestDeletePayload(map[string]string{"parameter": row})
+	default:
+		return nil, ErrUnsupportedCommand
+	}
+}
+
+// Message returns the WRP message carrying the operation to a device on behalf of partnerIDs.
+func (o Operation) Message(deviceID, tid string, partnerIDs []string) (*wrp.Message, error) {
+	payload, err := o.Payload()
+	if err != nil {
+		return nil, err
+	}
+	return wrap(payload, tid, map[string]string{"deviceid": deviceID, "service": o.Service}, partnerIDs)
+}
+
+// DeviceResponse returns the status code the device reported along with its WDMP response out
+// of a response of the XMiDT cluster to the operation. Non-200 responses of the cluster are
+// errors. Values of sensitive parameters of GET responses are masked unless ctx carries the
+// reveal capability.
+func (o Operation) DeviceResponse(ctx context.Context, resp *common.XmidtResponse, sensitive *SensitiveParameters) (int, json.RawMessage, error) {
+	return o.response(ctx, newMasker(sensitive), resp)
+}
+
+func (o Operation) response(ctx context.Context, m *masker, resp *common.XmidtResponse) (int, json.RawMessage, error) {
+	if resp.Code != http.StatusOK {
+		return 0, nil, common.NewXmidtResponseError(resp)
+	}
+
+	if !o.ReadOnly() {
+		m = nil
+	}
+
+	body, err := devicePayload(ctx, m, resp)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	statusCode := http.StatusOK
+	var deviceResponse struct {
+		StatusCode int `json:"statusCode"`
+	}
+	if json.Unmarshal(body, &deviceResponse) == nil && deviceResponse.StatusCode != 0 {
+		statusCode = deviceResponse.StatusCode
+	}
+
+	if !json.Valid(body) {
+		// incomplete device responses are forwarded as much as possible, as with the HTTP API
+		body, _ = json.Marshal(string(body))
+	}
+	return statusCode, body, nil
+}
+
+// commandLabel is the command label value of the operation. Unsupported ones share a label.
+func (o Operation) commandLabel() string {
+	switch o.Command {
+	case CommandGet, CommandGetAttrs, CommandSet, CommandSetAttrs, CommandTestSet, CommandAddRow, CommandReplaceRows, CommandDeleteRow:
+		return o.Command
+	default:
+		return unsupportedCommandLabel
+	}
+}
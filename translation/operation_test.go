@@ -0,0 +1,154 @@
+package translation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/tr1d1um/common"
+)
+
+func TestOperationPayload(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		Operation       string
+		ExpectedPayload string
+		ExpectedErr     error
+	}{
+		{
+			Name:            "GET",
+			Operation:       `{"command":"GET","names":["a","b"]}`,
+			ExpectedPayload: `{"command":"GET","names":["a","b"]}`,
+		},
+		{
+			Name:            "GET_ATTRIBUTES",
+			Operation:       `{"command":"GET_ATTRIBUTES","names":["a"],"attributes":"notify"}`,
+			ExpectedPayload: `{"command":"GET_ATTRIBUTES","names":["a"],"attributes":"notify"}`,
+		},
+		{
+			Name:        "GET without names",
+			Operation:   `{"command":"GET"}`,
+			ExpectedErr: ErrEmptyNames,
+		},
+		{
+			Name:            "SET",
+			Operation:       `{"command":"SET","parameters":[{"name":"a","value":"b","dataType":0}]}`,
+			ExpectedPayload: `{"command":"SET","parameters":[{"name":"a","value":"b","dataType":0}]}`,
+		},
+		{
+			Name:            "TEST_AND_SET",
+			Operation:       `{"command":"TEST_AND_SET","new-cid":"new","old-cid":"old"}`,
+			ExpectedPayload: `{"command":"TEST_AND_SET","new-cid":"new","old-cid":"old"}`,
+		},
+		{
+			Name:        "SET without parameters",
+			Operation:   `{"command":"SET"}`,
+			ExpectedErr: ErrInvalidSetWDMP,
+		},
+		{
+			Name:            "ADD_ROW",
+			Operation:       `{"command":"ADD_ROW","table":"t","row":{"k":"v"}}`,
+			ExpectedPayload: `{"command":"ADD_ROW","table":"t","row":{"k":"v"}}`,
+		},
+		{
+			Name:        "ADD_ROW without row",
+			Operation:   `{"command":"ADD_ROW","table":"t"}`,
+			ExpectedErr: ErrMissingRow,
+		},
+		{
+			Name:            "REPLACE_ROWS",
+			Operation:       `{"command":"REPLACE_ROWS","table":"t","rows":{"0":{"k":"v"}}}`,
+			ExpectedPayload: `{"command":"REPLACE_ROWS","table":"t","rows":{"0":{"k":"v"}}}`,
+		},
+		{
+			Name:            "DELETE_ROW",
+			Operation:       `{"command":"DELETE_ROW","row":"r"}`,
+			ExpectedPayload: `{"command":"DELETE_ROW","row":"r"}`,
+		},
+		{
+			Name:        "DELETE_ROW with row object",
+			Operation:   `{"command":"DELETE_ROW","row":{"k":"v"}}`,
+			ExpectedErr: ErrMissingRow,
+		},
+		{
+			Name:        "Unsupported",
+			Operation:   `{"command":"REBOOT"}`,
+			ExpectedErr: ErrUnsupportedCommand,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			var o Operation
+			require.NoError(t, json.Unmarshal([]byte(tc.Operation), &o))
+
+			payload, err := o.Payload()
+			if tc.ExpectedErr != nil {
+				assert.Equal(tc.ExpectedErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.JSONEq(tc.ExpectedPayload, string(payload))
+		})
+	}
+}
+
+func TestOperationDeviceResponse(t *testing.T) {
+	sensitive := &SensitiveParameters{Patterns: []string{"Device.Secret"}, MaskResponses: true}
+	testCases := []struct {
+		Name               string
+		Operation          Operation
+		Response           *common.XmidtResponse
+		ExpectedStatusCode int
+		ExpectedResponse   string
+		ExpectedErr        bool
+	}{
+		{
+			Name:               "GET masked",
+			Operation:          Operation{Command: CommandGet},
+			Response:           deviceResponse(`{"statusCode":200,"parameters":[{"name":"Device.Secret","value":"s"}]}`),
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedResponse:   `{"statusCode":200,"parameters":[{"name":"Device.Secret","value":"****"}]}`,
+		},
+		{
+			Name:               "SET device failure",
+			Operation:          Operation{Command: CommandSet},
+			Response:           deviceResponse(`{"statusCode":520,"message":"failure"}`),
+			ExpectedStatusCode: 520,
+			ExpectedResponse:   `{"statusCode":520,"message":"failure"}`,
+		},
+		{
+			Name:               "Incomplete response",
+			Operation:          Operation{Command: CommandSet},
+			Response:           deviceResponse(`{"statusCode":200`),
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedResponse:   `"{\"statusCode\":200"`,
+		},
+		{
+			Name:        "XMiDT failure",
+			Operation:   Operation{Command: CommandGet},
+			Response:    &common.XmidtResponse{Code: http.StatusNotFound},
+			ExpectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			statusCode, response, err := tc.Operation.DeviceResponse(context.Background(), tc.Response, sensitive)
+			if tc.ExpectedErr {
+				assert.Error(err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(tc.ExpectedStatusCode, statusCode)
+			assert.JSONEq(tc.ExpectedResponse, string(response))
+		})
+	}
+}
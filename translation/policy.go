@@ -0,0 +1,199 @@
+package translation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+var errForbiddenParameters = errors.New("forbidden parameters")
+
+// ParameterPolicy restricts the parameter and table names callers may read or write.
+type ParameterPolicy struct {
+	// Rules are evaluated for every name of a request. A name is forbidden if it matches a
+	// deny pattern of any rule applying to the caller. Otherwise, it is allowed if it matches
+	// an allow pattern of such a rule.
+	Rules []ParameterRule
+
+	// DefaultDeny forbids the names no applying rule allows.
+	// (Optional) By default, such names are allowed.
+	DefaultDeny bool
+}
+
+// ParameterRule allows or denies names to the callers it applies to. Patterns are globs
+// (i.e. 'Device.*.FactoryReset') if they include any of '*?[' and name prefixes otherwise.
+type ParameterRule struct {
+	// Capabilities, Partners and Principals select the callers the rule applies to. A caller
+	// matching any of them is selected. A rule without any of them applies to every caller.
+	Capabilities []string
+	Partners     []string
+	Principals   []string
+
+	// Commands restricts the rule to the given WDMP commands (i.e. SET, ADD_ROW).
+	// (Optional) By default, the rule applies to all commands.
+	Commands []string
+
+	Allow []string
+	Deny  []string
+}
+
+// policyCaller is what parameter rules select callers on.
+type policyCaller struct {
+	principal    string
+	partnerIDs   []string
+	capabilities []string
+}
+
+func (r ParameterRule) appliesTo(c policyCaller, command string) bool {
+	if len(r.Commands) > 0 && !contains(command, r.Commands) {
+		return false
+	}
+
+	if len(r.Capabilities) == 0 && len(r.Partners) == 0 && len(r.Principals) == 0 {
+		return true
+	}
+
+	if c.principal != "" && contains(c.principal, r.Principals) {
+		return true
+	}
+
+	for _, p := range c.partnerIDs {
+		if contains(p, r.Partners) {
+			return true
+		}
+	}
+
+	for _, capability := range c.capabilities {
+		if contains(capability, r.Capabilities) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if strings.ContainsAny(p, "*?[") {
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		} else if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// forbidden returns the names of the given command the caller may not use.
+func (p *ParameterPolicy) forbidden(c policyCaller, command string, names []string) []string {
+	var applying []ParameterRule
+	for _, r := range p.Rules {
+		if r.appliesTo(c, command) {
+			applying = append(applying, r)
+		}
+	}
+
+	var forbidden []string
+	for _, name := range names {
+		allowed, denied := false, false
+		for _, r := range applying {
+			if matchesAny(name, r.Deny) {
+				denied = true
+				break
+			}
+			allowed = allowed || matchesAny(name, r.Allow)
+		}
+
+		if denied || (!allowed && p.DefaultDeny) {
+			forbidden = append(forbidden, name)
+		}
+	}
+	return forbidden
+}
+
+// wdmpNames returns the command of a WDMP payload along with the parameter or table names it targets.
+func wdmpNames(payload []byte) (string, []string, error) {
+	var command struct {
+		Command string `json:"command"`
+	}
+
+	if err := json.Unmarshal(payload, &command); err != nil {
+		return "", nil, err
+	}
+
+	switch command.Command {
+	case CommandGet, CommandGetAttrs:
+		var wdmp getWDMP
+		err := json.Unmarshal(payload, &wdmp)
+		return wdmp.Command, wdmp.Names, err
+	case CommandAddRow:
+		var wdmp addRowWDMP
+		err := json.Unmarshal(payload, &wdmp)
+		return wdmp.Command, []string{wdmp.Table}, err
+	case CommandReplaceRows:
+		var wdmp replaceRowsWDMP
+		err := json.Unmarshal(payload, &wdmp)
+		return wdmp.Command, []string{wdmp.Table}, err
+	case CommandDeleteRow:
+		var wdmp deleteRowDMP
+		err := json.Unmarshal(payload, &wdmp)
+		return wdmp.Command, []string{wdmp.Row}, err
+	}
+
+	var wdmp setWDMP
+	err := json.Unmarshal(payload, &wdmp)
+	return wdmp.Command, getParamNames(wdmp.Parameters), err
+}
+
+// decodeAuthorizedRequest decorates a WRP request decoder such that requests using names
+// forbidden by the policy are rejected with a 403 listing them.
+func decodeAuthorizedRequest(policy *ParameterPolicy, decoder kithttp.DecodeRequestFunc) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		decoded, err := decoder(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := policy.Authorize(ctx, decoded.(*wrpRequest).WRPMessage); err != nil {
+			return nil, err
+		}
+		return decoded, nil
+	}
+}
+
+// Authorize returns a 403 error listing the names of the WDMP payload of msg forbidden to the
+// caller in ctx.
+func (p *ParameterPolicy) Authorize(ctx context.Context, msg *wrp.Message) error {
+	if p == nil || (len(p.Rules) == 0 && !p.DefaultDeny) {
+		return nil
+	}
+
+	command, names, err := wdmpNames(msg.Payload)
+	if err != nil {
+		return err
+	}
+
+	// partner ID headers can be set by anyone, so rules are only selected on token partner IDs
+	tokenPartners, _ := common.TokenPartnerIDs(ctx)
+	c := policyCaller{
+		partnerIDs:   tokenPartners,
+		capabilities: common.Capabilities(ctx),
+	}
+	if auth, ok := bascule.FromContext(ctx); ok {
+		c.principal = auth.Token.Principal()
+	}
+
+	if forbidden := p.forbidden(c, command, names); len(forbidden) > 0 {
+		return common.NewProblemError(fmt.Errorf("%w: %s", errForbiddenParameters, strings.Join(forbidden, ", ")), http.StatusForbidden, ErrorCodeForbiddenParameters, "Forbidden parameters", forbidden...)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package translation
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/common"
+)
+
+func TestParameterPolicyForbidden(t *testing.T) {
+	policy := &ParameterPolicy{
+		Rules: []ParameterRule{
+			{Deny: []string{"Device.*.FactoryReset", "Device.DeviceInfo.X_RDKCENTRAL-COM_FirmwareDownloadURL"}, Commands: []string{CommandSet}},
+			{Principals: []string{"ops"}, Allow: []string{"Device."}},
+			{Partners: []string{"partnerA"}, Allow: []string{"Device.WiFi."}},
+			{Capabilities: []string{"x1:webpa:api:.*:all"}, Deny: []string{"Device.WiFi.Radio."}},
+		},
+		DefaultDeny: true,
+	}
+
+	names := []string{"Device.WiFi.SSID.1.SSID", "Device.WiFi.Radio.1.Enable", "Device.X_CISCO_COM_DeviceControl.FactoryReset", "Device.Time.Enable"}
+
+	testCases := []struct {
+		Name      string
+		Caller    policyCaller
+		Command   string
+		Forbidden []string
+	}{
+		{
+			Name:      "Stranger",
+			Command:   CommandGet,
+			Forbidden: names,
+		},
+		{
+			Name:      "Partner",
+			Caller:    policyCaller{partnerIDs: []string{"partnerA"}},
+			Command:   CommandGet,
+			Forbidden: []string{"Device.X_CISCO_COM_DeviceControl.FactoryReset", "Device.Time.Enable"},
+		},
+		{
+			Name:    "Principal reading",
+			Caller:  policyCaller{principal: "ops"},
+			Command: CommandGet,
+		},
+		{
+			Name:      "Principal writing",
+			Caller:    policyCaller{principal: "ops"},
+			Command:   CommandSet,
+			Forbidden: []string{"Device.X_CISCO_COM_DeviceControl.FactoryReset"},
+		},
+		{
+			Name:      "Deny wins over allow",
+			Caller:    policyCaller{principal: "ops", capabilities: []string{"x1:webpa:api:.*:all"}},
+			Command:   CommandGet,
+			Forbidden: []string{"Device.WiFi.Radio.1.Enable"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert.Equal(t, testCase.Forbidden, policy.forbidden(testCase.Caller, testCase.Command, names))
+		})
+	}
+}
+
+func TestWDMPNames(t *testing.T) {
+	testCases := []struct {
+		Payload         string
+		ExpectedCommand string
+		ExpectedNames   []string
+	}{
+		{`{"command":"GET","names":["a","b"]}`, CommandGet, []string{"a", "b"}},
+		{`{"command":"SET","parameters":[{"name":"a","value":"1","dataType":0}]}`, CommandSet, []string{"a"}},
+		{`{"command":"ADD_ROW","table":"Device.NAT.PortMapping.","row":{"Enable":"true"}}`, CommandAddRow, []string{"Device.NAT.PortMapping."}},
+		{`{"command":"REPLACE_ROWS","table":"Device.NAT.PortMapping.","rows":{}}`, CommandReplaceRows, []string{"Device.NAT.PortMapping."}},
+		{`{"command":"DELETE_ROW","row":"Device.NAT.PortMapping.1."}`, CommandDeleteRow, []string{"Device.NAT.PortMapping.1."}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.ExpectedCommand, func(t *testing.T) {
+			assert := assert.New(t)
+			command, names, err := wdmpNames([]byte(testCase.Payload))
+			assert.Nil(err)
+			assert.Equal(testCase.ExpectedCommand, command)
+			assert.Equal(testCase.ExpectedNames, names)
+		})
+	}
+}
+
+func TestDecodeAuthorizedRequest(t *testing.T) {
+	assert := assert.New(t)
+	policy := &ParameterPolicy{Rules: []ParameterRule{{Deny: []string{"Device.X_CISCO_COM_DeviceControl.FactoryReset"}}}}
+	decoder := decodeAuthorizedRequest(policy, decodeRequest(nil))
+
+	ctx := bascule.WithAuthentication(ctxTID, bascule.Authentication{
+		Token: bascule.NewToken("jwt", "client0", bascule.NewAttributes(map[string]interface{}{})),
+	})
+
+	r := httptest.NewRequest(http.MethodPatch, "http://localhost/device/mac:112233445566/config",
+		bytes.NewBufferString(`{"parameters":[{"name":"Device.X_CISCO_COM_DeviceControl.FactoryReset","value":"Router","dataType":0},{"name":"Device.Time.Enable","value":"true","dataType":3}]}`))
+	r = mux.SetURLVars(r, map[string]string{"deviceid": "mac:112233445566", "service": "config"})
+
+	_, err := decoder(ctx, r)
+	assert.Equal(http.StatusForbidden, err.(common.CodedError).StatusCode())
+	assert.Equal("forbidden parameters: Device.X_CISCO_COM_DeviceControl.FactoryReset", err.Error())
+
+	r = httptest.NewRequest(http.MethodGet, "http://localhost/device/mac:112233445566/config?names=Device.Time.Enable", nil)
+	r = mux.SetURLVars(r, map[string]string{"deviceid": "mac:112233445566", "service": "config"})
+	decoded, err := decoder(ctx, r)
+	assert.Nil(err)
+	assert.NotNil(decoded)
+}
+
+func TestAuthorizeIgnoresHeaderPartners(t *testing.T) {
+	assert := assert.New(t)
+	policy := &ParameterPolicy{
+		Rules:       []ParameterRule{{Partners: []string{"partnerA"}, Allow: []string{"Device.WiFi."}}},
+		DefaultDeny: true,
+	}
+	decoder := decodeAuthorizedRequest(policy, decodeRequest(nil))
+
+	// the caller's token carries no partner IDs
+	ctx := bascule.WithAuthentication(ctxTID, bascule.Authentication{
+		Token: bascule.NewToken("basic", "client0", bascule.NewAttributes(map[string]interface{}{})),
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "http://localhost/device/mac:112233445566/config?names=Device.WiFi.SSID.1.SSID", nil)
+	r.Header.Set("X-Xmidt-Partner-Id", "partnerA")
+	r = mux.SetURLVars(r, map[string]string{"deviceid": "mac:112233445566", "service": "config"})
+
+	_, err := decoder(ctx, r)
+	if assert.Error(err) {
+		assert.Equal(http.StatusForbidden, err.(common.CodedError).StatusCode())
+	}
+
+	ctx = bascule.WithAuthentication(ctxTID, bascule.Authentication{
+		Token: bascule.NewToken("jwt", "client0", bascule.NewAttributes(map[string]interface{}{
+			"allowedResources": map[string]interface{}{"allowedPartners": []string{"partnerA"}},
+		})),
+	})
+	_, err = decoder(ctx, r)
+	assert.Nil(err)
+}
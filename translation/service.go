@@ -60,7 +60,9 @@ func (w *service) SendWRP(ctx context.Context, wrpMsg *wrp.Message, authHeaderVa
 
 	var payload []byte
 
+	_, span := common.StartSpan(ctx, common.EncodeWRPSpan)
 	err := wrp.NewEncoderBytes(&payload, wrp.Msgpack).Encode(wrpMsg)
+	common.EndSpan(span, err)
 
 	if err != nil {
 		return nil, err
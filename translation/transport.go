@@ -9,16 +9,14 @@ import (
 	"strings"
 
 	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics/provider"
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
 	"github.com/justinas/alice"
-	"github.com/spf13/cast"
 
-	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/audit"
 	"github.com/xmidt-org/tr1d1um/common"
-	"github.com/xmidt-org/webpa-common/basculechecks"
 	"github.com/xmidt-org/wrp-go/v3"
-	"github.com/xmidt-org/wrp-go/v3/wrphttp"
 )
 
 const (
@@ -44,92 +42,108 @@ type Options struct {
 	Log                         kitlog.Logger
 	ValidServices               []string
 	ReducedLoggingResponseCodes []int
+
+	//PartnerPolicy configures how partner IDs are derived from tokens and headers.
+	//(Optional) By default, token partner IDs are preferred over header ones.
+	PartnerPolicy *common.PartnerPolicy
+
+	//ParameterPolicy restricts the parameter and table names callers may use.
+	//(Optional) By default, all names are allowed.
+	ParameterPolicy *ParameterPolicy
+
+	//SensitiveParameters are masked in transaction logs and, optionally, in GET responses.
+	//(Optional) By default, nothing is masked.
+	SensitiveParameters *SensitiveParameters
+
+	//Audit records the mutating commands along with their outcome.
+	//(Optional) By default, nothing is recorded.
+	Audit audit.Sink
+
+	//Runtime, when set, overrides ValidServices and ReducedLoggingResponseCodes with the ones
+	//of its current snapshot.
+	//(Optional)
+	Runtime *common.Runtime
+
+	//MetricsProvider helps initialize the route metrics.
+	//(Optional) Defaults to a discard provider.
+	MetricsProvider provider.Provider
+
+	//Drainer tracks the requests that must complete before shutting down and rejects the
+	//ones arriving while draining. Console sessions are not tracked, their commands are.
+	//(Optional)
+	Drainer *common.Drainer
+
+	//Console enables the device console (WebSocket) endpoint when set.
+	//(Optional)
+	Console *ConsoleOptions
 }
 
 // ConfigHandler sets up the server that powers the translation service
 func ConfigHandler(c *Options) {
+	validServices := func() []string { return c.ValidServices }
+	transactionLogging := common.TransactionLogging(c.ReducedLoggingResponseCodes, c.Log)
+	if c.Runtime != nil {
+		validServices = func() []string { return c.Runtime.Load().ValidServices }
+		transactionLogging = common.RuntimeTransactionLogging(c.Runtime, c.Log)
+	}
+
+	m := newMasker(c.SensitiveParameters)
+	routeMetrics := common.NewRouteMetrics(c.MetricsProvider)
 	opts := []kithttp.ServerOption{
-		kithttp.ServerBefore(common.Capture(c.Log), captureWDMPParameters),
+		kithttp.ServerBefore(kithttp.PopulateRequestContext, m.capture(common.Capture(c.Log)), captureWDMPParameters(m), routeMetrics.Before),
 		kithttp.ServerErrorEncoder(common.ErrorLogEncoder(c.Log, encodeError)),
-		kithttp.ServerFinalizer(common.TransactionLogging(c.ReducedLoggingResponseCodes, c.Log)),
+		kithttp.ServerFinalizer(transactionLogging, routeMetrics.Finalizer),
 	}
 
 	WRPHandler := kithttp.NewServer(
 		makeTranslationEndpoint(c.S),
-		decodeValidServiceRequest(c.ValidServices, decodeRequest),
-		encodeResponse,
+		decodeTraced(decodeRouteLabels(decodeValidServiceRequest(validServices, decodeAuthorizedRequest(c.ParameterPolicy, decodeRequest(c.PartnerPolicy))))),
+		m.encodeMaskedResponse(encodeResponse),
 		opts...,
 	)
 
-	c.APIRouter.Handle("/device/{deviceid}/{service}", c.Authenticate.Then(common.Welcome(WRPHandler))).
-		Methods(http.MethodGet, http.MethodPatch)
-
-	c.APIRouter.Handle("/device/{deviceid}/{service}/{parameter}", c.Authenticate.Then(common.Welcome(WRPHandler))).
-		Methods(http.MethodDelete, http.MethodPut, http.MethodPost)
-}
-
-// getPartnerIDs returns the array that represents the partner-ids that were
-// passed in as headers.  This function handles multiple duplicate headers.
-func getPartnerIDs(h http.Header) []string {
-	headers, ok := h[wrphttp.PartnerIdHeader]
-	if !ok {
-		return nil
-	}
-
-	var partners []string
+	auditing := audit.Middleware(c.Audit, c.Log, describeOperation(m, c.PartnerPolicy))
 
-	for _, value := range headers {
-		fields := strings.Split(value, ",")
-		for i := 0; i < len(fields); i++ {
-			fields[i] = strings.TrimSpace(fields[i])
-		}
-		partners = append(partners, fields...)
+	// Must be registered before the '/device/{deviceid}/{service}' route which would match console requests too.
+	if c.Console != nil {
+		console := newConsoleHandler(*c.Console, auditEndpoint(c.Audit, c.Log, m)(makeTranslationEndpoint(c.S)), validServices, c, m)
+		c.APIRouter.Handle("/device/{deviceid}/console", c.Authenticate.Then(common.Welcome(console))).
+			Methods(http.MethodGet)
 	}
-	return partners
-}
 
-// getPartnerIDsDecodeRequest returns array of partnerIDs needed for decodeRequest
-func getPartnerIDsDecodeRequest(ctx context.Context, r *http.Request) []string {
-	auth, ok := bascule.FromContext(ctx)
-	//if no token
-	if !ok {
-		return getPartnerIDs(r.Header)
-	}
-	tokenType := auth.Token.Type()
-	//if not jwt type
-	if tokenType != "jwt" {
-		return getPartnerIDs(r.Header)
-	}
-	partnerVal, ok := bascule.GetNestedAttribute(auth.Token.Attributes(), basculechecks.PartnerKeys()...)
-	//if no partner ids
-	if !ok {
-		return getPartnerIDs(r.Header)
-	}
-	partnerIDs, err := cast.ToStringSliceE(partnerVal)
+	c.APIRouter.Handle("/device/{deviceid}/{service}", c.Drainer.Track(c.Authenticate.Then(auditing(common.Welcome(WRPHandler))))).
+		Methods(http.MethodGet, http.MethodPatch)
 
-	if err != nil {
-		return getPartnerIDs(r.Header)
-	}
-	return partnerIDs
+	c.APIRouter.Handle("/device/{deviceid}/{service}/{parameter}", c.Drainer.Track(c.Authenticate.Then(auditing(common.Welcome(WRPHandler))))).
+		Methods(http.MethodDelete, http.MethodPut, http.MethodPost)
 }
 
 /* Request Decoding */
-func decodeRequest(ctx context.Context, r *http.Request) (decodedRequest interface{}, err error) {
-	var (
-		payload []byte
-		wrpMsg  *wrp.Message
-	)
-	if payload, err = requestPayload(r); err == nil {
-		var tid = ctx.Value(common.ContextKeyRequestTID).(string)
-		partnerIDs := getPartnerIDsDecodeRequest(ctx, r)
-		if wrpMsg, err = wrap(payload, tid, mux.Vars(r), partnerIDs); err == nil {
-			decodedRequest = &wrpRequest{
-				WRPMessage:      wrpMsg,
-				AuthHeaderValue: r.Header.Get(authHeaderKey),
+func decodeRequest(partnerPolicy *common.PartnerPolicy) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (decodedRequest interface{}, err error) {
+		var (
+			payload    []byte
+			wrpMsg     *wrp.Message
+			partnerIDs []string
+		)
+		if partnerIDs, err = partnerPolicy.PartnerIDs(ctx, r.Header); err != nil {
+			return
+		}
+		_, span := common.StartSpan(ctx, common.BuildWDMPSpan)
+		payload, err = requestPayload(r)
+		common.EndSpan(span, err)
+
+		if err == nil {
+			var tid = ctx.Value(common.ContextKeyRequestTID).(string)
+			if wrpMsg, err = wrap(payload, tid, mux.Vars(r), partnerIDs); err == nil {
+				decodedRequest = &wrpRequest{
+					WRPMessage:      wrpMsg,
+					AuthHeaderValue: r.Header.Get(authHeaderKey),
+				}
 			}
 		}
+		return
 	}
-	return
 }
 
 func requestPayload(r *http.Request) (payload []byte, err error) {
@@ -170,6 +184,9 @@ func encodeResponse(ctx context.Context, w http.ResponseWriter, response interfa
 		return
 	}
 
+	_, span := common.StartSpan(ctx, common.DecodeResponseSpan)
+	defer func() { common.EndSpan(span, err) }()
+
 	wrpModel := new(wrp.Message)
 
 	if err = wrp.NewDecoderBytes(resp.Body, wrp.Msgpack).Decode(wrpModel); err == nil {
@@ -182,6 +199,11 @@ func encodeResponse(ctx context.Context, w http.ResponseWriter, response interfa
 
 		// if possible, use the device response status code
 		if errUnmarshall := json.Unmarshal(wrpModel.Payload, &deviceResponseModel); errUnmarshall == nil {
+			if deviceResponseModel.StatusCode != 0 {
+				common.SetDeviceStatus(ctx, deviceResponseModel.StatusCode)
+				span.SetAttributes(common.DeviceStatusAttribute.Int(deviceResponseModel.StatusCode))
+			}
+
 			if deviceResponseModel.StatusCode != 0 && deviceResponseModel.StatusCode != http.StatusInternalServerError {
 				w.WriteHeader(deviceResponseModel.StatusCode)
 			}
@@ -196,23 +218,7 @@ func encodeResponse(ctx context.Context, w http.ResponseWriter, response interfa
 /* Error Encoding */
 
 func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
-	w.Header().Set(contentTypeHeaderKey, "application/json; charset=utf-8")
-	w.Header().Set(common.HeaderWPATID, ctx.Value(common.ContextKeyRequestTID).(string))
-
-	if ce, ok := err.(common.CodedError); ok {
-		w.WriteHeader(ce.StatusCode())
-	} else {
-		w.WriteHeader(http.StatusInternalServerError)
-
-		//the real error is logged into our system before encodeError() is called
-		//the idea behind masking it is to not send the external API consumer internal error messages
-		err = common.ErrTr1d1umInternal
-	}
-
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": err.Error(),
-	})
-
+	common.WriteError(ctx, err, w)
 }
 
 /* Request-type specific decoding functions */
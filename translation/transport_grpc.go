@@ -0,0 +1,278 @@
+package translation
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	kitlog "github.com/go-kit/kit/log"
+	kitgrpc "github.com/go-kit/kit/transport/grpc"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/audit"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/rpc/tr1d1umpb"
+	"github.com/xmidt-org/webpa-common/logging"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// GRPCOptions wraps the properties needed to serve the translation RPCs. They mean the same
+// as the ones of Options.
+type GRPCOptions struct {
+	S   Service
+	Log kitlog.Logger
+
+	ValidServices       []string
+	PartnerPolicy       *common.PartnerPolicy
+	ParameterPolicy     *ParameterPolicy
+	SensitiveParameters *SensitiveParameters
+	Audit               audit.Sink
+	Runtime             *common.Runtime
+}
+
+// GRPCHandlers serve the translation RPCs of the gRPC API.
+type GRPCHandlers struct {
+	Get         kitgrpc.Handler
+	Set         kitgrpc.Handler
+	TestAndSet  kitgrpc.Handler
+	AddRow      kitgrpc.Handler
+	ReplaceRows kitgrpc.Handler
+	DeleteRow   kitgrpc.Handler
+}
+
+// NewGRPCHandlers sets up the handlers of the translation RPCs. They share the endpoint of
+// the HTTP API along with its service, partner and parameter checks.
+func NewGRPCHandlers(o *GRPCOptions) *GRPCHandlers {
+	validServices := func() []string { return o.ValidServices }
+	if o.Runtime != nil {
+		validServices = func() []string { return o.Runtime.Load().ValidServices }
+	}
+
+	m := newMasker(o.SensitiveParameters)
+	e := auditEndpoint(o.Audit, o.Log, m)(makeTranslationEndpoint(o.S))
+	opts := []kitgrpc.ServerOption{
+		kitgrpc.ServerBefore(common.CaptureGRPC(o.Log)),
+		kitgrpc.ServerErrorHandler(common.GRPCErrorLogger(o.Log)),
+		kitgrpc.ServerFinalizer(common.GRPCTransactionLogging),
+	}
+
+	newHandler := func(payload grpcPayload, encode kitgrpc.EncodeResponseFunc) kitgrpc.Handler {
+		return kitgrpc.NewServer(e, decodeGRPCRequest(validServices, o.PartnerPolicy, o.ParameterPolicy, payload), encode, opts...)
+	}
+
+	return &GRPCHandlers{
+		Get:         newHandler(getGRPCPayload, encodeGRPCResponse(m)),
+		Set:         newHandler(setGRPCPayload, encodeGRPCResponse(nil)),
+		TestAndSet:  newHandler(testAndSetGRPCPayload, encodeGRPCResponse(nil)),
+		AddRow:      newHandler(addRowGRPCPayload, encodeGRPCResponse(nil)),
+		ReplaceRows: newHandler(replaceRowsGRPCPayload, encodeGRPCResponse(nil)),
+		DeleteRow:   newHandler(deleteRowGRPCPayload, encodeGRPCResponse(nil)),
+	}
+}
+
+// deviceServiceRequest is implemented by the requests of all the translation RPCs.
+type deviceServiceRequest interface {
+	GetDeviceId() string
+	GetService() string
+}
+
+// grpcPayload builds the WDMP payload of a translation RPC request.
+type grpcPayload func(request interface{}) ([]byte, error)
+
+func decodeGRPCRequest(validServices func() []string, partnerPolicy *common.PartnerPolicy, policy *ParameterPolicy, payload grpcPayload) kitgrpc.DecodeRequestFunc {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		r := request.(deviceServiceRequest)
+		if !contains(r.GetService(), validServices()) {
+			return nil, ErrInvalidService
+		}
+
+		header := common.RequestHeader(ctx)
+		partnerIDs, err := partnerPolicy.PartnerIDs(ctx, header)
+		if err != nil {
+			return nil, err
+		}
+
+		wdmp, err := payload(request)
+		if err != nil {
+			return nil, err
+		}
+
+		tid, _ := ctx.Value(common.ContextKeyRequestTID).(string)
+		wrpMsg, err := wrap(wdmp, tid, map[string]string{"deviceid": r.GetDeviceId(), "service": r.GetService()}, partnerIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		req := &wrpRequest{
+			WRPMessage:      wrpMsg,
+			AuthHeaderValue: header.Get(authHeaderKey),
+		}
+		if err := policy.Authorize(ctx, wrpMsg); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}
+}
+
+func getGRPCPayload(request interface{}) ([]byte, error) {
+	r := request.(*tr1d1umpb.GetRequest)
+	return requestGetPayload(strings.Join(r.Names, ","), r.Attributes)
+}
+
+func setGRPCPayload(request interface{}) ([]byte, error) {
+	return setPayload(request.(*tr1d1umpb.SetRequest).Parameters, "", "", "")
+}
+
+func testAndSetGRPCPayload(request interface{}) ([]byte, error) {
+	r := request.(*tr1d1umpb.TestAndSetRequest)
+	if r.NewCid == "" {
+		return nil, ErrNewCIDRequired
+	}
+	return setPayload(r.Parameters, r.NewCid, r.OldCid, r.SyncCmc)
+}
+
+// setPayload is requestSetPayload for typed parameters.
+func setPayload(parameters []*tr1d1umpb.Parameter, newCID, oldCID, syncCMC string) ([]byte, error) {
+	wdmp := &setWDMP{Parameters: make([]setParam, 0, len(parameters))}
+	for _, p := range parameters {
+		name := p.Name
+		param := setParam{Name: &name}
+
+		if p.Value != nil {
+			if p.DataType < 0 || p.DataType > math.MaxInt8 {
+				return nil, ErrInvalidSetWDMP
+			}
+
+			dataType := int8(p.DataType)
+			param.DataType, param.Value = &dataType, p.Value.AsInterface()
+		}
+
+		if p.Attributes != nil {
+			param.Attributes = p.Attributes.AsMap()
+		}
+		wdmp.Parameters = append(wdmp.Parameters, param)
+	}
+
+	if err := deduceSET(wdmp, newCID, oldCID, syncCMC); err != nil {
+		return nil, err
+	}
+
+	if !isValidSetWDMP(wdmp) {
+		return nil, ErrInvalidSetWDMP
+	}
+	return json.Marshal(wdmp)
+}
+
+func addRowGRPCPayload(request interface{}) ([]byte, error) {
+	r := request.(*tr1d1umpb.AddRowRequest)
+	if r.Table == "" {
+		return nil, ErrMissingTable
+	}
+
+	if len(r.Row) == 0 {
+		return nil, ErrMissingRow
+	}
+	return json.Marshal(&addRowWDMP{Command: CommandAddRow, Table: r.Table, Row: r.Row})
+}
+
+func replaceRowsGRPCPayload(request interface{}) ([]byte, error) {
+	r := request.(*tr1d1umpb.ReplaceRowsRequest)
+	table := strings.TrimSpace(r.Table)
+	if table == "" {
+		return nil, ErrMissingTable
+	}
+
+	rows := make(indexRow, len(r.Rows))
+	for index, row := range r.Rows {
+		rows[index] = row.GetColumns()
+	}
+	return json.Marshal(&replaceRowsWDMP{Command: CommandReplaceRows, Table: table, Rows: rows})
+}
+
+func deleteRowGRPCPayload(request interface{}) ([]byte, error) {
+	return requestDeletePayload(map[string]string{"parameter": request.(*tr1d1umpb.DeleteRowRequest).Row})
+}
+
+// encodeGRPCResponse returns the encoder of the translation RPCs. Non-200 responses of the
+// XMiDT cluster are errors while device failures are responses. The masker, when set, hides
+// the values of sensitive parameters as in GET responses.
+func encodeGRPCResponse(m *masker) kitgrpc.EncodeResponseFunc {
+	return func(ctx context.Context, response interface{}) (interface{}, error) {
+		resp := response.(*common.XmidtResponse)
+		if resp.Code != http.StatusOK {
+			return nil, common.NewXmidtResponseError(resp)
+		}
+
+		payload, err := devicePayload(ctx, m, resp)
+		if err != nil {
+			return nil, err
+		}
+
+		var model map[string]interface{}
+		if err := json.Unmarshal(payload, &model); err != nil {
+			return nil, err
+		}
+
+		s, err := structpb.NewStruct(model)
+		if err != nil {
+			return nil, err
+		}
+
+		statusCode, _ := model["statusCode"].(float64)
+		message, _ := model["message"].(string)
+		return &tr1d1umpb.DeviceResponse{
+			StatusCode: int32(statusCode),
+			Message:    message,
+			Payload:    s,
+		}, nil
+	}
+}
+
+// auditEndpoint is the endpoint counterpart of audit.Middleware for gRPC and console requests.
+// Unlike with HTTP, requests rejected before reaching the endpoint are not audited.
+func auditEndpoint(sink audit.Sink, logger kitlog.Logger, m *masker) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		if sink == nil {
+			return next
+		}
+
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			response, err := next(ctx, request)
+
+			wrpMsg := request.(*wrpRequest).WRPMessage
+			command, parameters, describeErr := auditParameters(wrpMsg.Payload, m)
+			if describeErr != nil || command == CommandGet || command == CommandGetAttrs {
+				return response, err
+			}
+
+			record := audit.Record{
+				Time:       time.Now().UTC(),
+				TID:        wrpMsg.TransactionUUID,
+				PartnerIDs: wrpMsg.PartnerIDs,
+				DeviceID:   strings.SplitN(wrpMsg.Destination, "/", 2)[0],
+				Operation:  command,
+				Parameters: parameters,
+				Code:       http.StatusOK,
+			}
+
+			if auth, ok := bascule.FromContext(ctx); ok {
+				record.Principal = auth.Token.Principal()
+			}
+
+			if err != nil {
+				record.Code = common.NewProblem(err, "").Status
+			} else {
+				record.Code = response.(*common.XmidtResponse).Code
+			}
+
+			if writeErr := sink.Write(ctx, record); writeErr != nil {
+				logging.Error(logger).Log(logging.MessageKey(), "Failed to write audit record", "tid", record.TID,
+					"operation", record.Operation, logging.ErrorKey(), writeErr)
+			}
+			return response, err
+		}
+	}
+}
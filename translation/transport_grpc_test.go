@@ -0,0 +1,294 @@
+package translation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/audit"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/rpc/tr1d1umpb"
+	"github.com/xmidt-org/webpa-common/logging"
+	"github.com/xmidt-org/wrp-go/v3"
+	"github.com/xmidt-org/wrp-go/v3/wrphttp"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestGRPCPayloads(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		Payload         grpcPayload
+		Request         interface{}
+		ExpectedPayload string
+		ExpectedErr     error
+	}{
+		{
+			Name:            "Get",
+			Payload:         getGRPCPayload,
+			Request:         &tr1d1umpb.GetRequest{Names: []string{"a", "b"}},
+			ExpectedPayload: `{"command":"GET","names":["a","b"]}`,
+		},
+		{
+			Name:            "Get attributes",
+			Payload:         getGRPCPayload,
+			Request:         &tr1d1umpb.GetRequest{Names: []string{"a"}, Attributes: "notify"},
+			ExpectedPayload: `{"command":"GET_ATTRIBUTES","names":["a"],"attributes":"notify"}`,
+		},
+		{
+			Name:        "Get without names",
+			Payload:     getGRPCPayload,
+			Request:     &tr1d1umpb.GetRequest{},
+			ExpectedErr: ErrEmptyNames,
+		},
+		{
+			Name:    "Set",
+			Payload: setGRPCPayload,
+			Request: &tr1d1umpb.SetRequest{Parameters: []*tr1d1umpb.Parameter{
+				{Name: "a", DataType: 3, Value: structpb.NewBoolValue(true)},
+			}},
+			ExpectedPayload: `{"command":"SET","parameters":[{"name":"a","value":true,"dataType":3}]}`,
+		},
+		{
+			Name:    "Set attributes",
+			Payload: setGRPCPayload,
+			Request: &tr1d1umpb.SetRequest{Parameters: []*tr1d1umpb.Parameter{
+				{Name: "a", Attributes: &structpb.Struct{Fields: map[string]*structpb.Value{"notify": structpb.NewNumberValue(1)}}},
+			}},
+			ExpectedPayload: `{"command":"SET_ATTRIBUTES","parameters":[{"name":"a","attributes":{"notify":1}}]}`,
+		},
+		{
+			Name:    "Set invalid data type",
+			Payload: setGRPCPayload,
+			Request: &tr1d1umpb.SetRequest{Parameters: []*tr1d1umpb.Parameter{
+				{Name: "a", DataType: 300, Value: structpb.NewStringValue("b")},
+			}},
+			ExpectedErr: ErrInvalidSetWDMP,
+		},
+		{
+			Name:        "Set without parameters",
+			Payload:     setGRPCPayload,
+			Request:     &tr1d1umpb.SetRequest{},
+			ExpectedErr: ErrInvalidSetWDMP,
+		},
+		{
+			Name:            "Test and set",
+			Payload:         testAndSetGRPCPayload,
+			Request:         &tr1d1umpb.TestAndSetRequest{NewCid: "new", OldCid: "old"},
+			ExpectedPayload: `{"command":"TEST_AND_SET","old-cid":"old","new-cid":"new"}`,
+		},
+		{
+			Name:        "Test and set without CID",
+			Payload:     testAndSetGRPCPayload,
+			Request:     &tr1d1umpb.TestAndSetRequest{},
+			ExpectedErr: ErrNewCIDRequired,
+		},
+		{
+			Name:            "Add row",
+			Payload:         addRowGRPCPayload,
+			Request:         &tr1d1umpb.AddRowRequest{Table: "t", Row: map[string]string{"k": "v"}},
+			ExpectedPayload: `{"command":"ADD_ROW","table":"t","row":{"k":"v"}}`,
+		},
+		{
+			Name:        "Add row without table",
+			Payload:     addRowGRPCPayload,
+			Request:     &tr1d1umpb.AddRowRequest{Row: map[string]string{"k": "v"}},
+			ExpectedErr: ErrMissingTable,
+		},
+		{
+			Name:        "Add row without row",
+			Payload:     addRowGRPCPayload,
+			Request:     &tr1d1umpb.AddRowRequest{Table: "t"},
+			ExpectedErr: ErrMissingRow,
+		},
+		{
+			Name:            "Replace rows",
+			Payload:         replaceRowsGRPCPayload,
+			Request:         &tr1d1umpb.ReplaceRowsRequest{Table: " t ", Rows: map[string]*tr1d1umpb.Row{"0": {Columns: map[string]string{"k": "v"}}}},
+			ExpectedPayload: `{"command":"REPLACE_ROWS","table":"t","rows":{"0":{"k":"v"}}}`,
+		},
+		{
+			Name:        "Replace rows without table",
+			Payload:     replaceRowsGRPCPayload,
+			Request:     &tr1d1umpb.ReplaceRowsRequest{},
+			ExpectedErr: ErrMissingTable,
+		},
+		{
+			Name:            "Delete row",
+			Payload:         deleteRowGRPCPayload,
+			Request:         &tr1d1umpb.DeleteRowRequest{Row: "r"},
+			ExpectedPayload: `{"command":"DELETE_ROW","row":"r"}`,
+		},
+		{
+			Name:        "Delete row without row",
+			Payload:     deleteRowGRPCPayload,
+			Request:     &tr1d1umpb.DeleteRowRequest{},
+			ExpectedErr: ErrMissingRow,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			payload, err := tc.Payload(tc.Request)
+			if tc.ExpectedErr != nil {
+				assert.Equal(tc.ExpectedErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.JSONEq(tc.ExpectedPayload, string(payload))
+		})
+	}
+}
+
+func TestDecodeGRPCRequest(t *testing.T) {
+	validServices := func() []string { return []string{"config"} }
+	header := http.Header{"Authorization": []string{"Basic dGVzdDp0ZXN0"}, wrphttp.PartnerIdHeader: []string{"partner0"}}
+	ctx := context.WithValue(ctxTID, common.ContextKeyRequestHeader, header)
+
+	t.Run("Valid", func(t *testing.T) {
+		assert := assert.New(t)
+		decoded, err := decodeGRPCRequest(validServices, nil, nil, getGRPCPayload)(ctx,
+			&tr1d1umpb.GetRequest{DeviceId: "mac:112233445566", Service: "config", Names: []string{"a"}})
+		require.NoError(t, err)
+
+		req := decoded.(*wrpRequest)
+		assert.Equal("Basic dGVzdDp0ZXN0", req.AuthHeaderValue)
+		assert.Equal("test-tid", req.WRPMessage.TransactionUUID)
+		assert.Equal("mac:112233445566/config", req.WRPMessage.Destination)
+		assert.Equal([]string{"partner0"}, req.WRPMessage.PartnerIDs)
+		assert.JSONEq(`{"command":"GET","names":["a"]}`, string(req.WRPMessage.Payload))
+	})
+
+	t.Run("Invalid service", func(t *testing.T) {
+		_, err := decodeGRPCRequest(validServices, nil, nil, getGRPCPayload)(ctx,
+			&tr1d1umpb.GetRequest{DeviceId: "mac:112233445566", Service: "iot", Names: []string{"a"}})
+		assert.Equal(t, ErrInvalidService, err)
+	})
+
+	t.Run("Invalid device", func(t *testing.T) {
+		_, err := decodeGRPCRequest(validServices, nil, nil, getGRPCPayload)(ctx,
+			&tr1d1umpb.GetRequest{DeviceId: "nope", Service: "config", Names: []string{"a"}})
+		assert.Equal(t, http.StatusBadRequest, err.(common.CodedError).StatusCode())
+	})
+
+	t.Run("Forbidden partners", func(t *testing.T) {
+		auth := bascule.Authentication{
+			Token: bascule.NewToken("basic", "client0", bascule.NewAttributes(map[string]interface{}{})),
+		}
+
+		_, err := decodeGRPCRequest(validServices, &common.PartnerPolicy{RequireTokenPartners: []string{"basic"}}, nil, getGRPCPayload)(
+			bascule.WithAuthentication(ctx, auth), &tr1d1umpb.GetRequest{DeviceId: "mac:112233445566", Service: "config", Names: []string{"a"}})
+		assert.Equal(t, http.StatusForbidden, err.(common.CodedError).StatusCode())
+	})
+
+	t.Run("Forbidden parameters", func(t *testing.T) {
+		policy := &ParameterPolicy{Rules: []ParameterRule{{Deny: []string{"Device.Users."}}}}
+		_, err := decodeGRPCRequest(validServices, nil, policy, getGRPCPayload)(ctx,
+			&tr1d1umpb.GetRequest{DeviceId: "mac:112233445566", Service: "config", Names: []string{"Device.Users.User.1.Password"}})
+
+		var problemErr common.ProblemError
+		require.True(t, errors.As(err, &problemErr))
+		assert.Equal(t, ErrorCodeForbiddenParameters, problemErr.ErrorCode())
+	})
+}
+
+func TestEncodeGRPCResponse(t *testing.T) {
+	deviceResponse := func(payload string) *common.XmidtResponse {
+		return &common.XmidtResponse{
+			Code: http.StatusOK,
+			Body: bytes.NewBuffer(wrp.MustEncode(&wrp.Message{
+				Type:    wrp.SimpleRequestResponseMessageType,
+				Payload: []byte(payload),
+			}, wrp.Msgpack)).Bytes(),
+		}
+	}
+
+	t.Run("StatusNotOK", func(t *testing.T) {
+		_, err := encodeGRPCResponse(nil)(ctxTID, &common.XmidtResponse{Code: http.StatusNotFound, Body: []byte("not found")})
+		assert.Equal(t, http.StatusNotFound, err.(common.CodedError).StatusCode())
+	})
+
+	t.Run("UnexpectedResponseFormat", func(t *testing.T) {
+		_, err := encodeGRPCResponse(nil)(ctxTID, &common.XmidtResponse{Code: http.StatusOK, Body: []byte("t")})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("RDKDeviceResponse", func(t *testing.T) {
+		assert := assert.New(t)
+		response, err := encodeGRPCResponse(nil)(ctxTID, deviceResponse(`{"statusCode":520,"message":"Error unsupported namespace"}`))
+		require.NoError(t, err)
+
+		r := response.(*tr1d1umpb.DeviceResponse)
+		assert.EqualValues(520, r.StatusCode)
+		assert.Equal("Error unsupported namespace", r.Message)
+		assert.Equal(float64(520), r.Payload.AsMap()["statusCode"])
+	})
+
+	t.Run("Masked", func(t *testing.T) {
+		assert := assert.New(t)
+		payload := `{"statusCode":200,"parameters":[{"name":"Device.Users.User.1.Password","value":"secret","dataType":0}]}`
+		response, err := encodeGRPCResponse(newMasker(testSensitiveParameters))(ctxTID, deviceResponse(payload))
+		require.NoError(t, err)
+
+		masked, err := json.Marshal(response.(*tr1d1umpb.DeviceResponse).Payload.AsMap())
+		require.NoError(t, err)
+		assert.Contains(string(masked), maskedValue)
+		assert.NotContains(string(masked), "secret")
+	})
+}
+
+type recordingSink []audit.Record
+
+func (s *recordingSink) Write(_ context.Context, r audit.Record) error {
+	*s = append(*s, r)
+	return nil
+}
+
+func TestAuditEndpoint(t *testing.T) {
+	newRequest := func(payload string) *wrpRequest {
+		return &wrpRequest{WRPMessage: &wrp.Message{
+			TransactionUUID: "test-tid",
+			Destination:     "mac:112233445566/config",
+			PartnerIDs:      []string{"partner0"},
+			Payload:         []byte(payload),
+		}}
+	}
+
+	next := func(_ context.Context, request interface{}) (interface{}, error) {
+		return &common.XmidtResponse{Code: http.StatusOK}, nil
+	}
+
+	t.Run("Write", func(t *testing.T) {
+		assert := assert.New(t)
+		var sink recordingSink
+		auth := bascule.Authentication{
+			Token: bascule.NewToken("basic", "client0", bascule.NewAttributes(map[string]interface{}{})),
+		}
+
+		e := auditEndpoint(&sink, logging.NewTestLogger(nil, t), nil)(next)
+		_, err := e(bascule.WithAuthentication(ctxTID, auth), newRequest(`{"command":"DELETE_ROW","row":"Device.NAT.PortMapping.1."}`))
+		assert.Nil(err)
+		require.Len(t, sink, 1)
+		assert.Equal("test-tid", sink[0].TID)
+		assert.Equal("client0", sink[0].Principal)
+		assert.Equal("mac:112233445566", sink[0].DeviceID)
+		assert.Equal([]string{"partner0"}, sink[0].PartnerIDs)
+		assert.Equal(CommandDeleteRow, sink[0].Operation)
+		assert.Equal(http.StatusOK, sink[0].Code)
+	})
+
+	t.Run("Read", func(t *testing.T) {
+		var sink recordingSink
+		e := auditEndpoint(&sink, logging.NewTestLogger(nil, t), nil)(next)
+		_, err := e(ctxTID, newRequest(`{"command":"GET","names":["a"]}`))
+		assert.Nil(t, err)
+		assert.Empty(t, sink)
+	})
+}
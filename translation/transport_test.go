@@ -27,7 +27,7 @@ func TestDecodeRequest(t *testing.T) {
 	t.Run("PayloadFailure", func(t *testing.T) {
 		assert := assert.New(t)
 		r := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
-		_, e := decodeRequest(ctxTID, r)
+		_, e := decodeRequest(nil)(ctxTID, r)
 		assert.EqualValues(ErrEmptyNames, e)
 	})
 
@@ -35,7 +35,7 @@ func TestDecodeRequest(t *testing.T) {
 		assert := assert.New(t)
 		r := httptest.NewRequest(http.MethodGet, "http://localhost?names='deviceField'", nil)
 		r = mux.SetURLVars(r, map[string]string{"deviceid": "mac:112233445566"})
-		wrpMsg, e := decodeRequest(ctxTID, r)
+		wrpMsg, e := decodeRequest(nil)(ctxTID, r)
 		assert.Nil(e)
 		assert.NotEmpty(wrpMsg)
 	})
@@ -44,7 +44,7 @@ func TestDecodeRequest(t *testing.T) {
 		assert := assert.New(t)
 		r := httptest.NewRequest(http.MethodGet, "http://localhost?names='deviceField'", nil)
 		r = mux.SetURLVars(r, map[string]string{"deviceid": "mac:112233445566"})
-		wrpMsg, e := decodeRequest(ctxTID, r)
+		wrpMsg, e := decodeRequest(nil)(ctxTID, r)
 		assert.Nil(e)
 		assert.NotEmpty(wrpMsg)
 	})
@@ -123,7 +123,7 @@ func TestDecodeRequestPartnerIDs(t *testing.T) {
 				ctx = bascule.WithAuthentication(ctxTID, auth)
 			}
 
-			wrpMsg, e := decodeRequest(ctx, r)
+			wrpMsg, e := decodeRequest(nil)(ctx, r)
 			assert.Nil(e)
 			realWRP, _ := wrpMsg.(*wrpRequest)
 			assert.Equal(test.expectedPartnerIDs, realWRP.WRPMessage.PartnerIDs)
@@ -131,6 +131,21 @@ func TestDecodeRequestPartnerIDs(t *testing.T) {
 	}
 }
 
+func TestDecodeRequestPartnerPolicy(t *testing.T) {
+	assert := assert.New(t)
+	auth := bascule.Authentication{
+		Token: bascule.NewToken("basic", "client0", bascule.NewAttributes(map[string]interface{}{})),
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "http://localhost?names='deviceField'", nil)
+	r = mux.SetURLVars(r, map[string]string{"deviceid": "mac:112233445566"})
+	r.Header.Set(wrphttp.PartnerIdHeader, "partner0")
+
+	_, e := decodeRequest(&common.PartnerPolicy{RequireTokenPartners: []string{"basic"}})(bascule.WithAuthentication(ctxTID, auth), r)
+	assert.NotNil(e)
+	assert.Equal(http.StatusForbidden, e.(common.CodedError).StatusCode())
+}
+
 func TestRequestPayload(t *testing.T) {
 	t.Run("Get", func(t *testing.T) {
 		assert := assert.New(t)
@@ -533,4 +548,24 @@ func TestEncodeError(t *testing.T) {
 
 		assert.EqualValues(expected.String(), w.Body.String())
 	})
+	t.Run("ProblemDetails", func(t *testing.T) {
+		assert := assert.New(t)
+
+		w := httptest.NewRecorder()
+		encodeError(context.WithValue(ctxTID, common.ContextKeyRequestAccept, common.ProblemContentType), ErrInvalidService, w)
+
+		var problem common.Problem
+		assert.Nil(json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.EqualValues(http.StatusBadRequest, w.Code)
+		assert.Equal(common.ProblemContentType, w.Header().Get("Content-Type"))
+		assert.Equal(common.Problem{
+			Type:   common.ProblemTypePrefix + ErrorCodeUnsupportedService,
+			Title:  "Unsupported device service",
+			Status: http.StatusBadRequest,
+			Detail: ErrInvalidService.Error(),
+			Code:   ErrorCodeUnsupportedService,
+			TID:    ctxTID.Value(common.ContextKeyRequestTID).(string),
+			Fields: []string{"service"},
+		}, problem)
+	})
 }
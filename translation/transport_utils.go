@@ -93,7 +93,7 @@ func getCommandForParams(params []setParam) (command string) {
 func wrap(WDMP []byte, tid string, pathVars map[string]string, partnerIDs []string) (*wrp.Message, error) {
 	canonicalDeviceID, err := device.ParseID(pathVars["deviceid"])
 	if err != nil {
-		return nil, common.NewBadRequestError(err)
+		return nil, common.NewInvalidDeviceIDError(err)
 	}
 
 	return &wrp.Message{
@@ -105,10 +105,10 @@ func wrap(WDMP []byte, tid string, pathVars map[string]string, partnerIDs []stri
 	}, nil
 }
 
-func decodeValidServiceRequest(services []string, decoder kithttp.DecodeRequestFunc) kithttp.DecodeRequestFunc {
+func decodeValidServiceRequest(services func() []string, decoder kithttp.DecodeRequestFunc) kithttp.DecodeRequestFunc {
 	return func(c context.Context, r *http.Request) (interface{}, error) {
 
-		if !contains(mux.Vars(r)["service"], services) {
+		if !contains(mux.Vars(r)["service"], services()) {
 			return nil, ErrInvalidService
 		}
 
@@ -116,13 +116,81 @@ func decodeValidServiceRequest(services []string, decoder kithttp.DecodeRequestF
 	}
 }
 
+// wdmpSummary holds the parts of a WDMP payload that describe a request in metrics and traces.
+type wdmpSummary struct {
+	Command    string                     `json:"command"`
+	Names      []string                   `json:"names"`
+	Parameters []json.RawMessage          `json:"parameters"`
+	Rows       map[string]json.RawMessage `json:"rows"`
+	Row        json.RawMessage            `json:"row"`
+}
+
+// summarize reads the summary of a WDMP payload. The payload is built by tr1d1um so it's
+// always valid.
+func summarize(payload []byte) (s wdmpSummary) {
+	json.Unmarshal(payload, &s)
+	return
+}
+
+// parameterCount is the number of parameters a WDMP command reads or writes. Table commands
+// count their rows.
+func (s wdmpSummary) parameterCount() int {
+	switch {
+	case s.Rows != nil:
+		return len(s.Rows)
+	case s.Row != nil:
+		return 1
+	}
+	return len(s.Names) + len(s.Parameters)
+}
+
+// decodeRouteLabels decorates a WRP request decoder such that the service and WDMP command
+// of valid requests label their metrics.
+func decodeRouteLabels(decoder kithttp.DecodeRequestFunc) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		decoded, err := decoder(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+
+		common.SetRouteLabels(ctx, mux.Vars(r)["service"], summarize(decoded.(*wrpRequest).WRPMessage.Payload).Command)
+		return decoded, nil
+	}
+}
+
+// decodeTraced decorates a WRP request decoder such that decoding gets its own span
+// describing the request.
+func decodeTraced(decoder kithttp.DecodeRequestFunc) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		vars := mux.Vars(r)
+		ctx, span := common.StartSpan(ctx, common.DecodeRequestSpan,
+			common.DeviceIDAttribute.String(vars["deviceid"]),
+			common.ServiceAttribute.String(vars["service"]),
+		)
+
+		decoded, err := decoder(ctx, r)
+		if err == nil {
+			wrpMsg := decoded.(*wrpRequest).WRPMessage
+			summary := summarize(wrpMsg.Payload)
+			span.SetAttributes(
+				common.CommandAttribute.String(summary.Command),
+				common.ParameterCountAttribute.Int(summary.parameterCount()),
+				common.PartnerIDsAttribute.Array(wrpMsg.PartnerIDs),
+			)
+		}
+
+		common.EndSpan(span, err)
+		return decoded, err
+	}
+}
+
 func loadWDMP(encodedWDMP []byte, newCID, oldCID, syncCMC string) (*setWDMP, error) {
 	wdmp := new(setWDMP)
 
 	err := json.Unmarshal(encodedWDMP, wdmp)
 
 	if err != nil && len(encodedWDMP) > 0 { //len(encodedWDMP) == 0 is ok as it is used for TEST_SET
-		return nil, common.NewBadRequestError(fmt.Errorf("Invalid WDMP structure. %s", err.Error()))
+		return nil, newInvalidWDMPError(fmt.Errorf("Invalid WDMP structure. %s", err.Error()))
 	}
 
 	err = deduceSET(wdmp, newCID, oldCID, syncCMC)
@@ -137,28 +205,32 @@ func loadWDMP(encodedWDMP []byte, newCID, oldCID, syncCMC string) (*setWDMP, err
 	return wdmp, nil
 }
 
-func captureWDMPParameters(ctx context.Context, r *http.Request) (nctx context.Context) {
-	nctx = ctx
+// captureWDMPParameters adds the command and parameter names of SET requests to the transaction
+// logger. Sensitive parameter names are masked.
+func captureWDMPParameters(m *masker) kithttp.RequestFunc {
+	return func(ctx context.Context, r *http.Request) (nctx context.Context) {
+		nctx = ctx
 
-	if r.Method == http.MethodPatch {
-		bodyBytes, _ := ioutil.ReadAll(r.Body)
-		r.Body.Close()
+		if r.Method == http.MethodPatch {
+			bodyBytes, _ := ioutil.ReadAll(r.Body)
+			r.Body.Close()
 
-		r.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
+			r.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
 
-		if wdmp, e := loadWDMP(bodyBytes, r.Header.Get(HeaderWPASyncNewCID), r.Header.Get(HeaderWPASyncOldCID), r.Header.Get(HeaderWPASyncCMC)); e == nil {
-			if transactionInfoLogger, ok := ctx.Value(common.ContextKeyTransactionInfoLogger).(kitlog.Logger); ok {
-				transactionInfoLogger = kitlog.WithPrefix(transactionInfoLogger,
-					"command", wdmp.Command,
-					"parameters", getParamNames(wdmp.Parameters),
-				)
+			if wdmp, e := loadWDMP(bodyBytes, r.Header.Get(HeaderWPASyncNewCID), r.Header.Get(HeaderWPASyncOldCID), r.Header.Get(HeaderWPASyncCMC)); e == nil {
+				if transactionInfoLogger, ok := ctx.Value(common.ContextKeyTransactionInfoLogger).(kitlog.Logger); ok {
+					transactionInfoLogger = kitlog.WithPrefix(transactionInfoLogger,
+						"command", wdmp.Command,
+						"parameters", m.maskNames(getParamNames(wdmp.Parameters)),
+					)
 
-				nctx = context.WithValue(ctx, common.ContextKeyTransactionInfoLogger, transactionInfoLogger)
+					nctx = context.WithValue(ctx, common.ContextKeyTransactionInfoLogger, transactionInfoLogger)
+				}
 			}
 		}
-	}
 
-	return
+		return
+	}
 }
 
 func getParamNames(params []setParam) (paramNames []string) {
@@ -179,3 +251,17 @@ func contains(i string, elements []string) bool {
 	}
 	return false
 }
+
+// devicePayload returns the WDMP response of the device out of a 200 response of the XMiDT
+// cluster. The masker, when set, hides the values of sensitive parameters as in GET responses.
+func devicePayload(ctx context.Context, m *masker, resp *common.XmidtResponse) ([]byte, error) {
+	var wrpModel wrp.Message
+	if err := wrp.NewDecoderBytes(resp.Body, wrp.Msgpack).Decode(&wrpModel); err != nil {
+		return nil, err
+	}
+
+	if m != nil && m.maskResponses && !common.HasCapability(ctx, m.revealCapability) {
+		return m.maskPayload(wrpModel.Payload), nil
+	}
+	return wrpModel.Payload, nil
+}
@@ -9,9 +9,13 @@ import (
 	"github.com/gorilla/mux"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/xmidt-org/tr1d1um/common"
 	"github.com/xmidt-org/webpa-common/device"
+	"github.com/xmidt-org/webpa-common/xmetrics/xmetricstest"
 	"github.com/xmidt-org/wrp-go/v3"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/oteltest"
 )
 
 func TestValidateAndDeduceSETCommand(t *testing.T) {
@@ -160,7 +164,7 @@ func TestWrapInWRP(t *testing.T) {
 		w, e := wrap([]byte(""), "", nil, nil)
 
 		assert.Nil(w)
-		assert.EqualValues(common.NewBadRequestError(device.ErrorInvalidDeviceName), e)
+		assert.EqualValues(common.NewInvalidDeviceIDError(device.ErrorInvalidDeviceName), e)
 	})
 
 	t.Run("GivenParameters", func(t *testing.T) {
@@ -177,7 +181,7 @@ func TestWrapInWRP(t *testing.T) {
 }
 
 func TestDecodeValidServiceRequest(t *testing.T) {
-	f := decodeValidServiceRequest([]string{"s0"}, func(_ context.Context, _ *http.Request) (interface{}, error) {
+	f := decodeValidServiceRequest(func() []string { return []string{"s0"} }, func(_ context.Context, _ *http.Request) (interface{}, error) {
 		return nil, nil
 	})
 
@@ -200,6 +204,118 @@ func TestDecodeValidServiceRequest(t *testing.T) {
 	})
 }
 
+func TestDecodeRouteLabels(t *testing.T) {
+	t.Run("Error", func(t *testing.T) {
+		assert := assert.New(t)
+		f := decodeRouteLabels(func(_ context.Context, _ *http.Request) (interface{}, error) {
+			return nil, ErrInvalidService
+		})
+
+		i, err := f(context.TODO(), httptest.NewRequest(http.MethodGet, "localhost:8090/api", nil))
+		assert.Nil(i)
+		assert.EqualValues(ErrInvalidService, err)
+	})
+
+	t.Run("Labels", func(t *testing.T) {
+		assert := assert.New(t)
+		p := xmetricstest.NewProvider(nil, common.Metrics)
+		m := common.NewRouteMetrics(p)
+
+		expected := &wrpRequest{WRPMessage: &wrp.Message{Payload: []byte(`{"command":"GET","names":["p"]}`)}}
+		f := decodeRouteLabels(func(_ context.Context, _ *http.Request) (interface{}, error) {
+			return expected, nil
+		})
+
+		r := httptest.NewRequest(http.MethodGet, "localhost:8090/api", nil)
+		r = mux.SetURLVars(r, map[string]string{"service": "config"})
+		ctx := m.Before(context.TODO(), r)
+
+		i, err := f(ctx, r)
+		assert.Equal(expected, i)
+		assert.Nil(err)
+
+		m.Finalizer(ctx, http.StatusOK, r)
+		p.Assert(t, common.RouteRequestsCounter,
+			common.RouteLabel, "unknown",
+			common.MethodLabel, http.MethodGet,
+			common.CommandLabel, "GET",
+			common.ServiceLabel, "config",
+			common.CodeLabel, "200",
+		)(xmetricstest.Value(1))
+	})
+}
+
+func TestDecodeTraced(t *testing.T) {
+	testCases := []struct {
+		Name                   string
+		Payload                string
+		Err                    error
+		ExpectedCommand        string
+		ExpectedParameterCount int64
+	}{
+		{
+			Name:                   "Get",
+			Payload:                `{"command":"GET","names":["p0","p1"]}`,
+			ExpectedCommand:        "GET",
+			ExpectedParameterCount: 2,
+		},
+		{
+			Name:                   "Replace Rows",
+			Payload:                `{"command":"REPLACE_ROWS","table":"t.","rows":{"0":{"a":"b"},"1":{"c":"d"},"2":{"e":"f"}}}`,
+			ExpectedCommand:        "REPLACE_ROWS",
+			ExpectedParameterCount: 3,
+		},
+		{
+			Name:                   "Delete Row",
+			Payload:                `{"command":"DELETE_ROW","row":"t.1."}`,
+			ExpectedCommand:        "DELETE_ROW",
+			ExpectedParameterCount: 1,
+		},
+		{
+			Name: "Error",
+			Err:  ErrInvalidService,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			recorder := new(oteltest.SpanRecorder)
+			ctx, _ := oteltest.NewTracerProvider(oteltest.WithSpanRecorder(recorder)).Tracer("test").Start(context.Background(), "parent")
+
+			f := decodeTraced(func(_ context.Context, _ *http.Request) (interface{}, error) {
+				if testCase.Err != nil {
+					return nil, testCase.Err
+				}
+				return &wrpRequest{WRPMessage: &wrp.Message{Payload: []byte(testCase.Payload), PartnerIDs: []string{"comcast"}}}, nil
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "localhost:8090/api", nil)
+			r = mux.SetURLVars(r, map[string]string{"deviceid": "mac:112233445566", "service": "config"})
+
+			_, err := f(ctx, r)
+			assert.Equal(testCase.Err, err)
+
+			completed := recorder.Completed()
+			require.Len(t, completed, 1)
+			attributes := completed[0].Attributes()
+			assert.Equal(common.DecodeRequestSpan, completed[0].Name())
+			assert.Equal("mac:112233445566", attributes[common.DeviceIDAttribute].AsString())
+			assert.Equal("config", attributes[common.ServiceAttribute].AsString())
+
+			if testCase.Err != nil {
+				assert.Equal(codes.Error, completed[0].StatusCode())
+				return
+			}
+
+			assert.Equal(codes.Unset, completed[0].StatusCode())
+			assert.Equal(testCase.ExpectedCommand, attributes[common.CommandAttribute].AsString())
+			assert.Equal(testCase.ExpectedParameterCount, attributes[common.ParameterCountAttribute].AsInt64())
+			assert.Equal([1]string{"comcast"}, attributes[common.PartnerIDsAttribute].AsArray())
+		})
+	}
+}
+
 func TestContains(t *testing.T) {
 	assert := assert.New(t)
 	assert.False(contains("a", nil))
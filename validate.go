@@ -0,0 +1,277 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/xmidt-org/ancla"
+	"github.com/xmidt-org/tr1d1um/audit"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/tr1d1um/healthcheck"
+	"github.com/xmidt-org/tr1d1um/rpc"
+	"github.com/xmidt-org/tr1d1um/schedule"
+	"github.com/xmidt-org/tr1d1um/stat"
+	"github.com/xmidt-org/tr1d1um/translation"
+	"github.com/xmidt-org/tr1d1um/webhook"
+	"github.com/xmidt-org/webpa-common/logging"
+	"github.com/xmidt-org/webpa-common/server"
+	"github.com/xmidt-org/webpa-common/xmetrics"
+)
+
+const validateConfigFlag = "validate-config"
+
+var errInvalidConfig = errors.New("invalid configuration")
+
+// configSection is a config key along with the type its value decodes into.
+type configSection struct {
+	key    string
+	target func() interface{}
+
+	// strict sections report the keys their type does not have, which usually are typos.
+	strict bool
+}
+
+var configSections = []configSection{
+	{key: "xmidtClientTimeout", target: func() interface{} { return new(httpClientTimeout) }, strict: true},
+	{key: "argusClientTimeout", target: func() interface{} { return new(httpClientTimeout) }, strict: true},
+	{key: "capabilityCheck", target: func() interface{} { return new(CapabilityConfig) }, strict: true},
+	{key: "deviceAccessCheck", target: func() interface{} { return new(DeviceAccessConfig) }, strict: true},
+	{key: "basicAuth", target: func() interface{} { return new(BasicAuthConfig) }, strict: true},
+	{key: "jwtValidator", target: func() interface{} { return new(JWTValidator) }},
+	{key: authAcquirerKey, target: func() interface{} { return new(authAcquirerConfig) }},
+	{key: webhookConfigKey, target: func() interface{} { return new(ancla.Config) }},
+	{key: webhookValidationConfigKey, target: func() interface{} { return new(webhook.ValidationOptions) }, strict: true},
+	{key: webhookStoreConfigKey, target: func() interface{} { return new(webhook.StoreConfig) }, strict: true},
+	{key: statWatchConfigKey, target: func() interface{} { return new(stat.WatchOptions) }, strict: true},
+	{key: statCacheConfigKey, target: func() interface{} { return new(stat.CacheOptions) }, strict: true},
+	{key: partnerPolicyConfigKey, target: func() interface{} { return new(common.PartnerPolicy) }, strict: true},
+	{key: parameterPolicyConfigKey, target: func() interface{} { return new(translation.ParameterPolicy) }, strict: true},
+	{key: sensitiveParametersConfigKey, target: func() interface{} { return new(translation.SensitiveParameters) }, strict: true},
+	{key: auditConfigKey, target: func() interface{} { return new(audit.Config) }, strict: true},
+	{key: healthChecksConfigKey, target: func() interface{} { return new(healthcheck.Config) }, strict: true},
+	{key: consoleConfigKey, target: func() interface{} { return new(translation.ConsoleOptions) }, strict: true},
+	{key: grpcConfigKey, target: func() interface{} { return new(rpc.Config) }, strict: true},
+	{key: schedulerConfigKey, target: func() interface{} { return new(schedule.Config) }, strict: true},
+}
+
+// configReport collects the problems found in a config. It doubles as a logger
+// so the warnings and errors the constructors log are reported too.
+type configReport struct {
+	errors   []string
+	warnings []string
+}
+
+func (r *configReport) error(key string, err error) {
+	r.errors = append(r.errors, fmt.Sprintf("%s: %v", key, err))
+}
+
+func (r *configReport) warn(key string, message string) {
+	r.warnings = append(r.warnings, fmt.Sprintf("%s: %s", key, message))
+}
+
+// Log records the error level entries as errors and the warning level ones as warnings.
+// Constructors log errors for the values they end up ignoring, such as invalid patterns.
+func (r *configReport) Log(keyvals ...interface{}) error {
+	var (
+		lvl      interface{}
+		message  string
+		details  []string
+		keyCount = len(keyvals) - len(keyvals)%2
+	)
+
+	for i := 0; i < keyCount; i += 2 {
+		switch k := keyvals[i]; {
+		case k == level.Key():
+			lvl = keyvals[i+1]
+		case k == logging.MessageKey():
+			message = fmt.Sprint(keyvals[i+1])
+		case k == logging.TimestampKey() || k == logging.CallerKey():
+		default:
+			details = append(details, fmt.Sprintf("%v=%v", k, keyvals[i+1]))
+		}
+	}
+
+	entry := strings.TrimSpace(message + " " + strings.Join(details, " "))
+	switch lvl {
+	case level.ErrorValue():
+		r.errors = append(r.errors, entry)
+	case level.WarnValue():
+		r.warnings = append(r.warnings, entry)
+	}
+	return nil
+}
+
+// decodeSections decodes the config sections which are set.
+func (r *configReport) decodeSections(v *viper.Viper) {
+	for _, s := range configSections {
+		if !v.IsSet(s.key) {
+			continue
+		}
+
+		if err := v.UnmarshalKey(s.key, s.target()); err != nil {
+			r.error(s.key, err)
+			continue
+		}
+
+		if s.strict {
+			// unknown keys are most likely misspelled ones whose values are ignored
+			if err := v.UnmarshalKey(s.key, s.target(), errorUnused); err != nil {
+				r.error(s.key, err)
+			}
+		}
+	}
+}
+
+func errorUnused(c *mapstructure.DecoderConfig) {
+	c.ErrorUnused = true
+}
+
+// validate runs the constructors tr1d1um starts with, without starting any listener
+// or client.
+func (r *configReport) validate(v *viper.Viper, registry xmetrics.Registry) {
+	r.decodeSections(v)
+
+	if _, err := runtimeOptions(v); err != nil {
+		r.error("runtime", err)
+	}
+
+	if _, err := shutdownGracePeriod(v); err != nil {
+		r.error(shutdownGracePeriodKey, err)
+	}
+
+	if _, err := loadTracing(v, applicationName); err != nil {
+		r.error(tracingConfigKey, err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	if _, err := authenticationHandler(v, r, registry, done); err != nil {
+		r.error("authentication", err)
+	}
+
+	if v.IsSet(authAcquirerKey) {
+		// tr1d1um starts without an acquirer in this case
+		if _, err := createAuthAcquirer(v); err != nil {
+			r.warn(authAcquirerKey, err.Error())
+		}
+	}
+
+	if _, err := newXmidtClientTimeout(v); err != nil {
+		r.error("xmidtClientTimeout", err)
+	}
+
+	if v.IsSet(webhookConfigKey) {
+		if _, err := newArgusClientTimeout(v); err != nil {
+			r.error("argusClientTimeout", err)
+		}
+
+		var store webhook.StoreConfig
+		if err := v.UnmarshalKey(webhookStoreConfigKey, &store); err == nil {
+			switch store.Type {
+			case "", webhook.ArgusStoreType, webhook.MemoryStoreType, webhook.FileStoreType:
+			default:
+				r.error(webhookStoreConfigKey, fmt.Errorf("unknown type %q", store.Type))
+			}
+		}
+	}
+
+	if v.IsSet(auditConfigKey) {
+		var c audit.Config
+		if err := v.UnmarshalKey(auditConfigKey, &c); err == nil {
+			switch {
+			case c.Type != "" && c.Type != audit.FileSinkType:
+				r.error(auditConfigKey, fmt.Errorf("unknown type %q", c.Type))
+			case c.File.Path == "":
+				r.error(auditConfigKey, errors.New("file.path is required"))
+			}
+
+			if c.ReadCapability == "" {
+				r.warn(auditConfigKey, "records cannot be queried through GET /audit unless readCapability is set")
+			}
+		}
+	}
+
+	if v.IsSet(consoleConfigKey) {
+		var c translation.ConsoleOptions
+		if err := v.UnmarshalKey(consoleConfigKey, &c); err == nil && c.WriteCapability == "" {
+			r.warn(consoleConfigKey, "console sessions are read-only unless writeCapability is set")
+		}
+	}
+
+	if v.IsSet(schedulerConfigKey) {
+		var c schedule.Config
+		if err := v.UnmarshalKey(schedulerConfigKey, &c); err == nil {
+			switch {
+			case c.Type != "" && c.Type != schedule.FileStoreType:
+				r.error(schedulerConfigKey, fmt.Errorf("unknown type %q", c.Type))
+			case c.File.Path == "":
+				r.error(schedulerConfigKey, errors.New("file.path is required"))
+			}
+		}
+
+		if !v.IsSet(authAcquirerKey) {
+			r.warn(schedulerConfigKey, "scheduled operations are sent without authorization unless authAcquirer is configured")
+		}
+	}
+}
+
+func (r *configReport) print(w io.Writer) {
+	sort.Strings(r.errors)
+	sort.Strings(r.warnings)
+
+	for _, e := range r.errors {
+		fmt.Fprintf(w, "ERROR   %s\n", e)
+	}
+	for _, warning := range r.warnings {
+		fmt.Fprintf(w, "WARNING %s\n", warning)
+	}
+	fmt.Fprintf(w, "%d error(s), %d warning(s)\n", len(r.errors), len(r.warnings))
+}
+
+// logWarnings logs the problems found as warnings. Startup carries on as the
+// constructors deal with the config sections the way they always did.
+func (r *configReport) logWarnings(logger log.Logger) {
+	for _, e := range r.errors {
+		logging.Warn(logger).Log(logging.MessageKey(), "config problem", "problem", e)
+	}
+	for _, warning := range r.warnings {
+		logging.Warn(logger).Log(logging.MessageKey(), "config problem", "problem", warning)
+	}
+}
+
+// validateConfig loads the config as tr1d1um would and prints its problems. It returns
+// an error when the config has errors.
+func validateConfig(arguments []string, w io.Writer) error {
+	f, v := pflag.NewFlagSet(applicationName, pflag.ContinueOnError), viper.New()
+
+	// the flags of printVersion have to parse
+	f.BoolP("version", "v", false, "")
+	f.Bool(validateConfigFlag, false, "")
+
+	report := new(configReport)
+	_, registry, _, err := server.Initialize(applicationName, arguments, f, v, metricModules...)
+	if err != nil {
+		report.error("config", err)
+	} else {
+		for k, va := range defaults {
+			v.SetDefault(k, va)
+		}
+
+		fmt.Fprintf(w, "Validating %s\n", v.ConfigFileUsed())
+		report.validate(v, registry)
+	}
+
+	report.print(w)
+	if len(report.errors) > 0 {
+		return fmt.Errorf("%w: %d error(s)", errInvalidConfig, len(report.errors))
+	}
+	return nil
+}
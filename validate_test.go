@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/webpa-common/logging"
+)
+
+func TestConfigReportLog(t *testing.T) {
+	assert := assert.New(t)
+	report := new(configReport)
+
+	logging.Info(report).Log(logging.MessageKey(), "ignored")
+	logging.Debug(report).Log(logging.MessageKey(), "ignored")
+	logging.Warn(report).Log(logging.MessageKey(), "basic auth file not found", "file", "/etc/creds")
+	logging.Error(report).Log(logging.MessageKey(), "bad key")
+
+	assert.Equal([]string{"basic auth file not found file=/etc/creds"}, report.warnings)
+	assert.Equal([]string{"bad key"}, report.errors)
+}
+
+func TestDecodeSections(t *testing.T) {
+	testCases := []struct {
+		Name             string
+		Values           map[string]interface{}
+		ExpectedErrors   int
+		ExpectedWarnings int
+	}{
+		{
+			Name: "Valid",
+			Values: map[string]interface{}{
+				"xmidtClientTimeout": map[string]interface{}{"clientTimeout": "10s"},
+				auditConfigKey:       map[string]interface{}{"type": "file", "file": map[string]interface{}{"path": "audit.log"}},
+			},
+		},
+		{
+			Name:           "Bad duration",
+			Values:         map[string]interface{}{"xmidtClientTimeout": map[string]interface{}{"clientTimeout": "soon"}},
+			ExpectedErrors: 1,
+		},
+		{
+			Name:           "Unknown key",
+			Values:         map[string]interface{}{"xmidtClientTimeout": map[string]interface{}{"clientTimout": "10s"}},
+			ExpectedErrors: 1,
+		},
+		{
+			Name:   "Lenient section",
+			Values: map[string]interface{}{"jwtValidator": map[string]interface{}{"unknown": true}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			v := viper.New()
+			for k, va := range tc.Values {
+				v.Set(k, va)
+			}
+
+			report := new(configReport)
+			report.decodeSections(v)
+			assert.Len(report.errors, tc.ExpectedErrors, "errors: %v", report.errors)
+			assert.Len(report.warnings, tc.ExpectedWarnings, "warnings: %v", report.warnings)
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "validate")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	// server.Initialize creates a cpu profile file
+	profile := "--cpuprofile=" + filepath.Join(dir, "cpuprofile")
+
+	// the config shipped with tr1d1um is valid
+	var output bytes.Buffer
+	assert.Nil(validateConfig([]string{"--validate-config", profile}, &output), output.String())
+	assert.Contains(output.String(), "0 error(s)")
+
+	config := "supportedServices: [config]\nrequestMaxRetries: -1\naudit:\n  type: database\nxmidtClientTimeout:\n  clienttimeuot: 10s\n"
+	require.NoError(ioutil.WriteFile(filepath.Join(dir, "invalid.yaml"), []byte(config), 0600))
+
+	wd, err := os.Getwd()
+	require.NoError(err)
+	require.NoError(os.Chdir(dir))
+	defer os.Chdir(wd)
+
+	output.Reset()
+	err = validateConfig([]string{"--validate-config", profile, "--file", "invalid"}, &output)
+	assert.True(errors.Is(err, errInvalidConfig))
+	assert.Contains(output.String(), "ERROR   runtime: invalid requestMaxRetries")
+	assert.Contains(output.String(), `ERROR   audit: unknown type "database"`)
+	assert.Contains(output.String(), "ERROR   xmidtClientTimeout: ")
+
+	output.Reset()
+	err = validateConfig([]string{"--validate-config", profile, "--file", "missing"}, &output)
+	assert.True(errors.Is(err, errInvalidConfig))
+	assert.Contains(output.String(), "ERROR   config:")
+}
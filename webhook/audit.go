@@ -0,0 +1,32 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/xmidt-org/tr1d1um/audit"
+	"github.com/xmidt-org/tr1d1um/common"
+)
+
+// Audit operations of webhook registrations.
+const (
+	RegisterOperation = "WEBHOOK_REGISTER"
+	UpdateOperation   = "WEBHOOK_UPDATE"
+)
+
+// describeRegistration returns the audit record of a webhook registration (or update) request.
+// Malformed registrations are recorded too, without a target.
+func describeRegistration(operation string, partnerPolicy *common.PartnerPolicy) audit.DescribeFunc {
+	return func(r *http.Request, body []byte) (audit.Record, bool) {
+		partnerIDs, _ := partnerPolicy.PartnerIDs(r.Context(), r.Header)
+		record := audit.Record{
+			PartnerIDs: partnerIDs,
+			Operation:  operation,
+		}
+
+		if w, err := decodeWebhook(body); err == nil {
+			record.Target = w.Config.URL
+			record.Parameters = []audit.Parameter{{Name: "events", Value: w.Events}}
+		}
+		return record, true
+	}
+}
@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/common"
+)
+
+// caller identifies the client of a webhook request from its auth token.
+type caller struct {
+	principal  string
+	partnerIDs []string
+	admin      bool
+}
+
+// callerFromContext reads the caller identity out of the bascule token in ctx.
+// Partner IDs are only read from JWT claims.
+func callerFromContext(ctx context.Context) caller {
+	partnerIDs, _ := common.TokenPartnerIDs(ctx)
+	return caller{
+		principal:  principal(ctx),
+		partnerIDs: partnerIDs,
+	}
+}
+
+// callerFromRequest reads the caller identity of a request. Requests violating the
+// partner policy are rejected but, as partner ID headers can be set by anyone, only
+// token partner IDs count towards the caller identity.
+func callerFromRequest(ctx context.Context, h http.Header, adminCapability string, partnerPolicy *common.PartnerPolicy) (caller, error) {
+	if _, err := partnerPolicy.PartnerIDs(ctx, h); err != nil {
+		return caller{}, err
+	}
+
+	c := callerFromContext(ctx)
+	c.admin = common.HasCapability(ctx, adminCapability)
+	return c, nil
+}
+
+func principal(ctx context.Context) string {
+	auth, ok := bascule.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	switch auth.Token.Type() {
+	case "jwt", "basic":
+		return auth.Token.Principal()
+	}
+	return ""
+}
+
+// owns reports whether the caller registered r, either as the same principal or
+// as a principal of one of the same partners.
+func (c caller) owns(r Registration) bool {
+	if c.principal != "" && c.principal == r.Owner {
+		return true
+	}
+
+	for _, p := range c.partnerIDs {
+		for _, rp := range r.PartnerIDs {
+			if p == rp {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// canSee reports whether r should be listed to the caller.
+func (c caller) canSee(r Registration) bool {
+	return c.admin || c.owns(r)
+}
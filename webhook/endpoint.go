@@ -0,0 +1,163 @@
+package webhook
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/xmidt-org/ancla"
+)
+
+type webhookRequest struct {
+	ID     string
+	caller caller
+}
+
+type updateWebhookRequest struct {
+	webhookRequest
+	Webhook ancla.Webhook
+}
+
+type listWebhooksRequest struct {
+	caller caller
+	filter listFilter
+}
+
+// listFilter narrows down a webhook listing. Zero values match everything.
+type listFilter struct {
+	// event matches registrations with at least one matching event pattern.
+	event *regexp.Regexp
+
+	// url matches registrations whose config URL contains it.
+	url string
+
+	expiresBefore time.Time
+	expiresAfter  time.Time
+}
+
+func (f listFilter) matches(r Registration) bool {
+	if f.url != "" && !strings.Contains(r.Config.URL, f.url) {
+		return false
+	}
+
+	if !f.expiresBefore.IsZero() && !r.Until.Before(f.expiresBefore) {
+		return false
+	}
+
+	if !f.expiresAfter.IsZero() && !r.Until.After(f.expiresAfter) {
+		return false
+	}
+
+	if f.event == nil {
+		return true
+	}
+
+	for _, e := range r.Events {
+		if f.event.MatchString(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// getOwned fetches the registration with the given ID provided the caller owns it.
+func getOwned(ctx context.Context, s Service, req webhookRequest) (Registration, error) {
+	r, err := s.Get(ctx, req.ID)
+	if err != nil {
+		return Registration{}, err
+	}
+
+	if !req.caller.owns(r) {
+		return Registration{}, ErrWebhookNotOwned
+	}
+	return r, nil
+}
+
+func makeListWebhooksEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*listWebhooksRequest)
+		registrations, err := s.Registrations(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		visible := make([]Registration, 0, len(registrations))
+		for _, r := range registrations {
+			if req.caller.canSee(r) && req.filter.matches(r) {
+				visible = append(visible, r)
+			}
+		}
+		return visible, nil
+	}
+}
+
+func makeGetWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*webhookRequest)
+		return getOwned(ctx, s, *req)
+	}
+}
+
+func makeUpdateWebhookEndpoint(s Service, v *validator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*updateWebhookRequest)
+		existing, err := getOwned(ctx, s, req.webhookRequest)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = v.validate(ctx, req.Webhook); err != nil {
+			return nil, err
+		}
+
+		// ownership stays with the original registrant
+		updated := Registration{
+			Webhook:    req.Webhook,
+			ID:         existing.ID,
+			Owner:      existing.Owner,
+			PartnerIDs: existing.PartnerIDs,
+		}
+
+		if err = s.Update(ctx, updated); err != nil {
+			return nil, err
+		}
+		return updated, nil
+	}
+}
+
+func makeRemoveWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*webhookRequest)
+		existing, err := getOwned(ctx, s, *req)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = s.Remove(ctx, existing); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+}
+
+func makeRenewWebhookEndpoint(s Service, now func() time.Time) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(*webhookRequest)
+		existing, err := getOwned(ctx, s, *req)
+		if err != nil {
+			return nil, err
+		}
+
+		if existing.Duration <= 0 {
+			existing.Duration = defaultWebhookExpiration
+		}
+		existing.Until = now().Add(existing.Duration)
+
+		if err = s.Update(ctx, existing); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+}
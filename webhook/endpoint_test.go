@@ -0,0 +1,196 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCallerOwns(t *testing.T) {
+	r := Registration{Owner: "client0", PartnerIDs: []string{"partnerA"}}
+
+	testCases := []struct {
+		Name     string
+		Caller   caller
+		Expected bool
+	}{
+		{Name: "Same principal", Caller: caller{principal: "client0"}, Expected: true},
+		{Name: "Same partner", Caller: caller{principal: "client1", partnerIDs: []string{"partnerB", "partnerA"}}, Expected: true},
+		{Name: "Other principal and partner", Caller: caller{principal: "client1", partnerIDs: []string{"partnerB"}}},
+		{Name: "Anonymous", Caller: caller{}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert.Equal(t, testCase.Expected, testCase.Caller.owns(r))
+		})
+	}
+}
+
+func TestCallerFromContext(t *testing.T) {
+	assert := assert.New(t)
+	c := callerFromContext(partnerContext("client0", "partnerA", "partnerB"))
+	assert.Equal("client0", c.principal)
+	assert.Equal([]string{"partnerA", "partnerB"}, c.partnerIDs)
+
+	assert.Equal(caller{}, callerFromContext(context.Background()))
+}
+
+func TestGetWebhookEndpoint(t *testing.T) {
+	assert := assert.New(t)
+	s := new(mockService)
+	existing := Registration{ID: "abc", Owner: "client0"}
+	s.On("Get", mock.Anything, "abc").Return(existing, nil)
+
+	resp, err := makeGetWebhookEndpoint(s)(context.Background(), &webhookRequest{ID: "abc", caller: caller{principal: "client0"}})
+	assert.Nil(err)
+	assert.Equal(existing, resp)
+
+	_, err = makeGetWebhookEndpoint(s)(context.Background(), &webhookRequest{ID: "abc", caller: caller{principal: "client1"}})
+	assert.Equal(ErrWebhookNotOwned, err)
+}
+
+func TestUpdateWebhookEndpoint(t *testing.T) {
+	assert := assert.New(t)
+	s := new(mockService)
+	existing := Registration{ID: "abc", Owner: "client0", PartnerIDs: []string{"partnerA"}}
+	w := testWebhook()
+	expected := Registration{Webhook: w, ID: "abc", Owner: "client0", PartnerIDs: []string{"partnerA"}}
+
+	s.On("Get", mock.Anything, "abc").Return(existing, nil)
+	s.On("Update", mock.Anything, expected).Return(nil)
+
+	// a partner peer updates the webhook but ownership stays with the registrant
+	resp, err := makeUpdateWebhookEndpoint(s, newValidator(ValidationOptions{AllowPrivateAddresses: true}))(context.Background(), &updateWebhookRequest{
+		webhookRequest: webhookRequest{ID: "abc", caller: caller{principal: "client1", partnerIDs: []string{"partnerA"}}},
+		Webhook:        w,
+	})
+	assert.Nil(err)
+	assert.Equal(expected, resp)
+	s.AssertExpectations(t)
+}
+
+func TestUpdateWebhookEndpointProbesOwnedOnly(t *testing.T) {
+	assert := assert.New(t)
+	var probes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&probes, 1)
+	}))
+	defer server.Close()
+
+	s := new(mockService)
+	s.On("Get", mock.Anything, "abc").Return(Registration{ID: "abc", Owner: "client0"}, nil)
+
+	w := testWebhook()
+	w.Config.URL = server.URL
+	_, err := makeUpdateWebhookEndpoint(s, newValidator(ValidationOptions{AllowPrivateAddresses: true, Probe: true}))(context.Background(), &updateWebhookRequest{
+		webhookRequest: webhookRequest{ID: "abc", caller: caller{principal: "client1"}},
+		Webhook:        w,
+	})
+	assert.Equal(ErrWebhookNotOwned, err)
+	assert.Zero(atomic.LoadInt32(&probes))
+}
+
+func TestRemoveWebhookEndpoint(t *testing.T) {
+	assert := assert.New(t)
+	s := new(mockService)
+	existing := Registration{ID: "abc", Owner: "client0"}
+	s.On("Get", mock.Anything, "abc").Return(existing, nil)
+	s.On("Remove", mock.Anything, existing).Return(nil)
+
+	_, err := makeRemoveWebhookEndpoint(s)(context.Background(), &webhookRequest{ID: "abc", caller: caller{principal: "client1"}})
+	assert.Equal(ErrWebhookNotOwned, err)
+	s.AssertNotCalled(t, "Remove", mock.Anything, mock.Anything)
+
+	resp, err := makeRemoveWebhookEndpoint(s)(context.Background(), &webhookRequest{ID: "abc", caller: caller{principal: "client0"}})
+	assert.Nil(err)
+	assert.Equal(existing, resp)
+	s.AssertExpectations(t)
+}
+
+func TestListWebhooksEndpoint(t *testing.T) {
+	var (
+		mine     = Registration{ID: "1", Owner: "client0"}
+		partners = Registration{ID: "2", Owner: "client1", PartnerIDs: []string{"partnerA"}}
+		others   = Registration{ID: "3", Owner: "client2", PartnerIDs: []string{"partnerB"}}
+	)
+
+	testCases := []struct {
+		Name     string
+		Caller   caller
+		Expected []Registration
+	}{
+		{Name: "Owner and partner", Caller: caller{principal: "client0", partnerIDs: []string{"partnerA"}}, Expected: []Registration{mine, partners}},
+		{Name: "Admin", Caller: caller{principal: "client9", admin: true}, Expected: []Registration{mine, partners, others}},
+		{Name: "Stranger", Caller: caller{principal: "client9"}, Expected: []Registration{}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			s := new(mockService)
+			s.On("Registrations", mock.Anything).Return([]Registration{mine, partners, others}, nil)
+
+			resp, err := makeListWebhooksEndpoint(s)(context.Background(), &listWebhooksRequest{caller: testCase.Caller})
+			assert.Nil(err)
+			assert.Equal(testCase.Expected, resp)
+		})
+	}
+}
+
+func TestListFilterMatches(t *testing.T) {
+	r := Registration{Webhook: testWebhook()}
+	r.Config.URL = "https://receiver.example.com/events"
+	r.Events = []string{"device-status.*", "iot"}
+	r.Until = testNow
+
+	testCases := []struct {
+		Name     string
+		Filter   listFilter
+		Expected bool
+	}{
+		{Name: "No filter", Expected: true},
+		{Name: "Event", Filter: listFilter{event: regexp.MustCompile("^iot$")}, Expected: true},
+		{Name: "Event mismatch", Filter: listFilter{event: regexp.MustCompile("^online$")}},
+		{Name: "URL", Filter: listFilter{url: "receiver.example"}, Expected: true},
+		{Name: "URL mismatch", Filter: listFilter{url: "other.example"}},
+		{Name: "Expires before", Filter: listFilter{expiresBefore: testNow.Add(time.Minute)}, Expected: true},
+		{Name: "Expires before mismatch", Filter: listFilter{expiresBefore: testNow}},
+		{Name: "Expires after", Filter: listFilter{expiresAfter: testNow.Add(-time.Minute)}, Expected: true},
+		{Name: "Expires after mismatch", Filter: listFilter{expiresAfter: testNow}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert.Equal(t, testCase.Expected, testCase.Filter.matches(r))
+		})
+	}
+}
+
+func TestRenewWebhookEndpoint(t *testing.T) {
+	assert := assert.New(t)
+	s := new(mockService)
+	existing := Registration{Webhook: testWebhook(), ID: "abc", Owner: "client0"}
+	existing.Duration = 0
+	expected := existing
+	expected.Duration = defaultWebhookExpiration
+	expected.Until = testNow.Add(defaultWebhookExpiration)
+
+	s.On("Get", mock.Anything, "abc").Return(existing, nil)
+	s.On("Update", mock.Anything, expected).Return(nil)
+
+	resp, err := makeRenewWebhookEndpoint(s, func() time.Time { return testNow })(context.Background(), &webhookRequest{ID: "abc", caller: caller{principal: "client0"}})
+	assert.Nil(err)
+	assert.Equal(expected, resp)
+	s.AssertExpectations(t)
+
+	_, err = makeRenewWebhookEndpoint(s, time.Now)(context.Background(), &webhookRequest{ID: "abc", caller: caller{principal: "client1"}})
+	assert.Equal(ErrWebhookNotOwned, err)
+}
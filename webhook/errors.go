@@ -0,0 +1,31 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/xmidt-org/tr1d1um/common"
+)
+
+// Error values definitions for the webhook service
+var (
+	ErrWebhookNotFound   = common.NewCodedError(errors.New("webhook not found"), http.StatusNotFound)
+	ErrWebhookNotOwned   = common.NewCodedError(errors.New("webhook is registered by a different principal or partner"), http.StatusForbidden)
+	ErrMissingWebhookID  = common.NewBadRequestError(errors.New("webhook id is required"))
+	ErrWebhookIDMismatch = common.NewBadRequestError(errors.New("webhook config URL does not match the webhook id. Register a new webhook to change its URL"))
+	ErrInvalidConfigURL  = common.NewBadRequestError(errors.New("invalid config URL"))
+	ErrInvalidEvents     = common.NewBadRequestError(errors.New("invalid events"))
+	ErrInvalidWebhook    = common.NewBadRequestError(errors.New("invalid webhook JSON"))
+
+	ErrInvalidEventFilter  = common.NewBadRequestError(errors.New("event filter must be a valid regular expression"))
+	ErrInvalidExpiryFilter = common.NewBadRequestError(errors.New("expiry filters must be RFC3339 timestamps"))
+)
+
+var (
+	errNonSuccessPushResult    = errors.New("got a push result but was not of success type")
+	errFailedWebhookPush       = errors.New("failed to add webhook to registry")
+	errFailedWebhookRemove     = errors.New("failed to remove webhook from registry")
+	errFailedWebhookConversion = errors.New("failed to convert webhook to argus item")
+	errFailedItemConversion    = errors.New("failed to convert argus item to webhook")
+	errFailedWebhooksFetch     = errors.New("failed to fetch webhooks")
+)
@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/provider"
+	"github.com/xmidt-org/ancla"
+	"github.com/xmidt-org/webpa-common/logging"
+	"github.com/xmidt-org/webpa-common/xmetrics"
+)
+
+// Metric names for the webhook package.
+const (
+	ExpiringWebhooksGauge = "webhook_expiring"
+)
+
+// DefaultExpiryWarningWindow is used when no window is given to NewExpiryWatch.
+const DefaultExpiryWarningWindow = time.Minute
+
+// Metrics returns the metrics relevant to this package.
+func Metrics() []xmetrics.Metric {
+	return []xmetrics.Metric{
+		{
+			Name: ExpiringWebhooksGauge,
+			Type: xmetrics.GaugeType,
+			Help: "Number of webhooks which expire within the configured warning window.",
+		},
+	}
+}
+
+type expiryWatch struct {
+	window   time.Duration
+	expiring metrics.Gauge
+	logger   log.Logger
+	now      func() time.Time
+
+	lock sync.Mutex
+	// warned holds the expiry each webhook was last warned about so a webhook
+	// is only reported once until it is renewed.
+	warned map[string]time.Time
+}
+
+// NewExpiryWatch returns a watch which keeps track of the webhooks expiring within window.
+// Their count is reported through the ExpiringWebhooksGauge and a warning is logged for each.
+func NewExpiryWatch(window time.Duration, p provider.Provider, logger log.Logger) ancla.Watch {
+	if window <= 0 {
+		window = DefaultExpiryWarningWindow
+	}
+
+	if p == nil {
+		p = provider.NewDiscardProvider()
+	}
+
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+
+	return &expiryWatch{
+		window:   window,
+		expiring: p.NewGauge(ExpiringWebhooksGauge),
+		logger:   logging.Warn(logger),
+		now:      time.Now,
+		warned:   make(map[string]time.Time),
+	}
+}
+
+func (e *expiryWatch) Update(webhooks []ancla.Webhook) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	var (
+		now      = e.now()
+		expiring int
+		warned   = make(map[string]time.Time, len(e.warned))
+	)
+
+	for _, w := range webhooks {
+		remaining := w.Until.Sub(now)
+		if remaining <= 0 || remaining > e.window {
+			continue
+		}
+
+		expiring++
+		id := ID(w.Config.URL)
+		if until, ok := e.warned[id]; !ok || !until.Equal(w.Until) {
+			e.logger.Log(logging.MessageKey(), "Webhook is about to expire", "id", id, "url", w.Config.URL, "until", w.Until, "remaining", remaining)
+		}
+		warned[id] = w.Until
+	}
+
+	e.warned = warned
+	e.expiring.Set(float64(expiring))
+}
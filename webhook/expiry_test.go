@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics/generic"
+	"github.com/stretchr/testify/assert"
+	"github.com/xmidt-org/ancla"
+)
+
+func TestExpiryWatch(t *testing.T) {
+	assert := assert.New(t)
+	var (
+		buf   bytes.Buffer
+		gauge = generic.NewGauge(ExpiringWebhooksGauge)
+	)
+
+	e := &expiryWatch{
+		window:   time.Minute,
+		expiring: gauge,
+		logger:   log.NewLogfmtLogger(&buf),
+		now:      func() time.Time { return testNow },
+		warned:   make(map[string]time.Time),
+	}
+
+	webhook := func(url string, until time.Time) ancla.Webhook {
+		return ancla.Webhook{Config: ancla.DeliveryConfig{URL: url}, Until: until}
+	}
+
+	webhooks := []ancla.Webhook{
+		webhook("https://soon.example.com", testNow.Add(30*time.Second)),
+		webhook("https://later.example.com", testNow.Add(time.Hour)),
+		webhook("https://expired.example.com", testNow.Add(-time.Second)),
+	}
+
+	e.Update(webhooks)
+	assert.EqualValues(1, gauge.Value())
+	assert.Equal(1, bytes.Count(buf.Bytes(), []byte("about to expire")))
+	assert.Contains(buf.String(), "soon.example.com")
+
+	// the same expiry is only reported once
+	e.Update(webhooks)
+	assert.Equal(1, bytes.Count(buf.Bytes(), []byte("about to expire")))
+
+	// until it is renewed and about to expire again
+	webhooks[0].Until = testNow.Add(45 * time.Second)
+	e.Update(webhooks)
+	assert.Equal(2, bytes.Count(buf.Bytes(), []byte("about to expire")))
+
+	e.Update(nil)
+	assert.Zero(gauge.Value())
+}
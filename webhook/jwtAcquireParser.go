@@ -0,0 +1,69 @@
+package webhook
+
+import (
+	"errors"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/spf13/cast"
+	"github.com/xmidt-org/bascule/acquire"
+)
+
+// Supported values for the JWTParserType of the Argus token acquirer.
+// These mirror the ones supported by ancla so the webhook config section stays the same.
+const (
+	simpleParserType = "simple"
+	rawParserType    = "raw"
+)
+
+var (
+	errMissingExpClaim   = errors.New("missing exp claim in jwt")
+	errUnexpectedCasting = errors.New("unexpected casting error")
+	errInvalidParserType = errors.New("only 'simple' or 'raw' are supported as jwt acquire parser types")
+)
+
+type jwtAcquireParser struct {
+	token      acquire.TokenParser
+	expiration acquire.ParseExpiration
+}
+
+func rawTokenParser(data []byte) (string, error) {
+	return string(data), nil
+}
+
+func rawTokenExpirationParser(data []byte) (time.Time, error) {
+	p := jwt.Parser{SkipClaimsValidation: true}
+	token, _, err := p.ParseUnverified(string(data), jwt.MapClaims{})
+	if err != nil {
+		return time.Time{}, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return time.Time{}, errUnexpectedCasting
+	}
+	expVal, ok := claims["exp"]
+	if !ok {
+		return time.Time{}, errMissingExpClaim
+	}
+
+	exp, err := cast.ToInt64E(expVal)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(exp, 0), nil
+}
+
+func newJWTAcquireParser(parserType string) (jwtAcquireParser, error) {
+	if parserType == "" {
+		parserType = simpleParserType
+	}
+
+	switch parserType {
+	case simpleParserType:
+		// nil parsers make bascule/acquire fall back to its default simple ones
+		return jwtAcquireParser{}, nil
+	case rawParserType:
+		return jwtAcquireParser{token: rawTokenParser, expiration: rawTokenExpirationParser}, nil
+	}
+	return jwtAcquireParser{}, errInvalidParserType
+}
@@ -0,0 +1,36 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewJWTAcquireParser(t *testing.T) {
+	assert := assert.New(t)
+
+	p, err := newJWTAcquireParser("")
+	assert.Nil(err)
+	assert.Nil(p.token)
+
+	p, err = newJWTAcquireParser(rawParserType)
+	assert.Nil(err)
+	assert.NotNil(p.token)
+	assert.NotNil(p.expiration)
+
+	_, err = newJWTAcquireParser("fancy")
+	assert.Equal(errInvalidParserType, err)
+}
+
+func TestRawTokenExpirationParser(t *testing.T) {
+	assert := assert.New(t)
+
+	// {"alg":"none"}.{"exp":1620000000}
+	exp, err := rawTokenExpirationParser([]byte("eyJhbGciOiJub25lIn0.eyJleHAiOjE2MjAwMDAwMDB9."))
+	assert.Nil(err)
+	assert.EqualValues(1620000000, exp.Unix())
+
+	// {"alg":"none"}.{}
+	_, err = rawTokenExpirationParser([]byte("eyJhbGciOiJub25lIn0.e30."))
+	assert.Equal(errMissingExpClaim, err)
+}
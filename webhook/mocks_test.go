@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/xmidt-org/ancla"
+	"github.com/xmidt-org/argus/chrysom"
+	"github.com/xmidt-org/argus/model"
+)
+
+type mockPushReader struct {
+	mock.Mock
+}
+
+func (m *mockPushReader) GetItems(ctx context.Context, owner string) (chrysom.Items, error) {
+	args := m.Called(ctx, owner)
+	items, _ := args.Get(0).(chrysom.Items)
+	return items, args.Error(1)
+}
+
+func (m *mockPushReader) PushItem(ctx context.Context, owner string, item model.Item) (chrysom.PushResult, error) {
+	args := m.Called(ctx, owner, item)
+	return args.Get(0).(chrysom.PushResult), args.Error(1)
+}
+
+func (m *mockPushReader) RemoveItem(ctx context.Context, id, owner string) (model.Item, error) {
+	args := m.Called(ctx, id, owner)
+	return args.Get(0).(model.Item), args.Error(1)
+}
+
+func (m *mockPushReader) Start(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+func (m *mockPushReader) Stop(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+type mockService struct {
+	mock.Mock
+}
+
+func (m *mockService) Add(ctx context.Context, owner string, w ancla.Webhook) error {
+	return m.Called(ctx, owner, w).Error(0)
+}
+
+func (m *mockService) AllWebhooks(ctx context.Context) ([]ancla.Webhook, error) {
+	args := m.Called(ctx)
+	webhooks, _ := args.Get(0).([]ancla.Webhook)
+	return webhooks, args.Error(1)
+}
+
+func (m *mockService) Registrations(ctx context.Context) ([]Registration, error) {
+	args := m.Called(ctx)
+	registrations, _ := args.Get(0).([]Registration)
+	return registrations, args.Error(1)
+}
+
+func (m *mockService) Get(ctx context.Context, id string) (Registration, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(Registration), args.Error(1)
+}
+
+func (m *mockService) Update(ctx context.Context, r Registration) error {
+	return m.Called(ctx, r).Error(0)
+}
+
+func (m *mockService) Remove(ctx context.Context, r Registration) error {
+	return m.Called(ctx, r).Error(0)
+}
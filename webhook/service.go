@@ -0,0 +1,273 @@
+package webhook
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics/provider"
+	"github.com/xmidt-org/ancla"
+	"github.com/xmidt-org/argus/chrysom"
+	"github.com/xmidt-org/argus/model"
+	"github.com/xmidt-org/webpa-common/logging"
+)
+
+// Registration is a webhook along with the bookkeeping Tr1d1um needs to
+// enforce that only its registrant can read or modify it.
+type Registration struct {
+	ancla.Webhook
+
+	// ID identifies the registration in storage. It is the SHA256 checksum of the
+	// webhook's config URL so it matches the IDs used by ancla.
+	ID string `json:"id"`
+
+	// Owner is the principal which registered the webhook.
+	Owner string `json:"owner"`
+
+	// PartnerIDs are the partners of the principal which registered the webhook.
+	PartnerIDs []string `json:"partner_ids,omitempty"`
+}
+
+// Service describes the webhook operations Tr1d1um supports. It extends the
+// ancla service so it can be used with the ancla add and list handlers.
+type Service interface {
+	ancla.Service
+
+	// Registrations lists all registrations.
+	Registrations(ctx context.Context) ([]Registration, error)
+
+	// Get returns the registration with the given ID.
+	Get(ctx context.Context, id string) (Registration, error)
+
+	// Update replaces the webhook of an existing registration.
+	Update(ctx context.Context, r Registration) error
+
+	// Remove deletes the given registration.
+	Remove(ctx context.Context, r Registration) error
+}
+
+// ID returns the storage ID of the webhook with the given config URL.
+func ID(url string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(url)))
+}
+
+type service struct {
+	argus chrysom.PushReader
+	now   func() time.Time
+}
+
+// NewService returns an Argus-backed webhook service given an Argus client.
+func NewService(argus chrysom.PushReader) Service {
+	return &service{
+		argus: argus,
+		now:   time.Now,
+	}
+}
+
+// Add stores the given webhook on behalf of owner. Partner IDs are taken from the
+// caller's token available in ctx.
+func (s *service) Add(ctx context.Context, owner string, w ancla.Webhook) error {
+	return s.push(ctx, Registration{
+		Webhook:    w,
+		ID:         ID(w.Config.URL),
+		Owner:      owner,
+		PartnerIDs: callerFromContext(ctx).partnerIDs,
+	})
+}
+
+// AllWebhooks lists the webhooks of all registrations.
+func (s *service) AllWebhooks(ctx context.Context) ([]ancla.Webhook, error) {
+	registrations, err := s.all(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := make([]ancla.Webhook, len(registrations))
+	for i, r := range registrations {
+		webhooks[i] = r.Webhook
+	}
+	return webhooks, nil
+}
+
+func (s *service) Registrations(ctx context.Context) ([]Registration, error) {
+	return s.all(ctx)
+}
+
+func (s *service) Get(ctx context.Context, id string) (Registration, error) {
+	registrations, err := s.all(ctx)
+	if err != nil {
+		return Registration{}, err
+	}
+
+	for _, r := range registrations {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return Registration{}, ErrWebhookNotFound
+}
+
+func (s *service) Update(ctx context.Context, r Registration) error {
+	return s.push(ctx, r)
+}
+
+func (s *service) Remove(ctx context.Context, r Registration) error {
+	if _, err := s.argus.RemoveItem(ctx, r.ID, r.Owner); err != nil {
+		return fmt.Errorf("%w: %v", errFailedWebhookRemove, err)
+	}
+	return nil
+}
+
+func (s *service) push(ctx context.Context, r Registration) error {
+	item, err := registrationToItem(s.now, r)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errFailedWebhookConversion, err)
+	}
+
+	result, err := s.argus.PushItem(ctx, r.Owner, item)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errFailedWebhookPush, err)
+	}
+
+	if result != chrysom.CreatedPushResult && result != chrysom.UpdatedPushResult {
+		return fmt.Errorf("%w: %s", errNonSuccessPushResult, result)
+	}
+	return nil
+}
+
+func (s *service) all(ctx context.Context) ([]Registration, error) {
+	items, err := s.argus.GetItems(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errFailedWebhooksFetch, err)
+	}
+	return itemsToRegistrations(items)
+}
+
+func registrationToItem(now func() time.Time, r Registration) (model.Item, error) {
+	encoded, err := json.Marshal(r)
+	if err != nil {
+		return model.Item{}, err
+	}
+
+	var data map[string]interface{}
+	if err = json.Unmarshal(encoded, &data); err != nil {
+		return model.Item{}, err
+	}
+
+	ttlSeconds := int64(math.Max(0, r.Until.Sub(now()).Seconds()))
+
+	return model.Item{
+		ID:   r.ID,
+		Data: data,
+		TTL:  &ttlSeconds,
+	}, nil
+}
+
+func itemToRegistration(i model.Item) (Registration, error) {
+	encoded, err := json.Marshal(i.Data)
+	if err != nil {
+		return Registration{}, err
+	}
+
+	var r Registration
+	if err = json.Unmarshal(encoded, &r); err != nil {
+		return Registration{}, err
+	}
+
+	// the storage ID is authoritative
+	r.ID = i.ID
+	return r, nil
+}
+
+func itemsToRegistrations(items []model.Item) ([]Registration, error) {
+	registrations := make([]Registration, 0, len(items))
+	for _, item := range items {
+		r, err := itemToRegistration(item)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errFailedItemConversion, err)
+		}
+		registrations = append(registrations, r)
+	}
+	return registrations, nil
+}
+
+// Initialize builds the webhook service from the same configuration ancla uses and
+// starts listening for webhook updates. Webhooks are stored in Argus unless store
+// selects a local backend. Call the returned function to stop listening.
+func Initialize(cfg ancla.Config, store StoreConfig, getLogger func(context.Context) log.Logger, watches ...ancla.Watch) (Service, func(), error) {
+	if cfg.Logger == nil {
+		cfg.Logger = log.NewNopLogger()
+	}
+
+	if cfg.MetricsProvider == nil {
+		cfg.MetricsProvider = provider.NewDiscardProvider()
+	}
+
+	listSize := cfg.MetricsProvider.NewGauge(ancla.WebhookListSizeGauge)
+	watches = append(watches, ancla.WatchFunc(func(webhooks []ancla.Webhook) {
+		listSize.Set(float64(len(webhooks)))
+	}))
+
+	listener := chrysom.ListenerFunc(func(items chrysom.Items) {
+		registrations, err := itemsToRegistrations(items)
+		if err != nil {
+			logging.Error(cfg.Logger).Log(logging.MessageKey(), "Failed to convert items to webhooks", logging.ErrorKey(), err)
+			return
+		}
+
+		webhooks := make([]ancla.Webhook, len(registrations))
+		for i, r := range registrations {
+			webhooks[i] = r.Webhook
+		}
+
+		for _, watch := range watches {
+			watch.Update(webhooks)
+		}
+	})
+
+	var (
+		pushReader chrysom.PushReader
+		err        error
+	)
+
+	switch store.Type {
+	case "", ArgusStoreType:
+		pushReader, err = newArgusClient(cfg, listener, getLogger)
+	case MemoryStoreType:
+		pushReader, err = newLocalStore("", listener, cfg.Argus.Listen.PullInterval)
+	case FileStoreType:
+		if store.File == "" {
+			return nil, nil, errMissingStoreFile
+		}
+		pushReader, err = newLocalStore(store.File, listener, cfg.Argus.Listen.PullInterval)
+	default:
+		err = errInvalidStoreType
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pushReader.Start(context.Background())
+
+	return NewService(pushReader), func() { pushReader.Stop(context.Background()) }, nil
+}
+
+func newArgusClient(cfg ancla.Config, listener chrysom.Listener, getLogger func(context.Context) log.Logger) (*chrysom.Client, error) {
+	parser, err := newJWTAcquireParser(string(cfg.JWTParserType))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Argus.Logger = cfg.Logger
+	cfg.Argus.Auth.JWT.GetToken = parser.token
+	cfg.Argus.Auth.JWT.GetExpiration = parser.expiration
+	cfg.Argus.Listen.MetricsProvider = cfg.MetricsProvider
+	cfg.Argus.Listen.Listener = listener
+
+	return chrysom.NewClient(cfg.Argus, getLogger)
+}
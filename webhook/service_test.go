@@ -0,0 +1,136 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/ancla"
+	"github.com/xmidt-org/argus/chrysom"
+	"github.com/xmidt-org/argus/model"
+	"github.com/xmidt-org/bascule"
+)
+
+var testNow = time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+
+func testWebhook() ancla.Webhook {
+	return ancla.Webhook{
+		Config: ancla.DeliveryConfig{
+			URL:         "https://receiver.example.com/events",
+			ContentType: "application/json",
+			Secret:      "shh",
+		},
+		Events:   []string{"device-status"},
+		Matcher:  ancla.MetadataMatcherConfig{DeviceID: []string{".*"}},
+		Duration: defaultWebhookExpiration,
+		Until:    testNow.Add(time.Minute),
+	}
+}
+
+func partnerContext(principal string, partners ...interface{}) context.Context {
+	attrs := bascule.NewAttributes(map[string]interface{}{
+		"allowedResources": map[string]interface{}{
+			"allowedPartners": partners,
+		}})
+	return bascule.WithAuthentication(context.Background(), bascule.Authentication{
+		Token: bascule.NewToken("jwt", principal, attrs),
+	})
+}
+
+func newTestService(m *mockPushReader) *service {
+	return &service{argus: m, now: func() time.Time { return testNow }}
+}
+
+func TestAdd(t *testing.T) {
+	assert := assert.New(t)
+	m := new(mockPushReader)
+	s := newTestService(m)
+	ctx := partnerContext("client0", "partnerA")
+
+	m.On("PushItem", ctx, "client0", mock.MatchedBy(func(i model.Item) bool {
+		return i.ID == ID(testWebhook().Config.URL) &&
+			*i.TTL == 60 &&
+			i.Data["owner"] == "client0" &&
+			assert.ElementsMatch([]interface{}{"partnerA"}, i.Data["partner_ids"])
+	})).Return(chrysom.CreatedPushResult, nil)
+
+	assert.Nil(s.Add(ctx, "client0", testWebhook()))
+	m.AssertExpectations(t)
+}
+
+func TestAddFailures(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Result      chrysom.PushResult
+		Err         error
+		ExpectedErr error
+	}{
+		{
+			Name:        "Push error",
+			Err:         errors.New("argus down"),
+			ExpectedErr: errFailedWebhookPush,
+		},
+		{
+			Name:        "Unexpected result",
+			Result:      chrysom.PushResult("weird"),
+			ExpectedErr: errNonSuccessPushResult,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			m := new(mockPushReader)
+			s := newTestService(m)
+			m.On("PushItem", mock.Anything, "client0", mock.Anything).Return(testCase.Result, testCase.Err)
+			assert.True(t, errors.Is(s.Add(context.Background(), "client0", testWebhook()), testCase.ExpectedErr))
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	require := require.New(t)
+	assert := assert.New(t)
+	m := new(mockPushReader)
+	s := newTestService(m)
+
+	item, err := registrationToItem(s.now, Registration{Webhook: testWebhook(), ID: "abc", Owner: "client0"})
+	require.NoError(err)
+	m.On("GetItems", mock.Anything, "").Return(chrysom.Items{item}, nil)
+
+	r, err := s.Get(context.Background(), "abc")
+	assert.Nil(err)
+	assert.Equal("client0", r.Owner)
+	assert.Equal(testWebhook().Config.URL, r.Config.URL)
+
+	_, err = s.Get(context.Background(), "xyz")
+	assert.Equal(ErrWebhookNotFound, err)
+
+	webhooks, err := s.AllWebhooks(context.Background())
+	assert.Nil(err)
+	assert.Len(webhooks, 1)
+}
+
+func TestGetFailure(t *testing.T) {
+	m := new(mockPushReader)
+	s := newTestService(m)
+	m.On("GetItems", mock.Anything, "").Return(nil, errors.New("argus down"))
+
+	_, err := s.Get(context.Background(), "abc")
+	assert.True(t, errors.Is(err, errFailedWebhooksFetch))
+}
+
+func TestRemove(t *testing.T) {
+	assert := assert.New(t)
+	m := new(mockPushReader)
+	s := newTestService(m)
+
+	m.On("RemoveItem", mock.Anything, "abc", "client0").Return(model.Item{}, nil).Once()
+	assert.Nil(s.Remove(context.Background(), Registration{ID: "abc", Owner: "client0"}))
+
+	m.On("RemoveItem", mock.Anything, "abc", "client0").Return(model.Item{}, errors.New("argus down")).Once()
+	assert.True(errors.Is(s.Remove(context.Background(), Registration{ID: "abc", Owner: "client0"}), errFailedWebhookRemove))
+}
@@ -0,0 +1,289 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/xmidt-org/argus/chrysom"
+	"github.com/xmidt-org/argus/model"
+)
+
+// Supported webhook storage backends.
+const (
+	ArgusStoreType  = "argus"
+	MemoryStoreType = "memory"
+	FileStoreType   = "file"
+)
+
+// defaultPullInterval matches the one of the Argus client listener.
+const defaultPullInterval = 5 * time.Second
+
+var (
+	errInvalidStoreType = errors.New("webhook store type must be one of 'argus', 'memory' or 'file'")
+	errMissingStoreFile = errors.New("a file path is required for the 'file' webhook store")
+	errItemNotFound     = errors.New("item not found")
+	errItemNotOwned     = errors.New("item belongs to a different owner")
+	errListenerRunning  = errors.New("listener is already running")
+	errListenerStopped  = errors.New("listener is not running")
+)
+
+// StoreConfig selects where webhooks are stored.
+type StoreConfig struct {
+	// Type is one of 'argus', 'memory' or 'file'. The 'memory' and 'file' stores are meant
+	// for local development and testing as they are not shared between Tr1d1um instances.
+	// (Optional) Defaults to 'argus'.
+	Type string
+
+	// File is the path of the JSON file the 'file' store persists webhooks to.
+	File string
+}
+
+type storedItem struct {
+	Owner string     `json:"owner"`
+	Item  model.Item `json:"item"`
+
+	// ExpiresAt is nil for items without a TTL.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// localStore is a chrysom.PushReader which keeps items in memory and, optionally,
+// in a JSON file. As Argus does, it drops items once their TTL elapses and
+// periodically hands all items to its listener once started.
+type localStore struct {
+	file         string
+	listener     chrysom.Listener
+	pullInterval time.Duration
+	now          func() time.Time
+
+	lock  sync.Mutex
+	items map[string]storedItem
+
+	listenLock sync.Mutex
+	shutdown   chan struct{}
+}
+
+func newLocalStore(file string, listener chrysom.Listener, pullInterval time.Duration) (*localStore, error) {
+	if pullInterval <= 0 {
+		pullInterval = defaultPullInterval
+	}
+
+	s := &localStore{
+		file:         file,
+		listener:     listener,
+		pullInterval: pullInterval,
+		now:          time.Now,
+		items:        make(map[string]storedItem),
+	}
+
+	if file == "" {
+		return s, nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &s.items); err != nil {
+			return nil, fmt.Errorf("failed to decode webhook store file %s: %v", file, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *localStore) GetItems(_ context.Context, owner string) (chrysom.Items, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.expire()
+
+	ids := make([]string, 0, len(s.items))
+	for id, i := range s.items {
+		if owner == "" || owner == i.Owner {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	items := make(chrysom.Items, len(ids))
+	for n, id := range ids {
+		items[n] = s.withRemainingTTL(s.items[id])
+	}
+	return items, nil
+}
+
+func (s *localStore) PushItem(_ context.Context, owner string, item model.Item) (chrysom.PushResult, error) {
+	if item.ID == "" {
+		return "", chrysom.ErrItemIDEmpty
+	}
+
+	if len(item.Data) == 0 {
+		return "", chrysom.ErrItemDataEmpty
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.expire()
+
+	result := chrysom.CreatedPushResult
+	if existing, ok := s.items[item.ID]; ok {
+		if existing.Owner != owner {
+			return "", errItemNotOwned
+		}
+		result = chrysom.UpdatedPushResult
+	}
+
+	stored := storedItem{Owner: owner, Item: item}
+	if item.TTL != nil {
+		expiresAt := s.now().Add(time.Duration(*item.TTL) * time.Second)
+		stored.ExpiresAt = &expiresAt
+	}
+	s.items[item.ID] = stored
+
+	if err := s.persist(); err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+func (s *localStore) RemoveItem(_ context.Context, id, owner string) (model.Item, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.expire()
+
+	existing, ok := s.items[id]
+	if !ok {
+		return model.Item{}, errItemNotFound
+	}
+
+	if existing.Owner != owner {
+		return model.Item{}, errItemNotOwned
+	}
+
+	delete(s.items, id)
+	if err := s.persist(); err != nil {
+		return model.Item{}, err
+	}
+	return s.withRemainingTTL(existing), nil
+}
+
+// Start begins handing all items to the listener every pull interval.
+func (s *localStore) Start(context.Context) error {
+	s.listenLock.Lock()
+	defer s.listenLock.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+
+	if s.shutdown != nil {
+		return errListenerRunning
+	}
+
+	shutdown := make(chan struct{})
+	s.shutdown = shutdown
+
+	go func() {
+		ticker := time.NewTicker(s.pullInterval)
+		defer ticker.Stop()
+
+		for {
+			items, _ := s.GetItems(context.Background(), "")
+			s.listener.Update(items)
+
+			select {
+			case <-shutdown:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop ends the updates to the listener.
+func (s *localStore) Stop(context.Context) error {
+	s.listenLock.Lock()
+	defer s.listenLock.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+
+	if s.shutdown == nil {
+		return errListenerStopped
+	}
+
+	close(s.shutdown)
+	s.shutdown = nil
+	return nil
+}
+
+// expire drops the items whose TTL elapsed. The lock must be held.
+func (s *localStore) expire() {
+	now := s.now()
+	expired := false
+	for id, i := range s.items {
+		if i.ExpiresAt != nil && !now.Before(*i.ExpiresAt) {
+			delete(s.items, id)
+			expired = true
+		}
+	}
+
+	if expired {
+		// the file is brought up to date on the next successful write otherwise
+		s.persist()
+	}
+}
+
+func (s *localStore) withRemainingTTL(i storedItem) model.Item {
+	item := i.Item
+	if i.ExpiresAt != nil {
+		ttl := int64(i.ExpiresAt.Sub(s.now()).Seconds())
+		item.TTL = &ttl
+	}
+	return item
+}
+
+// persist writes all items to the store file, if any. The lock must be held.
+func (s *localStore) persist() error {
+	if s.file == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.items, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so a crash never leaves a truncated store behind
+	tmp, err := ioutil.TempFile(filepath.Dir(s.file), filepath.Base(s.file)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.file)
+}
@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/ancla"
+	"github.com/xmidt-org/argus/chrysom"
+	"github.com/xmidt-org/argus/model"
+)
+
+func testItem(id string, ttl int64) model.Item {
+	return model.Item{ID: id, Data: map[string]interface{}{"id": id}, TTL: &ttl}
+}
+
+func TestLocalStorePushAndExpiry(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	now := testNow
+	s, err := newLocalStore("", nil, 0)
+	require.NoError(err)
+	s.now = func() time.Time { return now }
+
+	result, err := s.PushItem(context.Background(), "client0", testItem("a", 60))
+	assert.Nil(err)
+	assert.Equal(chrysom.CreatedPushResult, result)
+
+	result, err = s.PushItem(context.Background(), "client0", testItem("a", 120))
+	assert.Nil(err)
+	assert.Equal(chrysom.UpdatedPushResult, result)
+
+	_, err = s.PushItem(context.Background(), "client1", testItem("a", 60))
+	assert.Equal(errItemNotOwned, err)
+
+	_, err = s.PushItem(context.Background(), "client1", testItem("b", 30))
+	assert.Nil(err)
+
+	now = now.Add(10 * time.Second)
+	items, err := s.GetItems(context.Background(), "")
+	assert.Nil(err)
+	require.Len(items, 2)
+	assert.EqualValues(110, *items[0].TTL)
+	assert.EqualValues(20, *items[1].TTL)
+
+	items, _ = s.GetItems(context.Background(), "client1")
+	assert.Len(items, 1)
+
+	now = now.Add(time.Minute)
+	items, _ = s.GetItems(context.Background(), "")
+	require.Len(items, 1)
+	assert.Equal("a", items[0].ID)
+
+	_, err = s.RemoveItem(context.Background(), "b", "client1")
+	assert.Equal(errItemNotFound, err)
+
+	_, err = s.RemoveItem(context.Background(), "a", "client1")
+	assert.Equal(errItemNotOwned, err)
+
+	removed, err := s.RemoveItem(context.Background(), "a", "client0")
+	assert.Nil(err)
+	assert.Equal("a", removed.ID)
+}
+
+func TestLocalStoreFile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "webhooks")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "webhooks.json")
+
+	s, err := newLocalStore(file, nil, 0)
+	require.NoError(err)
+	_, err = s.PushItem(context.Background(), "client0", testItem("a", 60))
+	require.NoError(err)
+
+	reopened, err := newLocalStore(file, nil, 0)
+	require.NoError(err)
+	items, err := reopened.GetItems(context.Background(), "client0")
+	assert.Nil(err)
+	require.Len(items, 1)
+	assert.Equal("a", items[0].ID)
+}
+
+func TestLocalStoreListener(t *testing.T) {
+	assert := assert.New(t)
+	updates := make(chan chrysom.Items, 1)
+	s, err := newLocalStore("", chrysom.ListenerFunc(func(items chrysom.Items) { updates <- items }), time.Hour)
+	assert.Nil(err)
+
+	assert.Nil(s.Start(context.Background()))
+	assert.Equal(errListenerRunning, s.Start(context.Background()))
+	assert.Len(<-updates, 0)
+
+	assert.Nil(s.Stop(context.Background()))
+	assert.Equal(errListenerStopped, s.Stop(context.Background()))
+}
+
+func TestInitializeLocalStore(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	_, _, err := Initialize(ancla.Config{}, StoreConfig{Type: "redis"}, nil)
+	assert.Equal(errInvalidStoreType, err)
+
+	_, _, err = Initialize(ancla.Config{}, StoreConfig{Type: FileStoreType}, nil)
+	assert.Equal(errMissingStoreFile, err)
+
+	svc, stop, err := Initialize(ancla.Config{}, StoreConfig{Type: MemoryStoreType}, nil)
+	require.NoError(err)
+	defer stop()
+
+	w := testWebhook()
+	w.Until = time.Now().Add(time.Minute)
+	require.NoError(svc.Add(partnerContext("client0", "partnerA"), "client0", w))
+
+	webhooks, err := svc.AllWebhooks(context.Background())
+	assert.Nil(err)
+	require.Len(webhooks, 1)
+	assert.Equal(w.Config.URL, webhooks[0].Config.URL)
+}
@@ -0,0 +1,323 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics/provider"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+	"github.com/justinas/alice"
+	"github.com/xmidt-org/ancla"
+	"github.com/xmidt-org/tr1d1um/audit"
+	"github.com/xmidt-org/tr1d1um/common"
+)
+
+const (
+	contentTypeHeaderKey = "Content-Type"
+
+	// defaultWebhookExpiration matches the fixed webhook duration ancla enforces.
+	defaultWebhookExpiration = 5 * time.Minute
+
+	obfuscatedSecret = "<obfuscated>"
+)
+
+// Query parameters supported by the webhook listing.
+const (
+	eventFilterQueryKey   = "event"
+	urlFilterQueryKey     = "url"
+	expiresBeforeQueryKey = "expiresBefore"
+	expiresAfterQueryKey  = "expiresAfter"
+)
+
+// Options wraps the properties needed to set up the webhook server
+type Options struct {
+	S Service
+
+	//APIRouter is assumed to be a subrouter with the API prefix path (i.e. 'api/v2')
+	APIRouter                   *mux.Router
+	Authenticate                *alice.Chain
+	Log                         kitlog.Logger
+	ReducedLoggingResponseCodes []int
+
+	//MetricsProvider helps initialize the metrics of the ancla handlers.
+	//(Optional) Defaults to a discard provider.
+	MetricsProvider provider.Provider
+
+	//AdminCapability is the token capability which allows listing the webhooks of
+	//all principals and partners.
+	//(Optional) If unset, callers only see the webhooks they own.
+	AdminCapability string
+
+	//Validation configures the checks new and updated webhooks go through.
+	//Note that its zero value rejects URLs that point to non-public addresses.
+	Validation ValidationOptions
+
+	//PartnerPolicy configures how partner IDs are derived from tokens and headers.
+	//(Optional) By default, token partner IDs are preferred over header ones.
+	PartnerPolicy *common.PartnerPolicy
+
+	//Audit records webhook registrations and updates along with their outcome.
+	//(Optional) By default, nothing is recorded.
+	Audit audit.Sink
+
+	//Runtime, when set, overrides ReducedLoggingResponseCodes with the ones of its current snapshot.
+	//(Optional)
+	Runtime *common.Runtime
+}
+
+// ConfigHandler sets up the server that powers the webhook service
+// That is, it configures the mux paths to register, list, fetch, update, renew and remove webhooks.
+// Listings only include the webhooks owned by the caller unless it has the admin capability.
+func ConfigHandler(c *Options) {
+	if c.MetricsProvider == nil {
+		c.MetricsProvider = provider.NewDiscardProvider()
+	}
+
+	transactionLogging := common.TransactionLogging(c.ReducedLoggingResponseCodes, c.Log)
+	if c.Runtime != nil {
+		transactionLogging = common.RuntimeTransactionLogging(c.Runtime, c.Log)
+	}
+
+	opts := []kithttp.ServerOption{
+		kithttp.ServerBefore(common.Capture(c.Log)),
+		kithttp.ServerErrorEncoder(common.ErrorLogEncoder(c.Log, encodeError)),
+		kithttp.ServerFinalizer(transactionLogging),
+	}
+
+	v := newValidator(c.Validation)
+
+	listHandler := kithttp.NewServer(
+		makeListWebhooksEndpoint(c.S),
+		decodeListWebhooksRequest(c.AdminCapability, c.PartnerPolicy),
+		encodeRegistrationsResponse,
+		opts...,
+	)
+
+	getHandler := kithttp.NewServer(
+		makeGetWebhookEndpoint(c.S),
+		decodeWebhookRequest(c.PartnerPolicy),
+		encodeRegistrationResponse,
+		opts...,
+	)
+
+	updateHandler := kithttp.NewServer(
+		makeUpdateWebhookEndpoint(c.S, v),
+		decodeUpdateWebhookRequest(time.Now, c.PartnerPolicy),
+		encodeRegistrationResponse,
+		opts...,
+	)
+
+	renewHandler := kithttp.NewServer(
+		makeRenewWebhookEndpoint(c.S, time.Now),
+		decodeWebhookRequest(c.PartnerPolicy),
+		encodeRegistrationResponse,
+		opts...,
+	)
+
+	removeHandler := kithttp.NewServer(
+		makeRemoveWebhookEndpoint(c.S),
+		decodeWebhookRequest(c.PartnerPolicy),
+		encodeRegistrationResponse,
+		opts...,
+	)
+
+	addWebhookHandler := ancla.NewAddWebhookHandler(c.S, ancla.HandlerConfig{MetricsProvider: c.MetricsProvider})
+
+	auditRegistration := audit.Middleware(c.Audit, c.Log, describeRegistration(RegisterOperation, c.PartnerPolicy))
+	auditUpdate := audit.Middleware(c.Audit, c.Log, describeRegistration(UpdateOperation, c.PartnerPolicy))
+
+	c.APIRouter.Handle("/hook", c.Authenticate.Then(auditRegistration(v.validating(c.Log, addWebhookHandler)))).Methods(http.MethodPost)
+	c.APIRouter.Handle("/hooks", c.Authenticate.Then(common.Welcome(listHandler))).Methods(http.MethodGet)
+
+	c.APIRouter.Handle("/hook/{id}", c.Authenticate.Then(common.Welcome(getHandler))).Methods(http.MethodGet)
+	c.APIRouter.Handle("/hook/{id}", c.Authenticate.Then(auditUpdate(common.Welcome(updateHandler)))).Methods(http.MethodPut)
+	c.APIRouter.Handle("/hook/{id}", c.Authenticate.Then(common.Welcome(removeHandler))).Methods(http.MethodDelete)
+	c.APIRouter.Handle("/hook/{id}/renew", c.Authenticate.Then(common.Welcome(renewHandler))).Methods(http.MethodPost)
+}
+
+func decodeWebhookRequest(partnerPolicy *common.PartnerPolicy) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		id := strings.TrimSpace(mux.Vars(r)["id"])
+		if id == "" {
+			return nil, ErrMissingWebhookID
+		}
+
+		c, err := callerFromRequest(ctx, r.Header, "", partnerPolicy)
+		if err != nil {
+			return nil, err
+		}
+
+		return &webhookRequest{
+			ID:     id,
+			caller: c,
+		}, nil
+	}
+}
+
+func decodeListWebhooksRequest(adminCapability string, partnerPolicy *common.PartnerPolicy) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		var (
+			query = r.URL.Query()
+			f     = listFilter{url: query.Get(urlFilterQueryKey)}
+			err   error
+		)
+
+		if event := query.Get(eventFilterQueryKey); event != "" {
+			if f.event, err = regexp.Compile(event); err != nil {
+				return nil, ErrInvalidEventFilter
+			}
+		}
+
+		if f.expiresBefore, err = parseExpiryFilter(query.Get(expiresBeforeQueryKey)); err != nil {
+			return nil, err
+		}
+
+		if f.expiresAfter, err = parseExpiryFilter(query.Get(expiresAfterQueryKey)); err != nil {
+			return nil, err
+		}
+
+		c, err := callerFromRequest(ctx, r.Header, adminCapability, partnerPolicy)
+		if err != nil {
+			return nil, err
+		}
+
+		return &listWebhooksRequest{
+			caller: c,
+			filter: f,
+		}, nil
+	}
+}
+
+func parseExpiryFilter(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, ErrInvalidExpiryFilter
+	}
+	return t, nil
+}
+
+// decodeUpdateWebhookRequest reads webhook updates. They go through the validator
+// in the endpoint, once ownership is established, so that probes are only sent on
+// behalf of the owners of webhooks.
+func decodeUpdateWebhookRequest(now func() time.Time, partnerPolicy *common.PartnerPolicy) kithttp.DecodeRequestFunc {
+	decodeRequest := decodeWebhookRequest(partnerPolicy)
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		req, err := decodeRequest(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+
+		payload, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		var w ancla.Webhook
+		if err = json.Unmarshal(payload, &w); err != nil {
+			return nil, ErrInvalidWebhook
+		}
+
+		wr := req.(*webhookRequest)
+		if err = validateWebhook(&w, wr.ID, r.RemoteAddr, now); err != nil {
+			return nil, err
+		}
+
+		return &updateWebhookRequest{
+			webhookRequest: *wr,
+			Webhook:        w,
+		}, nil
+	}
+}
+
+// validateWebhook applies the same validations and defaults ancla does on new webhooks
+// and additionally ensures the webhook still maps to the given ID.
+func validateWebhook(w *ancla.Webhook, id, requestOriginAddress string, now func() time.Time) error {
+	if strings.TrimSpace(w.Config.URL) == "" {
+		return ErrInvalidConfigURL
+	}
+
+	if ID(w.Config.URL) != id {
+		return ErrWebhookIDMismatch
+	}
+
+	if len(w.Events) == 0 {
+		return ErrInvalidEvents
+	}
+
+	if len(w.Matcher.DeviceID) == 0 {
+		w.Matcher.DeviceID = []string{".*"} // match anything
+	}
+
+	if w.Address == "" && requestOriginAddress != "" {
+		if host, _, err := net.SplitHostPort(requestOriginAddress); err == nil {
+			w.Address = host
+		}
+	}
+
+	w.Duration = defaultWebhookExpiration
+	if w.Until.IsZero() {
+		w.Until = now().Add(w.Duration)
+	}
+
+	return nil
+}
+
+func encodeRegistrationResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set(contentTypeHeaderKey, "application/json")
+	w.Header().Set(common.HeaderWPATID, ctx.Value(common.ContextKeyRequestTID).(string))
+	return json.NewEncoder(w).Encode(newRegistrationResponse(response.(Registration), time.Now()))
+}
+
+// registrationResponse is a registration as returned to API consumers.
+type registrationResponse struct {
+	Registration
+
+	// ExpiresIn is the number of seconds left before the webhook expires.
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+func newRegistrationResponse(r Registration, now time.Time) registrationResponse {
+	r.Config.Secret = obfuscatedSecret
+
+	expiresIn := int64(r.Until.Sub(now).Seconds())
+	if expiresIn < 0 {
+		expiresIn = 0
+	}
+
+	return registrationResponse{
+		Registration: r,
+		ExpiresIn:    expiresIn,
+	}
+}
+
+func encodeRegistrationsResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	var (
+		now           = time.Now()
+		registrations = response.([]Registration)
+		resp          = make([]registrationResponse, len(registrations))
+	)
+
+	for i, r := range registrations {
+		resp[i] = newRegistrationResponse(r, now)
+	}
+
+	w.Header().Set(contentTypeHeaderKey, "application/json")
+	w.Header().Set(common.HeaderWPATID, ctx.Value(common.ContextKeyRequestTID).(string))
+	return json.NewEncoder(w).Encode(resp)
+}
+
+func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	common.WriteError(ctx, err, w)
+}
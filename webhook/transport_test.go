@@ -0,0 +1,253 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/justinas/alice"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/bascule"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/webpa-common/logging"
+)
+
+var ctxTID = context.WithValue(context.Background(), common.ContextKeyRequestTID, "testTID")
+
+func TestDecodeWebhookRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "http://localhost/hook/abc", nil), map[string]string{"id": "abc"})
+	req, err := decodeWebhookRequest(nil)(partnerContext("client0", "partnerA"), r)
+	assert.Nil(err)
+	assert.Equal(&webhookRequest{ID: "abc", caller: caller{principal: "client0", partnerIDs: []string{"partnerA"}}}, req)
+
+	r = mux.SetURLVars(httptest.NewRequest(http.MethodGet, "http://localhost/hook/", nil), map[string]string{"id": " "})
+	_, err = decodeWebhookRequest(nil)(ctxTID, r)
+	assert.Equal(ErrMissingWebhookID, err)
+
+	r = mux.SetURLVars(httptest.NewRequest(http.MethodGet, "http://localhost/hook/abc", nil), map[string]string{"id": "abc"})
+	r.Header.Set("X-Xmidt-Partner-Id", "partnerB")
+	_, err = decodeWebhookRequest(&common.PartnerPolicy{EnforceHeaderSubset: true})(partnerContext("client0", "partnerA"), r)
+	assert.Equal(http.StatusForbidden, err.(common.CodedError).StatusCode())
+}
+
+func TestSpoofedPartnerHeader(t *testing.T) {
+	s := new(mockService)
+	existing := Registration{ID: "abc", Owner: "client0", PartnerIDs: []string{"partnerA"}}
+	s.On("Get", mock.Anything, "abc").Return(existing, nil)
+	s.On("Registrations", mock.Anything).Return([]Registration{existing}, nil)
+
+	// the caller authenticates without token partner IDs
+	authenticate := alice.New(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth := bascule.Authentication{Token: bascule.NewToken("basic", "client1", bascule.NewAttributes(nil))}
+			next.ServeHTTP(w, r.WithContext(bascule.WithAuthentication(r.Context(), auth)))
+		})
+	})
+
+	router := mux.NewRouter()
+	ConfigHandler(&Options{S: s, APIRouter: router, Authenticate: &authenticate, Log: logging.NewTestLogger(nil, t)})
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			r := httptest.NewRequest(method, "http://localhost/hook/abc", nil)
+			r.Header.Set("X-Xmidt-Partner-Id", "partnerA")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+
+	t.Run("List", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "http://localhost/hooks", nil)
+		r.Header.Set("X-Xmidt-Partner-Id", "partnerA")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[]`, w.Body.String())
+	})
+	s.AssertNotCalled(t, "Remove", mock.Anything, mock.Anything)
+}
+
+func TestDecodeUpdateWebhookRequest(t *testing.T) {
+	now := func() time.Time { return testNow }
+	url := "https://receiver.example.com/events"
+
+	testCases := []struct {
+		Name        string
+		ID          string
+		Body        string
+		ExpectedErr error
+	}{
+		{
+			Name:        "Invalid JSON",
+			ID:          ID(url),
+			Body:        "{",
+			ExpectedErr: ErrInvalidWebhook,
+		},
+		{
+			Name:        "Missing URL",
+			ID:          ID(url),
+			Body:        `{"events": ["device-status"]}`,
+			ExpectedErr: ErrInvalidConfigURL,
+		},
+		{
+			Name:        "URL changed",
+			ID:          ID("https://other.example.com"),
+			Body:        `{"config": {"url": "` + url + `"}, "events": ["device-status"]}`,
+			ExpectedErr: ErrWebhookIDMismatch,
+		},
+		{
+			Name:        "Missing events",
+			ID:          ID(url),
+			Body:        `{"config": {"url": "` + url + `"}}`,
+			ExpectedErr: ErrInvalidEvents,
+		},
+		{
+			Name: "Valid",
+			ID:   ID(url),
+			Body: `{"config": {"url": "` + url + `"}, "events": ["device-status"]}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			r := httptest.NewRequest(http.MethodPut, "http://localhost/hook/"+testCase.ID, bytes.NewBufferString(testCase.Body))
+			r = mux.SetURLVars(r, map[string]string{"id": testCase.ID})
+
+			req, err := decodeUpdateWebhookRequest(now, nil)(ctxTID, r)
+			assert.Equal(testCase.ExpectedErr, err)
+			if testCase.ExpectedErr != nil {
+				return
+			}
+
+			w := req.(*updateWebhookRequest).Webhook
+			assert.Equal([]string{".*"}, w.Matcher.DeviceID)
+			assert.Equal("192.0.2.1", w.Address)
+			assert.Equal(testNow.Add(defaultWebhookExpiration), w.Until)
+		})
+	}
+}
+
+func TestDecodeListWebhooksRequest(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Query          string
+		ExpectedFilter listFilter
+		ExpectedErr    error
+	}{
+		{
+			Name: "No filters",
+		},
+		{
+			Name:           "All filters",
+			Query:          "event=device-status&url=example.com&expiresBefore=2021-05-01T10:00:00Z&expiresAfter=2021-04-01T10:00:00Z",
+			ExpectedFilter: listFilter{event: regexp.MustCompile("device-status"), url: "example.com", expiresBefore: time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC), expiresAfter: time.Date(2021, 4, 1, 10, 0, 0, 0, time.UTC)},
+		},
+		{
+			Name:        "Invalid event regex",
+			Query:       "event=(device",
+			ExpectedErr: ErrInvalidEventFilter,
+		},
+		{
+			Name:        "Invalid expiry",
+			Query:       "expiresBefore=tomorrow",
+			ExpectedErr: ErrInvalidExpiryFilter,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			r := httptest.NewRequest(http.MethodGet, "http://localhost/hooks?"+testCase.Query, nil)
+			req, err := decodeListWebhooksRequest("hooks:admin", nil)(partnerContext("client0", "partnerA"), r)
+			assert.Equal(testCase.ExpectedErr, err)
+			if err != nil {
+				return
+			}
+
+			listReq := req.(*listWebhooksRequest)
+			assert.Equal(testCase.ExpectedFilter, listReq.filter)
+			assert.Equal(caller{principal: "client0", partnerIDs: []string{"partnerA"}}, listReq.caller)
+		})
+	}
+}
+
+func TestEncodeRegistrationsResponse(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	w := httptest.NewRecorder()
+
+	r := Registration{Webhook: testWebhook(), ID: "abc"}
+	r.Until = time.Now().Add(time.Hour)
+	err := encodeRegistrationsResponse(ctxTID, w, []Registration{r})
+	require.NoError(err)
+
+	var resp []registrationResponse
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(resp, 1)
+	assert.Equal(obfuscatedSecret, resp[0].Config.Secret)
+	assert.InDelta(3600, resp[0].ExpiresIn, 5)
+}
+
+func TestEncodeRegistrationResponse(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+	w := httptest.NewRecorder()
+
+	err := encodeRegistrationResponse(ctxTID, w, Registration{Webhook: testWebhook(), ID: "abc", Owner: "client0"})
+	require.NoError(err)
+
+	var r registrationResponse
+	require.NoError(json.Unmarshal(w.Body.Bytes(), &r))
+	assert.Equal(obfuscatedSecret, r.Config.Secret)
+	assert.Equal("abc", r.ID)
+	assert.Zero(r.ExpiresIn)
+	assert.Equal("testTID", w.Header().Get(common.HeaderWPATID))
+}
+
+func TestEncodeError(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		Err             error
+		ExpectedCode    int
+		ExpectedMessage string
+	}{
+		{
+			Name:            "Coded",
+			Err:             ErrWebhookNotOwned,
+			ExpectedCode:    http.StatusForbidden,
+			ExpectedMessage: ErrWebhookNotOwned.Error(),
+		},
+		{
+			Name:            "Internal",
+			Err:             errors.New("argus is down"),
+			ExpectedCode:    http.StatusInternalServerError,
+			ExpectedMessage: common.ErrTr1d1umInternal.Error(),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			w := httptest.NewRecorder()
+			encodeError(ctxTID, testCase.Err, w)
+
+			var body map[string]string
+			assert.Nil(json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(testCase.ExpectedCode, w.Code)
+			assert.Equal(testCase.ExpectedMessage, body["message"])
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/xmidt-org/ancla"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/wrp-go/v3"
+)
+
+// Headers set on the test event sent to probe a webhook callback.
+// The signature follows the format caduceus uses for regular events.
+const (
+	signatureHeader = "X-Webpa-Signature"
+	probeHeader     = "X-Tr1d1um-Webhook-Probe"
+)
+
+// DefaultProbeTimeout is the time allowed to a webhook callback to answer the test event
+// when ValidationOptions.ProbeTimeout is unset.
+const DefaultProbeTimeout = 5 * time.Second
+
+const probeDestination = "event:webhook-probe"
+
+// ValidationOptions configures the checks webhooks go through before being stored.
+type ValidationOptions struct {
+	// Schemes are the URL schemes allowed for the config, alternative and failure URLs.
+	// (Optional) By default, any scheme is allowed.
+	Schemes []string
+
+	// AllowPrivateAddresses allows URLs whose host is or resolves to a loopback, private,
+	// link-local or otherwise non-public address, including NAT64 and 6to4 addresses
+	// embedding one.
+	// (Optional) Defaults to false.
+	AllowPrivateAddresses bool
+
+	// Probe enables sending a signed test event to the config URL. Webhooks whose
+	// callback does not answer with a 2xx status code are rejected.
+	// (Optional) Defaults to false.
+	Probe bool
+
+	// ProbeTimeout bounds the time allowed to the callback to answer the test event.
+	// (Optional) Defaults to 5s.
+	ProbeTimeout time.Duration
+
+	// ProbeSource is the WRP source of the test event.
+	// (Optional)
+	ProbeSource string
+}
+
+// nonPublicNetworks are the address blocks webhook URLs may not point to unless allowed.
+var nonPublicNetworks = parseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"255.255.255.255/32",
+	"::/128",
+	"::1/128",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+// NAT64 and 6to4 addresses embed an IPv4 address, which gateways translate them to.
+var (
+	nat64Network = parseCIDRs("64:ff9b::/96")[0]
+	sixToFour    = parseCIDRs("2002::/16")[0]
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+func isPublic(ip net.IP) bool {
+	if embedded := embeddedIPv4(ip); embedded != nil && !isPublic(embedded) {
+		return false
+	}
+
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// embeddedIPv4 returns the IPv4 address embedded in NAT64 and 6to4 addresses, if any.
+func embeddedIPv4(ip net.IP) net.IP {
+	if ip.To4() != nil {
+		return nil
+	}
+
+	switch ip = ip.To16(); {
+	case ip == nil:
+		return nil
+	case nat64Network.Contains(ip):
+		return net.IPv4(ip[12], ip[13], ip[14], ip[15])
+	case sixToFour.Contains(ip):
+		return net.IPv4(ip[2], ip[3], ip[4], ip[5])
+	}
+	return nil
+}
+
+type validator struct {
+	schemes      map[string]bool
+	allowPrivate bool
+	lookupIPAddr func(context.Context, string) ([]net.IPAddr, error)
+
+	// probeClient is nil when probing is disabled
+	probeClient *http.Client
+	probeSource string
+	dialer      *net.Dialer
+}
+
+func newValidator(o ValidationOptions) *validator {
+	v := &validator{
+		allowPrivate: o.AllowPrivateAddresses,
+		lookupIPAddr: net.DefaultResolver.LookupIPAddr,
+		probeSource:  o.ProbeSource,
+		dialer:       new(net.Dialer),
+	}
+
+	if len(o.Schemes) > 0 {
+		v.schemes = make(map[string]bool, len(o.Schemes))
+		for _, s := range o.Schemes {
+			v.schemes[strings.ToLower(strings.TrimSpace(s))] = true
+		}
+	}
+
+	if o.Probe {
+		if o.ProbeTimeout <= 0 {
+			o.ProbeTimeout = DefaultProbeTimeout
+		}
+
+		v.probeClient = &http.Client{
+			Timeout: o.ProbeTimeout,
+			// a redirect could point the probe to an address that did not go through validation
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	return v
+}
+
+// validate checks the matchers and URLs of w and, if enabled, probes its callback.
+func (v *validator) validate(ctx context.Context, w ancla.Webhook) error {
+	for _, e := range w.Events {
+		if _, err := regexp.Compile(e); err != nil {
+			return common.NewBadRequestError(fmt.Errorf("invalid event matcher %q: %v", e, err))
+		}
+	}
+
+	for _, d := range w.Matcher.DeviceID {
+		if _, err := regexp.Compile(d); err != nil {
+			return common.NewBadRequestError(fmt.Errorf("invalid device ID matcher %q: %v", d, err))
+		}
+	}
+
+	configAddrs, err := v.validateURL(ctx, "config URL", w.Config.URL)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range w.Config.AlternativeURLs {
+		if _, err := v.validateURL(ctx, "alternative URL", u); err != nil {
+			return err
+		}
+	}
+
+	if w.FailureURL != "" {
+		if _, err := v.validateURL(ctx, "failure URL", w.FailureURL); err != nil {
+			return err
+		}
+	}
+
+	if v.probeClient != nil {
+		return v.probe(ctx, w, configAddrs)
+	}
+	return nil
+}
+
+// validateURL checks rawURL and returns the addresses its host resolved to, if
+// it had to be resolved.
+func (v *validator) validateURL(ctx context.Context, name, rawURL string) ([]net.IPAddr, error) {
+	u, err := url.ParseRequestURI(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, common.NewBadRequestError(fmt.Errorf("invalid %s %q", name, rawURL))
+	}
+
+	if v.schemes != nil && !v.schemes[strings.ToLower(u.Scheme)] {
+		return nil, common.NewBadRequestError(fmt.Errorf("%s scheme %q is not allowed", name, u.Scheme))
+	}
+
+	if v.allowPrivate {
+		return nil, nil
+	}
+
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if !isPublic(ip) {
+			return nil, common.NewBadRequestError(fmt.Errorf("%s host %q is not a public address", name, host))
+		}
+		return nil, nil
+	}
+
+	addrs, err := v.lookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return nil, common.NewBadRequestError(fmt.Errorf("%s host %q could not be resolved", name, host))
+	}
+
+	for _, addr := range addrs {
+		if !isPublic(addr.IP) {
+			return nil, common.NewBadRequestError(fmt.Errorf("%s host %q resolves to non-public address %s", name, host, addr.IP))
+		}
+	}
+	return addrs, nil
+}
+
+// pinnedProbeClient returns a probe client which connects to the given addresses only,
+// whatever the host of the URL resolves to by the time the probe is sent. Otherwise,
+// the host could resolve to a non-public address once validated.
+func (v *validator) pinnedProbeClient(addrs []net.IPAddr) *http.Client {
+	if len(addrs) == 0 {
+		return v.probeClient
+	}
+
+	client := *v.probeClient
+	client.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			_, port, err := net.SplitHostPort(address)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, addr := range addrs {
+				var conn net.Conn
+				if conn, err = v.dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port)); err == nil {
+					return conn, nil
+				}
+			}
+			return nil, err
+		},
+		TLSHandshakeTimeout: client.Timeout,
+		DisableKeepAlives:   true,
+	}
+	return &client
+}
+
+// probe sends a test event signed with the webhook secret to its config URL,
+// connecting to the addresses its host was validated with, if any.
+func (v *validator) probe(ctx context.Context, w ancla.Webhook, addrs []net.IPAddr) error {
+	var body []byte
+	err := wrp.NewEncoderBytes(&body, wrp.JSON).Encode(&wrp.Message{
+		Type:        wrp.SimpleEventMessageType,
+		Source:      v.probeSource,
+		Destination: probeDestination,
+		ContentType: "application/json",
+		Payload:     []byte(`{}`),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.Config.URL, bytes.NewReader(body))
+	if err != nil {
+		return common.NewBadRequestError(fmt.Errorf("invalid config URL %q", w.Config.URL))
+	}
+
+	req.Header.Set(contentTypeHeaderKey, wrp.JSON.ContentType())
+	req.Header.Set(probeHeader, "true")
+	if w.Config.Secret != "" {
+		h := hmac.New(sha1.New, []byte(w.Config.Secret))
+		h.Write(body)
+		req.Header.Set(signatureHeader, "sha1="+hex.EncodeToString(h.Sum(nil)))
+	}
+
+	resp, err := v.pinnedProbeClient(addrs).Do(req)
+	if err != nil {
+		return common.NewBadRequestError(fmt.Errorf("config URL probe failed: %v", err))
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return common.NewBadRequestError(fmt.Errorf("config URL probe failed: callback answered with status %d", resp.StatusCode))
+	}
+	return nil
+}
+
+// decodeWebhook reads a webhook from a registration payload. As ancla does, lists of
+// webhooks are accepted for backwards compatibility in which case the first one is used.
+func decodeWebhook(payload []byte) (ancla.Webhook, error) {
+	var w ancla.Webhook
+	if err := json.Unmarshal(payload, &w); err == nil {
+		return w, nil
+	}
+
+	var webhooks []ancla.Webhook
+	if err := json.Unmarshal(payload, &webhooks); err != nil || len(webhooks) == 0 {
+		return ancla.Webhook{}, ErrInvalidWebhook
+	}
+	return webhooks[0], nil
+}
+
+// validating is an Alice-style constructor that runs webhook registrations through
+// the validator before handing them to the delegate.
+func (v *validator) validating(logger kitlog.Logger, delegate http.Handler) http.Handler {
+	errorEncoder := common.ErrorLogEncoder(logger, encodeError)
+	capture := common.Capture(logger)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, err := ioutil.ReadAll(r.Body)
+		if err == nil {
+			var webhook ancla.Webhook
+			if webhook, err = decodeWebhook(payload); err == nil {
+				err = v.validate(r.Context(), webhook)
+			}
+		}
+
+		if err != nil {
+			errorEncoder(capture(r.Context(), r), err, w)
+			return
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(payload))
+		delegate.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,252 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xmidt-org/ancla"
+	"github.com/xmidt-org/tr1d1um/common"
+	"github.com/xmidt-org/webpa-common/logging"
+)
+
+func testLookup(_ context.Context, host string) ([]net.IPAddr, error) {
+	switch host {
+	case "receiver.example.com":
+		return []net.IPAddr{{IP: net.ParseIP("203.0.113.10")}}, nil
+	case "internal.example.com":
+		return []net.IPAddr{{IP: net.ParseIP("203.0.113.10")}, {IP: net.ParseIP("10.1.2.3")}}, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		Modify          func(*ancla.Webhook)
+		AllowPrivate    bool
+		ExpectedMessage string
+	}{
+		{
+			Name:   "Valid",
+			Modify: func(*ancla.Webhook) {},
+		},
+		{
+			Name:            "Invalid event matcher",
+			Modify:          func(w *ancla.Webhook) { w.Events = []string{"device-status", "(online"} },
+			ExpectedMessage: `invalid event matcher "(online"`,
+		},
+		{
+			Name:            "Invalid device ID matcher",
+			Modify:          func(w *ancla.Webhook) { w.Matcher.DeviceID = []string{"mac:[0-9"} },
+			ExpectedMessage: `invalid device ID matcher "mac:[0-9"`,
+		},
+		{
+			Name:            "Disallowed scheme",
+			Modify:          func(w *ancla.Webhook) { w.Config.URL = "http://receiver.example.com/events" },
+			ExpectedMessage: `config URL scheme "http" is not allowed`,
+		},
+		{
+			Name:            "Malformed alternative URL",
+			Modify:          func(w *ancla.Webhook) { w.Config.AlternativeURLs = []string{"receiver"} },
+			ExpectedMessage: `invalid alternative URL "receiver"`,
+		},
+		{
+			Name:            "Loopback address",
+			Modify:          func(w *ancla.Webhook) { w.Config.URL = "https://127.0.0.1:8080/events" },
+			ExpectedMessage: `config URL host "127.0.0.1" is not a public address`,
+		},
+		{
+			Name:            "NAT64 private address",
+			Modify:          func(w *ancla.Webhook) { w.Config.URL = "https://[64:ff9b::a00:1]/events" },
+			ExpectedMessage: `config URL host "64:ff9b::a00:1" is not a public address`,
+		},
+		{
+			Name:            "NAT64 loopback address",
+			Modify:          func(w *ancla.Webhook) { w.Config.URL = "https://[64:ff9b::7f00:1]/events" },
+			ExpectedMessage: `config URL host "64:ff9b::7f00:1" is not a public address`,
+		},
+		{
+			Name:            "6to4 private address",
+			Modify:          func(w *ancla.Webhook) { w.Config.URL = "https://[2002:a00:1::1]/events" },
+			ExpectedMessage: `config URL host "2002:a00:1::1" is not a public address`,
+		},
+		{
+			Name:            "6to4 loopback address",
+			Modify:          func(w *ancla.Webhook) { w.Config.URL = "https://[2002:7f00:1::1]/events" },
+			ExpectedMessage: `config URL host "2002:7f00:1::1" is not a public address`,
+		},
+		{
+			Name:            "Benchmarking address",
+			Modify:          func(w *ancla.Webhook) { w.Config.URL = "https://198.18.0.1/events" },
+			ExpectedMessage: `config URL host "198.18.0.1" is not a public address`,
+		},
+		{
+			Name:            "Reserved address",
+			Modify:          func(w *ancla.Webhook) { w.Config.URL = "https://240.0.0.1/events" },
+			ExpectedMessage: `config URL host "240.0.0.1" is not a public address`,
+		},
+		{
+			Name:            "Broadcast address",
+			Modify:          func(w *ancla.Webhook) { w.Config.URL = "https://255.255.255.255/events" },
+			ExpectedMessage: `config URL host "255.255.255.255" is not a public address`,
+		},
+		{
+			Name:            "Multicast address",
+			Modify:          func(w *ancla.Webhook) { w.Config.URL = "https://224.0.0.1/events" },
+			ExpectedMessage: `config URL host "224.0.0.1" is not a public address`,
+		},
+		{
+			Name:            "IPv6 multicast address",
+			Modify:          func(w *ancla.Webhook) { w.Config.URL = "https://[ff02::1]/events" },
+			ExpectedMessage: `config URL host "ff02::1" is not a public address`,
+		},
+		{
+			Name:            "IPv4-mapped private address",
+			Modify:          func(w *ancla.Webhook) { w.Config.URL = "https://[::ffff:10.0.0.1]/events" },
+			ExpectedMessage: `config URL host "::ffff:10.0.0.1" is not a public address`,
+		},
+		{
+			Name:   "NAT64 public address",
+			Modify: func(w *ancla.Webhook) { w.Config.URL = "https://[64:ff9b::cb00:710a]/events" },
+		},
+		{
+			Name:         "Loopback address allowed",
+			Modify:       func(w *ancla.Webhook) { w.Config.URL = "https://127.0.0.1:8080/events" },
+			AllowPrivate: true,
+		},
+		{
+			Name:            "Resolves to private address",
+			Modify:          func(w *ancla.Webhook) { w.FailureURL = "https://internal.example.com/failures" },
+			ExpectedMessage: `failure URL host "internal.example.com" resolves to non-public address 10.1.2.3`,
+		},
+		{
+			Name:            "Unresolvable host",
+			Modify:          func(w *ancla.Webhook) { w.Config.URL = "https://unknown.example.com/events" },
+			ExpectedMessage: `config URL host "unknown.example.com" could not be resolved`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			v := newValidator(ValidationOptions{Schemes: []string{"HTTPS"}, AllowPrivateAddresses: testCase.AllowPrivate})
+			v.lookupIPAddr = testLookup
+
+			w := testWebhook()
+			testCase.Modify(&w)
+			err := v.validate(context.Background(), w)
+			if testCase.ExpectedMessage == "" {
+				assert.Nil(err)
+				return
+			}
+
+			require.Implements(t, (*common.CodedError)(nil), err)
+			assert.Equal(http.StatusBadRequest, err.(common.CodedError).StatusCode())
+			assert.True(strings.HasPrefix(err.Error(), testCase.ExpectedMessage), err.Error())
+		})
+	}
+}
+
+func TestProbe(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Code        int
+		ExpectedErr bool
+	}{
+		{Name: "Accepted", Code: http.StatusAccepted},
+		{Name: "Rejected", Code: http.StatusNotFound, ExpectedErr: true},
+		{Name: "Redirected", Code: http.StatusFound, ExpectedErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			var signature, body string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				payload, _ := ioutil.ReadAll(r.Body)
+				body, signature = string(payload), r.Header.Get(signatureHeader)
+				w.Header().Set("Location", "http://192.168.1.1")
+				w.WriteHeader(testCase.Code)
+			}))
+			defer server.Close()
+
+			w := testWebhook()
+			w.Config.URL = server.URL
+			v := newValidator(ValidationOptions{AllowPrivateAddresses: true, Probe: true, ProbeSource: "dns:tr1d1um.example.com"})
+
+			err := v.validate(context.Background(), w)
+			assert.Equal(testCase.ExpectedErr, err != nil)
+
+			h := hmac.New(sha1.New, []byte("shh"))
+			h.Write([]byte(body))
+			assert.Equal("sha1="+hex.EncodeToString(h.Sum(nil)), signature)
+			assert.Contains(body, probeDestination)
+			assert.Contains(body, "dns:tr1d1um.example.com")
+		})
+	}
+}
+
+func TestPinnedProbeClient(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	v := newValidator(ValidationOptions{Probe: true})
+	assert.Equal(v.probeClient, v.pinnedProbeClient(nil))
+
+	// the probe connects to the validated address without resolving the host again
+	client := v.pinnedProbeClient([]net.IPAddr{{IP: net.ParseIP("127.0.0.1")}})
+	resp, err := client.Get("http://rebinding.invalid:" + port)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(http.StatusAccepted, resp.StatusCode)
+}
+
+func TestValidating(t *testing.T) {
+	v := newValidator(ValidationOptions{AllowPrivateAddresses: true})
+
+	testCases := []struct {
+		Name         string
+		Body         string
+		ExpectedCode int
+	}{
+		{Name: "Valid", Body: `{"config": {"url": "https://receiver.example.com"}, "events": ["device-status"]}`, ExpectedCode: http.StatusOK},
+		{Name: "Legacy list", Body: `[{"config": {"url": "https://receiver.example.com"}, "events": ["device-status"]}]`, ExpectedCode: http.StatusOK},
+		{Name: "Invalid JSON", Body: `{`, ExpectedCode: http.StatusBadRequest},
+		{Name: "Invalid matcher", Body: `{"config": {"url": "https://receiver.example.com"}, "events": ["(device"]}`, ExpectedCode: http.StatusBadRequest},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			var delegated string
+			h := v.validating(logging.NewTestLogger(nil, t), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				payload, _ := ioutil.ReadAll(r.Body)
+				delegated = string(payload)
+			}))
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "http://localhost/hook", strings.NewReader(testCase.Body)))
+			assert.Equal(testCase.ExpectedCode, w.Code)
+			if testCase.ExpectedCode == http.StatusOK {
+				assert.Equal(testCase.Body, delegated)
+			}
+		})
+	}
+}